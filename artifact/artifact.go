@@ -0,0 +1,414 @@
+// Package artifact uploads and downloads the artifacts of a workflow run, like @actions/artifact.
+//
+// Artifacts are zip archives uploaded to blob storage with signed URLs obtained from the
+// artifact service of the results service, which is called with Twirp and JSON. The zip is
+// created while it is uploaded in blocks, and extracted while it is downloaded, so artifacts
+// are never held in full on disk or in memory. Its SHA-256 digest is recorded at upload and
+// checked at download. The service
+// is located with ACTIONS_RESULTS_URL and authenticated with ACTIONS_RUNTIME_TOKEN, which also
+// identifies the workflow run and the job. Artifacts are not supported on GHES.
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// Artifact is an artifact of the workflow run.
+type Artifact struct {
+	// The name of the artifact
+	Name string
+
+	// The ID of the artifact
+	ID int64
+
+	// The size of the artifact in bytes
+	Size int64
+
+	// The time when the artifact was created, or nil if unknown
+	CreatedAt *time.Time
+
+	// The digest of the artifact, computed at time of upload, e.g. "sha256:..."
+	Digest string
+}
+
+// UploadArtifactResponse is the result of UploadArtifact.
+type UploadArtifactResponse struct {
+	// Total size of the artifact in bytes
+	Size int64
+
+	// SHA256 digest of the artifact that was created, in hexadecimal
+	Digest string
+
+	// The ID of the artifact that was created
+	ID int64
+}
+
+// FilesNotFoundError is returned by UploadArtifact when there is nothing to upload.
+type FilesNotFoundError struct {
+	Files []string
+}
+
+func (e *FilesNotFoundError) Error() string {
+	message := "No files were found to upload"
+	if len(e.Files) > 0 {
+		message += ": " + strings.Join(e.Files, ", ")
+	}
+	return message
+}
+
+// InvalidResponseError is returned when the artifact service responds unexpectedly.
+type InvalidResponseError struct {
+	Message string
+}
+
+func (e *InvalidResponseError) Error() string {
+	return e.Message
+}
+
+// ArtifactNotFoundError is returned when the artifact does not exist in the workflow run.
+type ArtifactNotFoundError struct {
+	Message string
+}
+
+func (e *ArtifactNotFoundError) Error() string {
+	if e.Message == "" {
+		return "Artifact not found"
+	}
+	return e.Message
+}
+
+// GHESNotSupportedError is returned on GitHub Enterprise Server.
+type GHESNotSupportedError struct{}
+
+func (e *GHESNotSupportedError) Error() string {
+	return "@actions/artifact v2.0.0+, upload-artifact@v4+ and download-artifact@v4+ are not currently supported on GHES."
+}
+
+// DigestMismatchError is returned by DownloadArtifact when the SHA-256 digest of the downloaded
+// zip differs from the digest computed at upload. The files have been extracted nonetheless.
+type DigestMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("The digest of the downloaded artifact %s does not match the digest computed at upload %s", e.Actual, e.Expected)
+}
+
+// UsageError is returned when the artifact storage quota of the repository is exhausted.
+type UsageError struct{}
+
+func (e *UsageError) Error() string {
+	return "Artifact storage quota has been hit. Unable to upload any new artifacts. Usage is recalculated every 6-12 hours.\nMore info on storage limits: https://docs.github.com/en/billing/managing-billing-for-github-actions/about-billing-for-github-actions#calculating-minute-and-storage-spending"
+}
+
+var errUploadStopped = errors.New("artifact upload stopped")
+
+func isUsageErrorMessage(msg string) bool {
+	return strings.Contains(msg, "insufficient usage")
+}
+
+// UploadArtifact uploads the files, which must all be under rootDirectory, as an artifact with
+// the name. Their paths within the artifact are relative to rootDirectory. options may be nil.
+func UploadArtifact(ctx context.Context, name string, files []string, rootDirectory string, options *UploadArtifactOptions) (*UploadArtifactResponse, error) {
+	if isGhes() {
+		return nil, &GHESNotSupportedError{}
+	}
+	opts, err := getUploadOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateArtifactName(name); err != nil {
+		return nil, err
+	}
+	if err := validateRootDirectory(rootDirectory); err != nil {
+		return nil, err
+	}
+
+	zipSpecification, err := getUploadZipSpecification(files, rootDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if len(zipSpecification) == 0 {
+		return nil, &FilesNotFoundError{Files: files}
+	}
+
+	ids, err := getBackendIDsFromToken()
+	if err != nil {
+		return nil, err
+	}
+	client, err := internalArtifactTwirpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := createArtifactRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		Name:                    name,
+		ExpiresAt:               getExpiration(opts.RetentionDays),
+		Version:                 4,
+	}
+	createResp, err := client.CreateArtifact(ctx, createReq)
+	if err != nil {
+		return nil, err
+	}
+	if !createResp.Ok {
+		return nil, &InvalidResponseError{Message: "CreateArtifact: response from backend was not ok"}
+	}
+
+	// The zip is created while it is uploaded
+	zipReader, zipWriter := io.Pipe()
+	zipDone := make(chan struct{})
+	go func() {
+		defer close(zipDone)
+		zipWriter.CloseWithError(createZip(zipWriter, zipSpecification, *opts.CompressionLevel))
+	}()
+	uploadSize, sha256Hash, err := uploadZipToBlobStorage(ctx, createResp.SignedUploadURL, zipReader, *opts.UploadChunkSize, *opts.UploadConcurrency)
+	// Stop creating the zip if the upload failed
+	zipReader.CloseWithError(errUploadStopped)
+	<-zipDone
+	if err != nil {
+		return nil, err
+	}
+
+	finalizeReq := finalizeArtifactRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		Name:                    name,
+		Size:                    int64String(uploadSize),
+		Hash:                    ptr.String("sha256:" + sha256Hash),
+	}
+	finalizeResp, err := client.FinalizeArtifact(ctx, finalizeReq)
+	if err != nil {
+		return nil, err
+	}
+	if !finalizeResp.Ok {
+		return nil, &InvalidResponseError{Message: "FinalizeArtifact: response from backend was not ok"}
+	}
+	return &UploadArtifactResponse{Size: uploadSize, Digest: sha256Hash, ID: int64(finalizeResp.ArtifactID)}, nil
+}
+
+// getExpiration returns the expiration time for the retention, limited to the retention of the
+// repository, or nil to use the retention of the repository.
+func getExpiration(retentionDays *int) *time.Time {
+	if retentionDays == nil || *retentionDays == 0 {
+		return nil
+	}
+	days := *retentionDays
+	if maxRetentionDays := getRetentionDays(); maxRetentionDays > 0 && maxRetentionDays < days {
+		days = maxRetentionDays
+	}
+	expiration := time.Now().UTC().AddDate(0, 0, days)
+	return &expiration
+}
+
+// ListArtifacts lists the artifacts of the workflow run. options may be nil.
+func ListArtifacts(ctx context.Context, options *ListArtifactsOptions) ([]Artifact, error) {
+	if isGhes() {
+		return nil, &GHESNotSupportedError{}
+	}
+	ids, err := getBackendIDsFromToken()
+	if err != nil {
+		return nil, err
+	}
+	client, err := internalArtifactTwirpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.ListArtifacts(ctx, listArtifactsRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make([]Artifact, 0, len(resp.Artifacts))
+	for _, a := range resp.Artifacts {
+		artifacts = append(artifacts, toArtifact(a))
+	}
+	if options != nil && options.Latest != nil && *options.Latest {
+		artifacts = filterLatest(artifacts)
+	}
+	return artifacts, nil
+}
+
+// filterLatest keeps the artifact with the highest ID of each name, in the order of the IDs.
+func filterLatest(artifacts []Artifact) []Artifact {
+	sorted := append([]Artifact(nil), artifacts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID > sorted[j].ID
+	})
+	var latest []Artifact
+	seen := map[string]bool{}
+	for _, a := range sorted {
+		if !seen[a.Name] {
+			seen[a.Name] = true
+			latest = append(latest, a)
+		}
+	}
+	return latest
+}
+
+func toArtifact(a monolithArtifact) Artifact {
+	artifact := Artifact{
+		Name:      a.Name,
+		ID:        int64(a.DatabaseID),
+		Size:      int64(a.Size),
+		CreatedAt: a.CreatedAt,
+	}
+	if a.Digest != nil {
+		artifact.Digest = *a.Digest
+	}
+	return artifact
+}
+
+// GetArtifact returns the artifact of the workflow run with the name, the latest one if the
+// workflow run was attempted several times. An *ArtifactNotFoundError is returned if there is none.
+func GetArtifact(ctx context.Context, name string) (*Artifact, error) {
+	if isGhes() {
+		return nil, &GHESNotSupportedError{}
+	}
+	ids, err := getBackendIDsFromToken()
+	if err != nil {
+		return nil, err
+	}
+	client, err := internalArtifactTwirpClient()
+	if err != nil {
+		return nil, err
+	}
+	return getArtifactByName(ctx, client, ids, name)
+}
+
+func getArtifactByName(ctx context.Context, client *artifactTwirpClient, ids backendIDs, name string) (*Artifact, error) {
+	resp, err := client.ListArtifacts(ctx, listArtifactsRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		NameFilter:              ptr.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Artifacts) == 0 {
+		return nil, &ArtifactNotFoundError{Message: fmt.Sprintf(`Artifact not found for name: %s
+Please ensure that your artifact is not expired and the artifact was uploaded using a compatible version of toolkit/upload-artifact.
+For more information, visit the GitHub Artifacts FAQ: https://github.com/actions/toolkit/blob/main/packages/artifact/docs/faq.md`, name)}
+	}
+
+	artifacts := make([]Artifact, 0, len(resp.Artifacts))
+	for _, a := range resp.Artifacts {
+		artifacts = append(artifacts, toArtifact(a))
+	}
+	// More than one artifact with the same name means the run was attempted several times
+	artifact := filterLatest(artifacts)[0]
+	return &artifact, nil
+}
+
+// DownloadArtifact downloads the artifact with the ID and extracts it into path while
+// downloading, and checks the digest of the artifact. path is created if needed and defaults
+// to GITHUB_WORKSPACE. It returns the download path.
+func DownloadArtifact(ctx context.Context, id int64, path string) (string, error) {
+	if isGhes() {
+		return "", &GHESNotSupportedError{}
+	}
+	downloadPath, err := resolveOrCreateDirectory(path)
+	if err != nil {
+		return "", err
+	}
+	ids, err := getBackendIDsFromToken()
+	if err != nil {
+		return "", err
+	}
+	client, err := internalArtifactTwirpClient()
+	if err != nil {
+		return "", err
+	}
+
+	listResp, err := client.ListArtifacts(ctx, listArtifactsRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		IDFilter:                (*int64String)(&id),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(listResp.Artifacts) == 0 {
+		return "", &ArtifactNotFoundError{Message: fmt.Sprintf("No artifacts found for ID: %d\nAre you trying to download from a different run? Try specifying a github-token with `actions:read` scope.", id)}
+	}
+
+	// Multiple artifacts found, defaulting to first
+	urlResp, err := client.GetSignedArtifactURL(ctx, getSignedArtifactURLRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		Name:                    listResp.Artifacts[0].Name,
+	})
+	if err != nil {
+		return "", err
+	}
+	digest, err := streamExtract(ctx, urlResp.SignedURL, downloadPath)
+	if err != nil {
+		return "", fmt.Errorf("Unable to download and extract artifact: %w", err)
+	}
+	// Artifacts uploaded by older versions have no digest
+	if expected := listResp.Artifacts[0].Digest; expected != nil && *expected != "" && *expected != digest {
+		return "", &DigestMismatchError{Expected: *expected, Actual: digest}
+	}
+	return downloadPath, nil
+}
+
+func resolveOrCreateDirectory(downloadPath string) (string, error) {
+	if downloadPath == "" {
+		workspace, err := getGitHubWorkspaceDir()
+		if err != nil {
+			return "", err
+		}
+		downloadPath = workspace
+	}
+	if err := os.MkdirAll(downloadPath, 0o755); err != nil {
+		return "", err
+	}
+	return downloadPath, nil
+}
+
+// DeleteArtifact deletes the artifact of the workflow run with the name, the latest one if the
+// workflow run was attempted several times, and returns its ID.
+func DeleteArtifact(ctx context.Context, name string) (int64, error) {
+	if isGhes() {
+		return 0, &GHESNotSupportedError{}
+	}
+	ids, err := getBackendIDsFromToken()
+	if err != nil {
+		return 0, err
+	}
+	client, err := internalArtifactTwirpClient()
+	if err != nil {
+		return 0, err
+	}
+	artifact, err := getArtifactByName(ctx, client, ids, name)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.DeleteArtifact(ctx, deleteArtifactRequest{
+		WorkflowRunBackendID:    ids.workflowRunBackendID,
+		WorkflowJobRunBackendID: ids.workflowJobRunBackendID,
+		Name:                    artifact.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Ok {
+		return 0, &InvalidResponseError{Message: "DeleteArtifact: response from backend was not ok"}
+	}
+	return int64(resp.ArtifactID), nil
+}
@@ -0,0 +1,362 @@
+package artifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/artifact/artifacttest"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func setupServer(t *testing.T) *artifacttest.Server {
+	server := artifacttest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("ACTIONS_RESULTS_URL", server.ResultsURL())
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", server.Token())
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_WORKSPACE", t.TempDir())
+	t.Setenv("GITHUB_RETENTION_DAYS", "")
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	oldRetryInterval, oldDownloadRetryDelay := baseRetryInterval, downloadRetryDelay
+	baseRetryInterval, downloadRetryDelay = 0, 0
+	t.Cleanup(func() {
+		baseRetryInterval, downloadRetryDelay = oldRetryInterval, oldDownloadRetryDelay
+	})
+	return server
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) []string {
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func createTestZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_UploadAndDownloadArtifact(t *testing.T) {
+	server := setupServer(t)
+	ctx := context.Background()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "hello",
+		"dir/b.txt":     strings.Repeat("compressible ", 1000),
+		"dir/sub/c.txt": "",
+	}
+	paths := writeFiles(t, root, files)
+	emptyDir := filepath.Join(root, "empty")
+	if err := os.Mkdir(emptyDir, 0o755); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	paths = append(paths, emptyDir)
+
+	server.FailNext("POST", "/twirp/", 1)
+	resp, err := UploadArtifact(ctx, "my-artifact", paths, root, &UploadArtifactOptions{RetentionDays: ptr.Int(1)})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	stored := server.Artifacts()
+	if len(stored) != 1 || !stored[0].Finalized || stored[0].ID != resp.ID || stored[0].Size != resp.Size || int64(len(stored[0].Data)) != resp.Size || stored[0].Digest != "sha256:"+resp.Digest {
+		t.Fatalf("unexpected artifacts: %v %v", stored, resp)
+	}
+	if stored[0].ExpiresAt == nil || stored[0].ExpiresAt.Before(time.Now().Add(23*time.Hour)) {
+		t.Fatalf("unexpected expiration: %v", stored[0].ExpiresAt)
+	}
+
+	artifacts, err := ListArtifacts(ctx, nil)
+	if err != nil || len(artifacts) != 1 || artifacts[0].Name != "my-artifact" || artifacts[0].ID != resp.ID || artifacts[0].Size != resp.Size || artifacts[0].CreatedAt == nil || artifacts[0].Digest != stored[0].Digest {
+		t.Fatalf("unexpected artifacts: %v %v", artifacts, err)
+	}
+	artifact, err := GetArtifact(ctx, "my-artifact")
+	if err != nil || !reflect.DeepEqual(*artifact, artifacts[0]) {
+		t.Fatalf("expected value is %v, but result was %v %v.", artifacts[0], artifact, err)
+	}
+
+	downloadPath := filepath.Join(t.TempDir(), "download")
+	server.FailNext("GET", "/blobs/", 1)
+	result, err := DownloadArtifact(ctx, resp.ID, downloadPath)
+	if err != nil || result != downloadPath {
+		t.Fatalf("expected value is %v, but result was %v %v.", downloadPath, result, err)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(downloadPath, filepath.FromSlash(name)))
+		if err != nil || string(data) != content {
+			t.Fatalf("unexpected content of %s: %q %v", name, data, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(downloadPath, "empty")); err != nil || !info.IsDir() {
+		t.Fatalf("expected the empty directory to be extracted: %v", err)
+	}
+
+	// Downloads to GITHUB_WORKSPACE by default
+	result, err = DownloadArtifact(ctx, resp.ID, "")
+	if err != nil || result != os.Getenv("GITHUB_WORKSPACE") {
+		t.Fatalf("unexpected download path: %v %v", result, err)
+	}
+
+	id, err := DeleteArtifact(ctx, "my-artifact")
+	if err != nil || id != resp.ID {
+		t.Fatalf("expected value is %v, but result was %v %v.", resp.ID, id, err)
+	}
+	var notFound *ArtifactNotFoundError
+	if _, err := GetArtifact(ctx, "my-artifact"); !errors.As(err, &notFound) {
+		t.Fatalf("expected an artifact not found error, but result was %v.", err)
+	}
+	if _, err := DownloadArtifact(ctx, resp.ID, downloadPath); !errors.As(err, &notFound) {
+		t.Fatalf("expected an artifact not found error, but result was %v.", err)
+	}
+}
+
+func Test_UploadArtifactInBlocks(t *testing.T) {
+	server := setupServer(t)
+	ctx := context.Background()
+
+	random := make([]byte, 50*1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	root := t.TempDir()
+	files := map[string]string{"random.bin": string(random), "a.txt": strings.Repeat("a", 10000)}
+	paths := writeFiles(t, root, files)
+
+	server.FailNext("PUT", "/blobs/", 2)
+	resp, err := UploadArtifact(ctx, "blocks", paths, root, &UploadArtifactOptions{
+		CompressionLevel:  ptr.Int(0),
+		UploadChunkSize:   ptr.Int(4096),
+		UploadConcurrency: ptr.Int(3),
+	})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	stored := server.Artifacts()[0]
+	digest := sha256.Sum256(stored.Data)
+	if expected := int(resp.Size+4095) / 4096; stored.Blocks != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, stored.Blocks)
+	}
+	if resp.Digest != hex.EncodeToString(digest[:]) || stored.Digest != "sha256:"+resp.Digest || resp.Size != int64(len(stored.Data)) {
+		t.Fatalf("unexpected digest: %v %v", resp, stored.Digest)
+	}
+	artifact, err := GetArtifact(ctx, "blocks")
+	if err != nil || artifact.Digest != stored.Digest {
+		t.Fatalf("expected value is %v, but result was %v %v.", stored.Digest, artifact, err)
+	}
+
+	downloadPath := t.TempDir()
+	if _, err := DownloadArtifact(ctx, resp.ID, downloadPath); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if actual := readFiles(t, downloadPath); !reflect.DeepEqual(actual, files) {
+		t.Fatalf("unexpected files: %d", len(actual))
+	}
+
+	// The upload stops at the first block which cannot be uploaded
+	server.FailNext("PUT", "/blobs/", 1000)
+	_, err = UploadArtifact(ctx, "failed", paths, root, &UploadArtifactOptions{UploadChunkSize: ptr.Int(4096)})
+	expected := "Failed to upload artifact zip to blob storage: stage block failed: (503) Service Unavailable"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected value is %q, but result was %v.", expected, err)
+	}
+}
+
+func Test_DownloadDigestMismatch(t *testing.T) {
+	server := setupServer(t)
+	id := server.AddArtifact("a", createTestZip(t, map[string]string{"a.txt": "a"}))
+	server.SetDigest(id, "sha256:0000")
+
+	_, err := DownloadArtifact(context.Background(), id, t.TempDir())
+	var mismatch *DigestMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Expected != "sha256:0000" || !strings.HasPrefix(mismatch.Actual, "sha256:") {
+		t.Fatalf("expected a digest mismatch error, but result was %v.", err)
+	}
+
+	// Artifacts without a digest are not checked
+	server.SetDigest(id, "")
+	if _, err := DownloadArtifact(context.Background(), id, t.TempDir()); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+}
+
+func Test_UploadArtifactErrors(t *testing.T) {
+	setupServer(t)
+	ctx := context.Background()
+	root := t.TempDir()
+	paths := writeFiles(t, root, map[string]string{"a.txt": "a"})
+	outside := writeFiles(t, t.TempDir(), map[string]string{"b.txt": "b"})
+
+	table := []struct {
+		name             string
+		files            []string
+		rootDirectory    string
+		compressionLevel *int
+		expected         string
+	}{
+		{name: "", files: paths, rootDirectory: root, expected: "Provided artifact name input during validation is empty"},
+		{name: "a/b", files: paths, rootDirectory: root, expected: "The artifact name is not valid: a/b. Contains the following character:  Forward slash /"},
+		{name: "a:b", files: paths, rootDirectory: root, expected: "The artifact name is not valid: a:b. Contains the following character:  Colon :"},
+		{name: "a", files: paths, rootDirectory: filepath.Join(root, "missing"), expected: "The provided rootDirectory " + filepath.Join(root, "missing") + " does not exist"},
+		{name: "a", files: paths, rootDirectory: paths[0], expected: "The provided rootDirectory " + paths[0] + " is not a valid directory"},
+		{name: "a", files: outside, rootDirectory: root, expected: "The rootDirectory: " + root + " is not a parent directory of the file: " + outside[0]},
+		{name: "a", files: []string{filepath.Join(root, "missing.txt")}, rootDirectory: root, expected: "File " + filepath.Join(root, "missing.txt") + " does not exist"},
+		{name: "a", files: nil, rootDirectory: root, expected: "No files were found to upload"},
+		{name: "a", files: paths, rootDirectory: root, compressionLevel: ptr.Int(10), expected: "Invalid compression level: 10. Valid values are 0-9."},
+	}
+	for _, tt := range table {
+		_, err := UploadArtifact(ctx, tt.name, tt.files, tt.rootDirectory, &UploadArtifactOptions{CompressionLevel: tt.compressionLevel})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.expected, err, tt)
+		}
+	}
+}
+
+func Test_ServiceErrors(t *testing.T) {
+	server := setupServer(t)
+	ctx := context.Background()
+	root := t.TempDir()
+	paths := writeFiles(t, root, map[string]string{"a.txt": "a"})
+
+	if _, err := UploadArtifact(ctx, "a", paths, root, nil); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	_, err := UploadArtifact(ctx, "a", paths, root, nil)
+	expected := "Failed to CreateArtifact: Received non-retryable error: Failed request: (409) Conflict: an artifact with this name already exists on the workflow run"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected value is %q, but result was %v.", expected, err)
+	}
+
+	server.FailNext("POST", "/twirp/", defaultMaxAttempts)
+	_, err = ListArtifacts(ctx, nil)
+	expected = "Failed to ListArtifacts: Failed to make request after 5 attempts: Failed request: (503) Service Unavailable: service unavailable"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected value is %q, but result was %v.", expected, err)
+	}
+
+	server.UsageExceeded = true
+	var usageErr *UsageError
+	if _, err := UploadArtifact(ctx, "b", paths, root, nil); !errors.As(err, &usageErr) {
+		t.Fatalf("expected a usage error, but result was %v.", err)
+	}
+
+	t.Setenv("GITHUB_SERVER_URL", "https://ghes.example.com")
+	var ghesErr *GHESNotSupportedError
+	if _, err := ListArtifacts(ctx, nil); !errors.As(err, &ghesErr) {
+		t.Fatalf("expected a GHES error, but result was %v.", err)
+	}
+}
+
+func Test_ListArtifactsLatest(t *testing.T) {
+	server := setupServer(t)
+	ctx := context.Background()
+	data := createTestZip(t, map[string]string{"a.txt": "a"})
+	server.AddArtifact("a", data)
+	server.AddArtifact("b", data)
+	latestA := server.AddArtifact("a", data)
+
+	table := []struct {
+		latest   bool
+		expected []int64
+	}{
+		{latest: false, expected: []int64{1, 2, 3}},
+		{latest: true, expected: []int64{latestA, 2}},
+	}
+	for _, tt := range table {
+		artifacts, err := ListArtifacts(ctx, &ListArtifactsOptions{Latest: ptr.Bool(tt.latest)})
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		var ids []int64
+		for _, a := range artifacts {
+			ids = append(ids, a.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, ids, tt)
+		}
+	}
+
+	artifact, err := GetArtifact(ctx, "a")
+	if err != nil || artifact.ID != latestA {
+		t.Fatalf("expected value is %v, but result was %v %v.", latestA, artifact, err)
+	}
+}
+
+func Test_DownloadMalformedPath(t *testing.T) {
+	server := setupServer(t)
+	id := server.AddArtifact("evil", createTestZip(t, map[string]string{"../evil.txt": "evil"}))
+
+	downloadPath := filepath.Join(t.TempDir(), "download")
+	_, err := DownloadArtifact(context.Background(), id, downloadPath)
+	if err == nil || !strings.Contains(err.Error(), "Artifact download failed with unretryable error: Malformed extraction path") {
+		t.Fatalf("expected a malformed extraction path error, but result was %v.", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(downloadPath), "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected the file not to be extracted: %v", err)
+	}
+}
+
+func Test_GetBackendIDsFromToken(t *testing.T) {
+	token := func(claims string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+	table := []struct {
+		token    string
+		expected backendIDs
+		err      bool
+	}{
+		{token: token(`{"scp":"Actions.ExampleScope Actions.Results:ce7f54c7-61c7-4aae-887f-30da475f5f1a:ca395085-040a-526b-2ce8-bdc85f692774"}`), expected: backendIDs{workflowRunBackendID: "ce7f54c7-61c7-4aae-887f-30da475f5f1a", workflowJobRunBackendID: "ca395085-040a-526b-2ce8-bdc85f692774"}},
+		{token: token(`{"scp":"Actions.ExampleScope Actions.Results:ce7f54c7-61c7-4aae-887f-30da475f5f1a"}`), err: true},
+		{token: token(`{"scp":"Actions.ExampleScope"}`), err: true},
+		{token: token(`{}`), err: true},
+		{token: "not-a-jwt", err: true},
+	}
+	for _, tt := range table {
+		t.Setenv("ACTIONS_RUNTIME_TOKEN", tt.token)
+		ids, err := getBackendIDsFromToken()
+		if tt.err {
+			if err == nil || err.Error() != errInvalidJwt.Error() {
+				t.Fatalf("expected an invalid JWT error, but result was %v %v.\ntest case: %v", ids, err, tt)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if ids != tt.expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, ids, tt)
+		}
+	}
+}
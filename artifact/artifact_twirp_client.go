@@ -0,0 +1,281 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ci-tools/toolkit/httpclient"
+)
+
+const (
+	userAgent          = "@actions/artifact-2.1.0"
+	artifactService    = "github.actions.results.api.v1.ArtifactService"
+	defaultMaxAttempts = 5
+	retryMultiplier    = 1.5
+)
+
+// The delay before the first retry, overridden by tests.
+var baseRetryInterval = 3 * time.Second
+
+// int64String is an int64 encoded as a JSON string, like protobuf does. Numbers are accepted too.
+type int64String int64
+
+func (v int64String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(v), 10))
+}
+
+func (v *int64String) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 value %s", data)
+	}
+	*v = int64String(n)
+	return nil
+}
+
+// The messages of the artifact service, encoded as protobuf JSON with the field names of the
+// proto files. Wrapper types such as google.protobuf.StringValue are encoded as their value.
+
+type createArtifactRequest struct {
+	WorkflowRunBackendID    string     `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string     `json:"workflow_job_run_backend_id"`
+	Name                    string     `json:"name"`
+	ExpiresAt               *time.Time `json:"expires_at,omitempty"`
+	Version                 int        `json:"version"`
+}
+
+type createArtifactResponse struct {
+	Ok              bool   `json:"ok"`
+	SignedUploadURL string `json:"signed_upload_url"`
+}
+
+type finalizeArtifactRequest struct {
+	WorkflowRunBackendID    string      `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string      `json:"workflow_job_run_backend_id"`
+	Name                    string      `json:"name"`
+	Size                    int64String `json:"size"`
+	Hash                    *string     `json:"hash,omitempty"`
+}
+
+type finalizeArtifactResponse struct {
+	Ok         bool        `json:"ok"`
+	ArtifactID int64String `json:"artifact_id"`
+}
+
+type listArtifactsRequest struct {
+	WorkflowRunBackendID    string       `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string       `json:"workflow_job_run_backend_id"`
+	NameFilter              *string      `json:"name_filter,omitempty"`
+	IDFilter                *int64String `json:"id_filter,omitempty"`
+}
+
+type listArtifactsResponse struct {
+	Artifacts []monolithArtifact `json:"artifacts"`
+}
+
+type monolithArtifact struct {
+	WorkflowRunBackendID    string      `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string      `json:"workflow_job_run_backend_id"`
+	DatabaseID              int64String `json:"database_id"`
+	Name                    string      `json:"name"`
+	Size                    int64String `json:"size"`
+	CreatedAt               *time.Time  `json:"created_at,omitempty"`
+	Digest                  *string     `json:"digest,omitempty"`
+}
+
+type getSignedArtifactURLRequest struct {
+	WorkflowRunBackendID    string `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string `json:"workflow_job_run_backend_id"`
+	Name                    string `json:"name"`
+}
+
+type getSignedArtifactURLResponse struct {
+	SignedURL string `json:"signed_url"`
+}
+
+type deleteArtifactRequest struct {
+	WorkflowRunBackendID    string `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string `json:"workflow_job_run_backend_id"`
+	Name                    string `json:"name"`
+}
+
+type deleteArtifactResponse struct {
+	Ok         bool        `json:"ok"`
+	ArtifactID int64String `json:"artifact_id"`
+}
+
+// artifactTwirpClient calls the artifact service of the results service with Twirp and JSON.
+type artifactTwirpClient struct {
+	client      *httpclient.Client
+	baseURL     string
+	maxAttempts int
+}
+
+func internalArtifactTwirpClient() (*artifactTwirpClient, error) {
+	baseURL, err := getResultsServiceURL()
+	if err != nil {
+		return nil, err
+	}
+	token, err := getRuntimeToken()
+	if err != nil {
+		return nil, err
+	}
+	handler := &httpclient.BearerCredentialHandler{Token: token}
+	return &artifactTwirpClient{
+		client:      httpclient.NewClient(userAgent, []httpclient.RequestHandler{handler}, nil),
+		baseURL:     baseURL,
+		maxAttempts: defaultMaxAttempts,
+	}, nil
+}
+
+func (c *artifactTwirpClient) CreateArtifact(ctx context.Context, req createArtifactRequest) (*createArtifactResponse, error) {
+	var resp createArtifactResponse
+	if err := c.request(ctx, "CreateArtifact", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *artifactTwirpClient) FinalizeArtifact(ctx context.Context, req finalizeArtifactRequest) (*finalizeArtifactResponse, error) {
+	var resp finalizeArtifactResponse
+	if err := c.request(ctx, "FinalizeArtifact", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *artifactTwirpClient) ListArtifacts(ctx context.Context, req listArtifactsRequest) (*listArtifactsResponse, error) {
+	var resp listArtifactsResponse
+	if err := c.request(ctx, "ListArtifacts", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *artifactTwirpClient) GetSignedArtifactURL(ctx context.Context, req getSignedArtifactURLRequest) (*getSignedArtifactURLResponse, error) {
+	var resp getSignedArtifactURLResponse
+	if err := c.request(ctx, "GetSignedArtifactURL", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *artifactTwirpClient) DeleteArtifact(ctx context.Context, req deleteArtifactRequest) (*deleteArtifactResponse, error) {
+	var resp deleteArtifactResponse
+	if err := c.request(ctx, "DeleteArtifact", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *artifactTwirpClient) request(ctx context.Context, method string, in interface{}, out interface{}) error {
+	requestURL := fmt.Sprintf("%s/twirp/%s/%s", c.baseURL, artifactService, method)
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	headers := http.Header{"Content-Type": {"application/json"}}
+
+	err = c.retryableRequest(ctx, func() (int, string, []byte, error) {
+		resp, err := c.client.Post(ctx, requestURL, string(data), headers)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, http.StatusText(resp.StatusCode), body, err
+	}, out)
+	if err != nil {
+		return fmt.Errorf("Failed to %s: %w", method, err)
+	}
+	return nil
+}
+
+// retryableRequest sends the request until it succeeds, fails with an error which is not
+// retryable or the attempts are exhausted, and decodes the JSON response into out.
+func (c *artifactTwirpClient) retryableRequest(ctx context.Context, operation func() (int, string, []byte, error), out interface{}) error {
+	var errorMessage string
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		isRetryable := false
+		statusCode, statusText, body, err := operation()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			isRetryable = true
+			errorMessage = err.Error()
+		} else if isSuccessStatusCode(statusCode) {
+			if err := json.Unmarshal(body, out); err != nil {
+				isRetryable = true
+				errorMessage = err.Error()
+			} else {
+				return nil
+			}
+		} else {
+			isRetryable = isRetryableHTTPStatusCode(statusCode)
+			errorMessage = fmt.Sprintf("Failed request: (%d) %s", statusCode, statusText)
+			var twirpError struct {
+				Msg string `json:"msg"`
+			}
+			if json.Unmarshal(body, &twirpError) == nil && twirpError.Msg != "" {
+				if isUsageErrorMessage(twirpError.Msg) {
+					return &UsageError{}
+				}
+				errorMessage = fmt.Sprintf("%s: %s", errorMessage, twirpError.Msg)
+			}
+		}
+
+		if !isRetryable {
+			return fmt.Errorf("Received non-retryable error: %s", errorMessage)
+		}
+		if attempt+1 == c.maxAttempts {
+			return fmt.Errorf("Failed to make request after %d attempts: %s", c.maxAttempts, errorMessage)
+		}
+
+		timer := time.NewTimer(getExponentialRetryTime(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return errors.New(errorMessage)
+}
+
+func isSuccessStatusCode(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+func isRetryableHTTPStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError,
+		http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// getExponentialRetryTime returns a random delay growing by retryMultiplier on each attempt.
+func getExponentialRetryTime(attempt int) time.Duration {
+	if attempt == 0 {
+		return baseRetryInterval
+	}
+	minTime := float64(baseRetryInterval) * math.Pow(retryMultiplier, float64(attempt))
+	maxTime := minTime * retryMultiplier
+	return time.Duration(rand.Float64()*(maxTime-minTime) + minTime)
+}
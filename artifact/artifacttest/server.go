@@ -0,0 +1,595 @@
+// Package artifacttest provides an in-memory fake of the artifact service of the results
+// service and of the blob storage behind its signed URLs, for testing code using the artifact
+// package without network access.
+//
+//	server := artifacttest.NewServer()
+//	defer server.Close()
+//	os.Setenv("ACTIONS_RESULTS_URL", server.ResultsURL())
+//	os.Setenv("ACTIONS_RUNTIME_TOKEN", server.Token())
+package artifacttest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	twirpPrefix = "/twirp/github.actions.results.api.v1.ArtifactService/"
+	blobPrefix  = "/blobs/"
+)
+
+// Artifact is an artifact stored by the server.
+type Artifact struct {
+	ID                      int64
+	Name                    string
+	WorkflowRunBackendID    string
+	WorkflowJobRunBackendID string
+	CreatedAt               time.Time
+	ExpiresAt               *time.Time
+
+	// Size is the size sent when finalizing the artifact
+	Size int64
+
+	// Digest is the hash sent when finalizing the artifact, e.g. "sha256:..."
+	Digest string
+
+	// Finalized reports whether the upload is complete. Only finalized artifacts are listed
+	Finalized bool
+
+	// Blocks is the number of blocks of the upload, or 0 if it was uploaded in a single request
+	Blocks int
+
+	// Data is the uploaded zip
+	Data []byte
+}
+
+// Server is a fake artifact service. Its methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// WorkflowRunBackendID and WorkflowJobRunBackendID identify the workflow run and the job
+	// in the token. Artifacts of other workflow runs are not visible.
+	WorkflowRunBackendID    string
+	WorkflowJobRunBackendID string
+
+	// UsageExceeded makes creating artifacts fail as if the storage quota was hit.
+	UsageExceeded bool
+
+	mu        sync.Mutex
+	artifacts []*Artifact
+	blocks    map[int64]map[string][]byte
+	nextID    int64
+	failing   map[string]int
+	key       []byte
+}
+
+// NewServer starts a fake artifact service. The caller must call Close when finished.
+func NewServer() *Server {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	s := &Server{
+		WorkflowRunBackendID:    "c4d7c21f-ba3f-4ddc-a8c8-6f2f626f8422",
+		WorkflowJobRunBackendID: "760803a1-f890-4d25-9a6e-a3fc01a0c7cf",
+		blocks:                  map[int64]map[string][]byte{},
+		nextID:                  1,
+		failing:                 map[string]int{},
+		key:                     key,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ResultsURL returns the value of ACTIONS_RESULTS_URL pointing to the server.
+func (s *Server) ResultsURL() string {
+	return s.URL + "/"
+}
+
+// Token returns the value of ACTIONS_RUNTIME_TOKEN, a JWT whose scope contains the backend ids.
+func (s *Server) Token() string {
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(map[string]string{"typ": "JWT", "alg": "HS256"}) + "." + encode(map[string]interface{}{
+		"scp": fmt.Sprintf("Actions.ExampleScope Actions.Results:%s:%s", s.WorkflowRunBackendID, s.WorkflowJobRunBackendID),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Artifacts returns a copy of the stored artifacts, in the order they were created.
+func (s *Server) Artifacts() []Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Artifact, 0, len(s.artifacts))
+	for _, a := range s.artifacts {
+		artifact := *a
+		artifact.Data = append([]byte(nil), a.Data...)
+		result = append(result, artifact)
+	}
+	return result
+}
+
+// AddArtifact stores a finalized artifact of the workflow run with the zip, e.g. an artifact
+// uploaded by another implementation or by a previous attempt of the run.
+func (s *Server) AddArtifact(name string, data []byte) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	digest := sha256.Sum256(data)
+	artifact := &Artifact{
+		ID:                      s.nextID,
+		Name:                    name,
+		WorkflowRunBackendID:    s.WorkflowRunBackendID,
+		WorkflowJobRunBackendID: s.WorkflowJobRunBackendID,
+		CreatedAt:               time.Now(),
+		Size:                    int64(len(data)),
+		Digest:                  "sha256:" + hex.EncodeToString(digest[:]),
+		Finalized:               true,
+		Data:                    append([]byte(nil), data...),
+	}
+	s.nextID++
+	s.artifacts = append(s.artifacts, artifact)
+	return artifact.ID
+}
+
+// SetDigest changes the digest of the artifact, e.g. to simulate a corrupted download.
+func (s *Server) SetDigest(id int64, digest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if artifact := s.findByID(id); artifact != nil {
+		artifact.Digest = digest
+	}
+}
+
+// FailNext makes the next count requests with the method and the path prefix respond with
+// 503 Service Unavailable, e.g. FailNext("POST", "/twirp/", 1) or FailNext("GET", "/blobs/", 1).
+func (s *Server) FailNext(method, pathPrefix string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing[method+" "+pathPrefix] = count
+}
+
+func (s *Server) shouldFail(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for prefix, count := range s.failing {
+		if count > 0 && strings.HasPrefix(r.Method+" "+r.URL.Path, prefix) {
+			s.failing[prefix] = count - 1
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.shouldFail(r) {
+		if strings.HasPrefix(r.URL.Path, twirpPrefix) {
+			writeError(w, http.StatusServiceUnavailable, "unavailable", "service unavailable")
+		} else {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		}
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, twirpPrefix):
+		s.serveTwirp(w, r, strings.TrimPrefix(r.URL.Path, twirpPrefix))
+	case strings.HasPrefix(r.URL.Path, blobPrefix):
+		s.serveBlob(w, r, strings.TrimPrefix(r.URL.Path, blobPrefix))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// twirpRequest holds the fields of the requests of all methods. Wrapper types such as
+// google.protobuf.Int64Value are encoded as their value, int64 values as strings or numbers.
+type twirpRequest struct {
+	WorkflowRunBackendID    string          `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string          `json:"workflow_job_run_backend_id"`
+	Name                    string          `json:"name"`
+	ExpiresAt               *time.Time      `json:"expires_at"`
+	Version                 int             `json:"version"`
+	Size                    json.RawMessage `json:"size"`
+	Hash                    *string         `json:"hash"`
+	NameFilter              *string         `json:"name_filter"`
+	IDFilter                json.RawMessage `json:"id_filter"`
+}
+
+func (s *Server) serveTwirp(w http.ResponseWriter, r *http.Request, method string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusNotFound, "bad_route", "unsupported method "+r.Method)
+		return
+	}
+	if !s.validToken(r) {
+		writeError(w, http.StatusUnauthorized, "unauthenticated", "invalid token")
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		writeError(w, http.StatusBadRequest, "malformed", "unexpected Content-Type")
+		return
+	}
+	var req twirpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed", "the json request could not be decoded")
+		return
+	}
+	if req.WorkflowRunBackendID != s.WorkflowRunBackendID || req.WorkflowJobRunBackendID != s.WorkflowJobRunBackendID {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid workflow run or job backend id")
+		return
+	}
+
+	switch method {
+	case "CreateArtifact":
+		s.createArtifact(w, req)
+	case "FinalizeArtifact":
+		s.finalizeArtifact(w, req)
+	case "ListArtifacts":
+		s.listArtifacts(w, req)
+	case "GetSignedArtifactURL":
+		s.getSignedArtifactURL(w, req)
+	case "DeleteArtifact":
+		s.deleteArtifact(w, req)
+	default:
+		writeError(w, http.StatusNotFound, "bad_route", "no handler for path "+r.URL.Path)
+	}
+}
+
+// validToken accepts the tokens returned by Token, which differ by their expiration.
+func (s *Server) validToken(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(token[:i]))
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+func (s *Server) createArtifact(w http.ResponseWriter, req twirpRequest) {
+	if s.UsageExceeded {
+		writeError(w, http.StatusTooManyRequests, "resource_exhausted", "insufficient usage to create artifact")
+		return
+	}
+	if req.Version != 4 {
+		writeError(w, http.StatusBadRequest, "invalid_argument", fmt.Sprintf("unsupported artifact version %d", req.Version))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.artifacts {
+		if a.Name != req.Name || a.WorkflowRunBackendID != req.WorkflowRunBackendID {
+			continue
+		}
+		if a.Finalized {
+			writeError(w, http.StatusConflict, "already_exists", "an artifact with this name already exists on the workflow run")
+			return
+		}
+		// An upload which was not finalized is started again
+		s.artifacts = append(s.artifacts[:i], s.artifacts[i+1:]...)
+		break
+	}
+	artifact := &Artifact{
+		ID:                      s.nextID,
+		Name:                    req.Name,
+		WorkflowRunBackendID:    req.WorkflowRunBackendID,
+		WorkflowJobRunBackendID: req.WorkflowJobRunBackendID,
+		CreatedAt:               time.Now(),
+		ExpiresAt:               req.ExpiresAt,
+	}
+	s.nextID++
+	s.artifacts = append(s.artifacts, artifact)
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"signed_upload_url": s.signedURL(artifact.ID, "upload"),
+	})
+}
+
+func (s *Server) finalizeArtifact(w http.ResponseWriter, req twirpRequest) {
+	size, err := parseInt64(req.Size)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_argument", "invalid size")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var artifact *Artifact
+	for _, a := range s.artifacts {
+		if a.Name == req.Name && a.WorkflowRunBackendID == req.WorkflowRunBackendID && !a.Finalized {
+			artifact = a
+		}
+	}
+	if artifact == nil {
+		writeError(w, http.StatusNotFound, "not_found", "artifact not found")
+		return
+	}
+	if int64(len(artifact.Data)) != size {
+		writeError(w, http.StatusBadRequest, "invalid_argument", fmt.Sprintf("size mismatch: uploaded %d bytes, finalized %d bytes", len(artifact.Data), size))
+		return
+	}
+	artifact.Size = size
+	if req.Hash != nil {
+		artifact.Digest = *req.Hash
+	}
+	artifact.Finalized = true
+	writeJSON(w, map[string]interface{}{
+		"ok":          true,
+		"artifact_id": strconv.FormatInt(artifact.ID, 10),
+	})
+}
+
+func (s *Server) listArtifacts(w http.ResponseWriter, req twirpRequest) {
+	var idFilter *int64
+	if len(req.IDFilter) > 0 && string(req.IDFilter) != "null" {
+		id, err := parseInt64(req.IDFilter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_argument", "invalid id_filter")
+			return
+		}
+		idFilter = &id
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifacts := []map[string]interface{}{}
+	for _, a := range s.artifacts {
+		if !a.Finalized || a.WorkflowRunBackendID != req.WorkflowRunBackendID {
+			continue
+		}
+		if req.NameFilter != nil && a.Name != *req.NameFilter {
+			continue
+		}
+		if idFilter != nil && a.ID != *idFilter {
+			continue
+		}
+		artifact := map[string]interface{}{
+			"workflow_run_backend_id":     a.WorkflowRunBackendID,
+			"workflow_job_run_backend_id": a.WorkflowJobRunBackendID,
+			"database_id":                 strconv.FormatInt(a.ID, 10),
+			"name":                        a.Name,
+			"size":                        strconv.FormatInt(a.Size, 10),
+			"created_at":                  a.CreatedAt.UTC().Format(time.RFC3339Nano),
+		}
+		if a.Digest != "" {
+			artifact["digest"] = a.Digest
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	writeJSON(w, map[string]interface{}{"artifacts": artifacts})
+}
+
+func (s *Server) getSignedArtifactURL(w http.ResponseWriter, req twirpRequest) {
+	s.mu.Lock()
+	artifact := s.findFinalized(req.WorkflowRunBackendID, req.Name)
+	s.mu.Unlock()
+	if artifact == nil {
+		writeError(w, http.StatusNotFound, "not_found", "artifact not found")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"signed_url": s.signedURL(artifact.ID, "download")})
+}
+
+func (s *Server) deleteArtifact(w http.ResponseWriter, req twirpRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifact := s.findFinalized(req.WorkflowRunBackendID, req.Name)
+	if artifact == nil {
+		writeError(w, http.StatusNotFound, "not_found", "artifact not found")
+		return
+	}
+	for i, a := range s.artifacts {
+		if a == artifact {
+			s.artifacts = append(s.artifacts[:i], s.artifacts[i+1:]...)
+			break
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":          true,
+		"artifact_id": strconv.FormatInt(artifact.ID, 10),
+	})
+}
+
+// findFinalized returns the latest finalized artifact with the name.
+func (s *Server) findFinalized(runID, name string) *Artifact {
+	var artifact *Artifact
+	for _, a := range s.artifacts {
+		if a.Finalized && a.WorkflowRunBackendID == runID && a.Name == name {
+			artifact = a
+		}
+	}
+	return artifact
+}
+
+// signedURL returns the URL of the blob of the artifact, signed for the operation.
+func (s *Server) signedURL(id int64, operation string) string {
+	return fmt.Sprintf("%s%s%d?sp=%s&sig=%s", s.URL, blobPrefix, id, operation, s.signature(id, operation))
+}
+
+func (s *Server) signature(id int64, operation string) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d:%s", id, operation)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// serveBlob uploads or downloads the zip of an artifact with a signed URL, like a block blob
+// uploaded in a single request or in blocks.
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, path string) {
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	operation := r.URL.Query().Get("sp")
+	if !hmac.Equal([]byte(r.URL.Query().Get("sig")), []byte(s.signature(id, operation))) {
+		http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && operation == "upload" && r.URL.Query().Get("comp") == "block":
+		s.putBlock(w, r, id)
+	case r.Method == http.MethodPut && operation == "upload" && r.URL.Query().Get("comp") == "blocklist":
+		s.putBlockList(w, r, id)
+	case r.Method == http.MethodPut && operation == "upload":
+		s.putBlob(w, r, id)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && operation == "download":
+		s.getBlob(w, r, id)
+	default:
+		http.Error(w, "AuthorizationPermissionMismatch", http.StatusForbidden)
+	}
+}
+
+func (s *Server) putBlob(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+		http.Error(w, "MissingRequiredHeader", http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength < 0 {
+		http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifact := s.findByID(id)
+	if artifact == nil || artifact.Finalized {
+		http.Error(w, "BlobNotFound", http.StatusNotFound)
+		return
+	}
+	artifact.Data = data
+	artifact.Blocks = 0
+	w.WriteHeader(http.StatusCreated)
+}
+
+// putBlock stages a block of the blob.
+func (s *Server) putBlock(w http.ResponseWriter, r *http.Request, id int64) {
+	blockID := r.URL.Query().Get("blockid")
+	if _, err := base64.StdEncoding.DecodeString(blockID); err != nil || blockID == "" {
+		http.Error(w, "InvalidQueryParameterValue", http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength < 0 {
+		http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifact := s.findByID(id)
+	if artifact == nil || artifact.Finalized {
+		http.Error(w, "BlobNotFound", http.StatusNotFound)
+		return
+	}
+	if s.blocks[id] == nil {
+		s.blocks[id] = map[string][]byte{}
+	}
+	s.blocks[id][blockID] = data
+	w.WriteHeader(http.StatusCreated)
+}
+
+// putBlockList commits the staged blocks listed in the body as the content of the blob.
+func (s *Server) putBlockList(w http.ResponseWriter, r *http.Request, id int64) {
+	var blockList struct {
+		Latest []string `xml:"Latest"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&blockList); err != nil {
+		http.Error(w, "InvalidXmlDocument", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifact := s.findByID(id)
+	if artifact == nil || artifact.Finalized {
+		http.Error(w, "BlobNotFound", http.StatusNotFound)
+		return
+	}
+	var data []byte
+	for _, blockID := range blockList.Latest {
+		block, ok := s.blocks[id][blockID]
+		if !ok {
+			http.Error(w, "InvalidBlockList", http.StatusBadRequest)
+			return
+		}
+		data = append(data, block...)
+	}
+	artifact.Data = data
+	artifact.Blocks = len(blockList.Latest)
+	delete(s.blocks, id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) getBlob(w http.ResponseWriter, r *http.Request, id int64) {
+	s.mu.Lock()
+	artifact := s.findByID(id)
+	var data []byte
+	if artifact != nil && artifact.Finalized {
+		data = artifact.Data
+	}
+	s.mu.Unlock()
+	if data == nil {
+		http.Error(w, "BlobNotFound", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (s *Server) findByID(id int64) *Artifact {
+	for _, a := range s.artifacts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func parseInt64(data json.RawMessage) (int64, error) {
+	return strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a Twirp error.
+func writeError(w http.ResponseWriter, statusCode int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "msg": msg})
+}
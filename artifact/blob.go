@@ -0,0 +1,231 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ci-tools/toolkit/httpclient"
+)
+
+const downloadMaxRetries = 5
+
+// The delay between download attempts, overridden by tests.
+var downloadRetryDelay = 5 * time.Second
+
+// uploadZipToBlobStorage uploads the zip stream to the signed URL of a block blob, in blocks of
+// chunkSize bytes with up to concurrency blocks in flight, and commits the blocks. At most
+// concurrency blocks are held in memory. It returns the size and the SHA-256 digest of the zip.
+func uploadZipToBlobStorage(ctx context.Context, signedUploadURL string, zipStream io.Reader, chunkSize int, concurrency int) (int64, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client := httpclient.NewClient(userAgent, nil, nil)
+	hasher := sha256.New()
+	stream := io.TeeReader(zipStream, hasher)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	setError := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// The buffers of the blocks, allocated on first use and reused once uploaded
+	buffers := make(chan []byte, concurrency)
+	for i := 0; i < concurrency; i++ {
+		buffers <- nil
+	}
+
+	var (
+		blockIDs   []string
+		uploadSize int64
+	)
+	for {
+		var buf []byte
+		select {
+		case buf = <-buffers:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if buf == nil {
+			buf = make([]byte, chunkSize)
+		}
+
+		n, err := io.ReadFull(stream, buf)
+		if n > 0 {
+			blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blockIDs))))
+			blockIDs = append(blockIDs, blockID)
+			uploadSize += int64(n)
+			wg.Add(1)
+			go func(block []byte) {
+				defer wg.Done()
+				if err := stageBlock(ctx, client, signedUploadURL, blockID, block); err != nil {
+					setError(err)
+				}
+				buffers <- block[:cap(block)]
+			}(buf[:n])
+		} else {
+			buffers <- buf
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			setError(err)
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return 0, "", firstErr
+	}
+	if ctx.Err() != nil {
+		return 0, "", ctx.Err()
+	}
+
+	if err := commitBlockList(ctx, client, signedUploadURL, blockIDs); err != nil {
+		return 0, "", err
+	}
+	return uploadSize, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func stageBlock(ctx context.Context, client *httpclient.Client, signedUploadURL string, blockID string, block []byte) error {
+	blockURL, err := withQuery(signedUploadURL, map[string]string{"comp": "block", "blockid": blockID})
+	if err != nil {
+		return err
+	}
+	return retryBlobRequest(ctx, "stage block", func() (*http.Response, error) {
+		return client.Request(ctx, http.MethodPut, blockURL, bytes.NewReader(block), nil)
+	})
+}
+
+func commitBlockList(ctx context.Context, client *httpclient.Client, signedUploadURL string, blockIDs []string) error {
+	blockListURL, err := withQuery(signedUploadURL, map[string]string{"comp": "blocklist"})
+	if err != nil {
+		return err
+	}
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, blockID := range blockIDs {
+		body.WriteString("<Latest>" + blockID + "</Latest>")
+	}
+	body.WriteString("</BlockList>")
+
+	headers := http.Header{"Content-Type": {"application/xml"}, "X-Ms-Blob-Content-Type": {"zip"}}
+	return retryBlobRequest(ctx, "commit block list", func() (*http.Response, error) {
+		return client.Request(ctx, http.MethodPut, blockListURL, strings.NewReader(body.String()), headers)
+	})
+}
+
+func withQuery(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// retryBlobRequest sends the request to blob storage until it succeeds, fails with a status
+// code which is not retryable or the attempts are exhausted.
+func retryBlobRequest(ctx context.Context, name string, method func() (*http.Response, error)) error {
+	var errorMessage string
+	for attempt := 0; attempt < defaultMaxAttempts; attempt++ {
+		isRetryable := true
+		resp, err := method()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errorMessage = err.Error()
+		} else {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if isSuccessStatusCode(resp.StatusCode) {
+				return nil
+			}
+			isRetryable = isRetryableHTTPStatusCode(resp.StatusCode)
+			errorMessage = fmt.Sprintf("(%d) %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		if !isRetryable || attempt+1 == defaultMaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(getExponentialRetryTime(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("Failed to upload artifact zip to blob storage: %s failed: %s", name, errorMessage)
+}
+
+// streamExtract downloads the zip from the signed URL and extracts it into directory while
+// downloading, retrying on errors. It returns the SHA-256 digest of the zip, e.g. "sha256:...".
+func streamExtract(ctx context.Context, signedURL string, directory string) (string, error) {
+	var lastErr error
+	for retryCount := 0; retryCount < downloadMaxRetries; retryCount++ {
+		digest, err := streamExtractExternal(ctx, signedURL, directory)
+		if err == nil {
+			return digest, nil
+		}
+		if _, ok := err.(*malformedExtractionPathError); ok {
+			return "", fmt.Errorf("Artifact download failed with unretryable error: %w", err)
+		}
+		lastErr = err
+		if retryCount+1 == downloadMaxRetries {
+			break
+		}
+
+		timer := time.NewTimer(downloadRetryDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		}
+	}
+	return "", fmt.Errorf("Artifact download failed after %d retries: %w", downloadMaxRetries, lastErr)
+}
+
+func streamExtractExternal(ctx context.Context, signedURL string, directory string) (string, error) {
+	client := httpclient.NewClient(userAgent, nil, nil)
+	resp, err := client.Get(ctx, signedURL, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unexpected HTTP response from blob storage: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	hasher := sha256.New()
+	if err := unzipStream(io.TeeReader(resp.Body, hasher), directory); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
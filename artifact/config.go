@@ -0,0 +1,114 @@
+package artifact
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var errInvalidJwt = errors.New("Failed to get backend IDs: The provided JWT token is invalid and/or missing claims")
+
+// backendIDs identify the workflow run and the job in the results service.
+type backendIDs struct {
+	workflowRunBackendID    string
+	workflowJobRunBackendID string
+}
+
+func getRuntimeToken() (string, error) {
+	token := os.Getenv("ACTIONS_RUNTIME_TOKEN")
+	if token == "" {
+		return "", errors.New("Unable to get the ACTIONS_RUNTIME_TOKEN env variable")
+	}
+	return token, nil
+}
+
+// getResultsServiceURL returns the origin of ACTIONS_RESULTS_URL.
+func getResultsServiceURL() (string, error) {
+	resultsURL := os.Getenv("ACTIONS_RESULTS_URL")
+	if resultsURL == "" {
+		return "", errors.New("Unable to get the ACTIONS_RESULTS_URL env variable")
+	}
+	u, err := url.Parse(resultsURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+func getGitHubWorkspaceDir() (string, error) {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" {
+		return "", errors.New("Unable to get the GITHUB_WORKSPACE env variable")
+	}
+	return workspace, nil
+}
+
+// getRetentionDays returns the maximum retention of the repository, or 0 if it is not known.
+func getRetentionDays() int {
+	days, err := strconv.Atoi(os.Getenv("GITHUB_RETENTION_DAYS"))
+	if err != nil {
+		return 0
+	}
+	return days
+}
+
+// isGhes reports whether the server is GitHub Enterprise Server, where artifacts of this
+// version are not supported.
+func isGhes() bool {
+	serverURL := os.Getenv("GITHUB_SERVER_URL")
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return false
+	}
+	hostname := strings.ToUpper(strings.TrimSpace(u.Hostname()))
+	isGitHubHost := hostname == "GITHUB.COM"
+	isGheHost := strings.HasSuffix(hostname, ".GHE.COM")
+	isLocalHost := strings.HasSuffix(hostname, ".LOCALHOST")
+	return !isGitHubHost && !isGheHost && !isLocalHost
+}
+
+// getBackendIDsFromToken reads the backend ids from the scope of the runtime token, which
+// contains a claim like "Actions.Results:<workflow run backend id>:<job run backend id>".
+// The token is not verified; it is only read.
+func getBackendIDsFromToken() (backendIDs, error) {
+	token, err := getRuntimeToken()
+	if err != nil {
+		return backendIDs{}, err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return backendIDs{}, errInvalidJwt
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return backendIDs{}, errInvalidJwt
+	}
+	var claims struct {
+		Scp string `json:"scp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Scp == "" {
+		return backendIDs{}, errInvalidJwt
+	}
+
+	for _, scope := range strings.Split(claims.Scp, " ") {
+		scopeParts := strings.Split(scope, ":")
+		if scopeParts[0] != "Actions.Results" {
+			// not the Actions.Results scope
+			continue
+		}
+		// Actions.Results:{workflowRunBackendId}:{workflowJobRunBackendId}
+		if len(scopeParts) != 3 {
+			// missing expected number of claims
+			return backendIDs{}, errInvalidJwt
+		}
+		return backendIDs{workflowRunBackendID: scopeParts[1], workflowJobRunBackendID: scopeParts[2]}, nil
+	}
+	return backendIDs{}, errInvalidJwt
+}
@@ -0,0 +1,56 @@
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// UploadArtifactOptions controls UploadArtifact.
+type UploadArtifactOptions struct {
+	/** Optional. Duration after which artifact will expire in days. Minimum 1 day. Maximum 90 days unless changed from the repository settings page. Defaults to the retention of the repository */
+	RetentionDays *int
+
+	/** Optional. The level of compression for Zlib to be applied to the artifact archive, from 0 (no compression) to 9 (best compression). Defaults to 6 */
+	CompressionLevel *int
+
+	/** Optional. Number of blocks of the artifact archive uploaded in parallel. Defaults to 4 */
+	UploadConcurrency *int
+
+	/** Optional. Size in bytes of the blocks of the artifact archive. At most UploadConcurrency blocks are held in memory. Defaults to 8MB */
+	UploadChunkSize *int
+}
+
+// ListArtifactsOptions controls ListArtifacts.
+type ListArtifactsOptions struct {
+	/** Optional. Filter the workflow run's artifacts to the latest by name. In the case of reruns, this can be useful to avoid duplicates. Defaults to false */
+	Latest *bool
+}
+
+// getUploadOptions returns a copy of the upload options with defaults applied.
+func getUploadOptions(options *UploadArtifactOptions) (UploadArtifactOptions, error) {
+	result := UploadArtifactOptions{
+		CompressionLevel:  ptr.Int(6),
+		UploadConcurrency: ptr.Int(4),
+		UploadChunkSize:   ptr.Int(8 * 1024 * 1024),
+	}
+	if options == nil {
+		return result, nil
+	}
+	if options.RetentionDays != nil {
+		result.RetentionDays = ptr.Int(*options.RetentionDays)
+	}
+	if options.CompressionLevel != nil {
+		if *options.CompressionLevel < 0 || *options.CompressionLevel > 9 {
+			return result, fmt.Errorf("Invalid compression level: %d. Valid values are 0-9.", *options.CompressionLevel)
+		}
+		result.CompressionLevel = ptr.Int(*options.CompressionLevel)
+	}
+	if options.UploadConcurrency != nil && *options.UploadConcurrency > 0 {
+		result.UploadConcurrency = ptr.Int(*options.UploadConcurrency)
+	}
+	if options.UploadChunkSize != nil && *options.UploadChunkSize > 0 {
+		result.UploadChunkSize = ptr.Int(*options.UploadChunkSize)
+	}
+	return result, nil
+}
@@ -0,0 +1,232 @@
+package artifact
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fileHeaderSignature      = 0x04034b50
+	directoryHeaderSignature = 0x02014b50
+	directoryEndSignature    = 0x06054b50
+	dataDescriptorSignature  = 0x08074b50
+	fileHeaderLen            = 30
+	zip64ExtraID             = 0x0001
+	uint32max                = 1<<32 - 1
+
+	methodStore   = 0
+	methodDeflate = 8
+)
+
+type malformedExtractionPathError struct {
+	path string
+}
+
+func (e *malformedExtractionPathError) Error() string {
+	return "Malformed extraction path: " + e.path
+}
+
+// countingReader counts the bytes read from a buffered reader. It implements io.ByteReader so
+// that compress/flate does not read past the end of the compressed data.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// unzipStream extracts the zip read from r into directory as it is read, reading the local
+// file headers instead of the central directory at the end of the zip, and then reads r to
+// the end. Entries must be deflated or stored with their sizes known in the local file header,
+// as written by UploadArtifact and actions/upload-artifact.
+func unzipStream(r io.Reader, directory string) error {
+	br := bufio.NewReader(r)
+	for {
+		var signature [4]byte
+		if _, err := io.ReadFull(br, signature[:]); err != nil {
+			if err == io.EOF {
+				return errors.New("zip: not a valid zip file")
+			}
+			return err
+		}
+		switch binary.LittleEndian.Uint32(signature[:]) {
+		case fileHeaderSignature:
+			if err := extractEntry(br, directory); err != nil {
+				return err
+			}
+		case directoryHeaderSignature, directoryEndSignature:
+			// The central directory follows the last entry
+			_, err := io.Copy(io.Discard, br)
+			return err
+		default:
+			return errors.New("zip: not a valid zip file")
+		}
+	}
+}
+
+// extractEntry extracts the entry whose local file header follows its signature.
+func extractEntry(br *bufio.Reader, directory string) error {
+	var buf [fileHeaderLen - 4]byte
+	if _, err := io.ReadFull(br, buf[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	flags := binary.LittleEndian.Uint16(buf[2:])
+	method := binary.LittleEndian.Uint16(buf[4:])
+	crc := binary.LittleEndian.Uint32(buf[10:])
+	compressedSize := uint64(binary.LittleEndian.Uint32(buf[14:]))
+	uncompressedSize := uint64(binary.LittleEndian.Uint32(buf[18:]))
+	nameLen := int(binary.LittleEndian.Uint16(buf[22:]))
+	extraLen := int(binary.LittleEndian.Uint16(buf[24:]))
+
+	nameAndExtra := make([]byte, nameLen+extraLen)
+	if _, err := io.ReadFull(br, nameAndExtra); err != nil {
+		return unexpectedEOF(err)
+	}
+	name := string(nameAndExtra[:nameLen])
+	zip64 := false
+	for extra := nameAndExtra[nameLen:]; len(extra) >= 4; {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if size > len(extra)-4 {
+			break
+		}
+		if id == zip64ExtraID {
+			zip64 = true
+			field := extra[4 : 4+size]
+			if uncompressedSize == uint32max && len(field) >= 8 {
+				uncompressedSize = binary.LittleEndian.Uint64(field)
+				field = field[8:]
+			}
+			if compressedSize == uint32max && len(field) >= 8 {
+				compressedSize = binary.LittleEndian.Uint64(field)
+			}
+		}
+		extra = extra[4+size:]
+	}
+
+	if flags&0x1 != 0 {
+		return fmt.Errorf("zip: encrypted entry %s is not supported", name)
+	}
+	hasDataDescriptor := flags&0x8 != 0
+	isDir := strings.HasSuffix(name, "/")
+
+	fullPath := filepath.Join(directory, filepath.FromSlash(name))
+	if !strings.HasPrefix(fullPath, filepath.Clean(directory)+string(filepath.Separator)) {
+		return &malformedExtractionPathError{path: fullPath}
+	}
+
+	cr := &countingReader{r: br}
+	var content io.Reader
+	switch method {
+	case methodDeflate:
+		fr := flate.NewReader(cr)
+		defer fr.Close()
+		content = fr
+	case methodStore:
+		if hasDataDescriptor && !isDir {
+			return fmt.Errorf("zip: stored entry %s with a data descriptor is not supported", name)
+		}
+		content = io.LimitReader(cr, int64(compressedSize))
+	default:
+		return fmt.Errorf("zip: unsupported compression method %d of entry %s", method, name)
+	}
+
+	hasher := crc32.NewIEEE()
+	var written int64
+	if isDir {
+		if err := os.MkdirAll(fullPath, 0o755); err != nil {
+			return err
+		}
+		if _, err := io.Copy(hasher, content); err != nil {
+			return fmt.Errorf("zip: reading entry %s: %w", name, unexpectedEOF(err))
+		}
+	} else {
+		var err error
+		written, err = writeEntry(fullPath, io.TeeReader(content, hasher))
+		if err != nil {
+			return fmt.Errorf("zip: reading entry %s: %w", name, err)
+		}
+	}
+
+	if hasDataDescriptor {
+		zip64 = zip64 || cr.n > uint32max || written > uint32max
+		var err error
+		crc, compressedSize, uncompressedSize, err = readDataDescriptor(br, zip64)
+		if err != nil {
+			return err
+		}
+	}
+	if uint64(cr.n) != compressedSize || uint64(written) != uncompressedSize || hasher.Sum32() != crc {
+		return fmt.Errorf("zip: checksum error in entry %s", name)
+	}
+	return nil
+}
+
+func writeEntry(fullPath string, r io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return 0, err
+	}
+	w, err := os.Create(fullPath)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, r)
+	if err != nil {
+		w.Close()
+		return n, unexpectedEOF(err)
+	}
+	return n, w.Close()
+}
+
+// readDataDescriptor reads the data descriptor following the data of an entry, whose
+// signature is optional.
+func readDataDescriptor(br *bufio.Reader, zip64 bool) (crc uint32, compressedSize, uncompressedSize uint64, err error) {
+	peek, err := br.Peek(4)
+	if err != nil {
+		return 0, 0, 0, unexpectedEOF(err)
+	}
+	if binary.LittleEndian.Uint32(peek) == dataDescriptorSignature {
+		br.Discard(4)
+	}
+
+	size := 12
+	if zip64 {
+		size = 20
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return 0, 0, 0, unexpectedEOF(err)
+	}
+	crc = binary.LittleEndian.Uint32(buf)
+	if zip64 {
+		return crc, binary.LittleEndian.Uint64(buf[4:]), binary.LittleEndian.Uint64(buf[12:]), nil
+	}
+	return crc, uint64(binary.LittleEndian.Uint32(buf[4:])), uint64(binary.LittleEndian.Uint32(buf[8:])), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
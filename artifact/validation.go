@@ -0,0 +1,77 @@
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type invalidCharacter struct {
+	character   string
+	description string
+}
+
+// Invalid characters that cannot be in the artifact name or an uploaded file. Will be rejected
+// from the server if attempted to be sent over. These characters are not allowed due to
+// limitations with certain file systems such as NTFS. To maintain file system agnostic
+// behavior, all characters that are not supported by an operating system are not allowed.
+var invalidArtifactFilePathCharacters = []invalidCharacter{
+	{`"`, ` Double quote "`},
+	{":", " Colon :"},
+	{"<", " Less than <"},
+	{">", " Greater than >"},
+	{"|", " Vertical bar |"},
+	{"*", " Asterisk *"},
+	{"?", " Question mark ?"},
+	{"\r", ` Carriage return \r`},
+	{"\n", ` Line feed \n`},
+}
+
+var invalidArtifactNameCharacters = append(append([]invalidCharacter(nil), invalidArtifactFilePathCharacters...),
+	invalidCharacter{`\`, ` Backslash \`},
+	invalidCharacter{"/", " Forward slash /"},
+)
+
+func describeCharacters(characters []invalidCharacter) string {
+	descriptions := make([]string, 0, len(characters))
+	for _, c := range characters {
+		descriptions = append(descriptions, c.description)
+	}
+	return strings.Join(descriptions, ",")
+}
+
+// validateArtifactName checks that the name has no characters which are not allowed.
+func validateArtifactName(name string) error {
+	if name == "" {
+		return errors.New("Provided artifact name input during validation is empty")
+	}
+	for _, c := range invalidArtifactNameCharacters {
+		if strings.Contains(name, c.character) {
+			return fmt.Errorf(`The artifact name is not valid: %s. Contains the following character: %s
+
+Invalid characters include: %s
+
+These characters are not allowed in the artifact name due to limitations with certain file systems such as NTFS. To maintain file system agnostic behavior, these characters are intentionally not allowed to prevent potential problems with downloads on different file systems.`,
+				name, c.description, describeCharacters(invalidArtifactNameCharacters))
+		}
+	}
+	return nil
+}
+
+// validateFilePath checks that the path of an uploaded file has no characters which are not allowed.
+func validateFilePath(path string) error {
+	if path == "" {
+		return errors.New("Provided file path input during validation is empty")
+	}
+	for _, c := range invalidArtifactFilePathCharacters {
+		if strings.Contains(path, c.character) {
+			return fmt.Errorf(`The path for one of the files in artifact is not valid: %s. Contains the following character: %s
+
+Invalid characters include: %s
+
+The following characters are not allowed in files that are uploaded due to limitations with certain file systems such as NTFS. To maintain file system agnostic behavior, these characters are intentionally not allowed to prevent potential problems with downloads on different file systems.`,
+				path, c.description, describeCharacters(invalidArtifactFilePathCharacters))
+		}
+	}
+	return nil
+}
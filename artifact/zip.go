@@ -0,0 +1,117 @@
+package artifact
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadZipSpecification is a file or an empty directory added to the zip.
+type uploadZipSpecification struct {
+	// sourcePath is the file to add, or empty for a directory
+	sourcePath string
+
+	// destinationPath is the path within the zip, relative to the root directory
+	destinationPath string
+
+	info os.FileInfo
+}
+
+func validateRootDirectory(rootDirectory string) error {
+	info, err := os.Stat(rootDirectory)
+	if err != nil {
+		return fmt.Errorf("The provided rootDirectory %s does not exist", rootDirectory)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("The provided rootDirectory %s is not a valid directory", rootDirectory)
+	}
+	return nil
+}
+
+// getUploadZipSpecification returns the entries of the zip for the files, which must all be
+// under rootDirectory. Directories are added as empty directories.
+func getUploadZipSpecification(filesToZip []string, rootDirectory string) ([]uploadZipSpecification, error) {
+	var specification []uploadZipSpecification
+	rootDirectory = filepath.Clean(rootDirectory)
+
+	for _, file := range filesToZip {
+		info, err := os.Lstat(file)
+		if err != nil {
+			return nil, fmt.Errorf("File %s does not exist", file)
+		}
+		file = filepath.Clean(file)
+		relativePath, err := filepath.Rel(rootDirectory, file)
+		if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("The rootDirectory: %s is not a parent directory of the file: %s", rootDirectory, file)
+		}
+		uploadPath := filepath.ToSlash(relativePath)
+		if err := validateFilePath(uploadPath); err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			// Empty directories are included in the zip
+			if uploadPath != "." {
+				specification = append(specification, uploadZipSpecification{destinationPath: uploadPath, info: info})
+			}
+			continue
+		}
+		specification = append(specification, uploadZipSpecification{sourcePath: file, destinationPath: uploadPath, info: info})
+	}
+	return specification, nil
+}
+
+// createZip writes the zip of the entries to w, compressing the files with the level of
+// compress/flate, from 0 (no compression) to 9 (best compression).
+func createZip(w io.Writer, specification []uploadZipSpecification, compressionLevel int) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, compressionLevel)
+	})
+
+	for _, file := range specification {
+		header := &zip.FileHeader{
+			Name:     file.destinationPath,
+			Method:   zip.Deflate,
+			Modified: file.info.ModTime(),
+		}
+		if file.sourcePath == "" {
+			header.Name += "/"
+			header.Method = zip.Store
+			header.SetMode(file.info.Mode())
+			if _, err := zw.CreateHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := addFile(zw, header, file.sourcePath); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// addFile adds the content of the file, following symbolic links.
+func addFile(zw *zip.Writer, header *zip.FileHeader, sourcePath string) error {
+	f, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header.SetMode(info.Mode())
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,206 @@
+package artifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readFiles returns the content of the files under root by their slash separated paths,
+// with an empty value for directories.
+func readFiles(t *testing.T, root string) map[string]string {
+	files := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			files[filepath.ToSlash(rel)+"/"] = ""
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return files
+}
+
+func Test_CreateZipAndUnzipStream(t *testing.T) {
+	random := make([]byte, 100*1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	root := t.TempDir()
+	paths := writeFiles(t, root, map[string]string{
+		"a.txt":       strings.Repeat("a", 100*1024),
+		"random.bin":  string(random),
+		"dir/b.txt":   "b",
+		"dir/empty":   "",
+		"dir/sub/c.c": "int main() {}",
+	})
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0o755); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	paths = append(paths, filepath.Join(root, "empty"))
+	specification, err := getUploadZipSpecification(paths, root)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	expected := readFiles(t, root)
+
+	for _, compressionLevel := range []int{0, 1, 6, 9} {
+		var buf bytes.Buffer
+		if err := createZip(&buf, specification, compressionLevel); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+
+		// The zip can be read with its central directory too
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil || len(zr.File) != len(specification) {
+			t.Fatalf("unexpected zip: %v %v", zr, err)
+		}
+
+		directory := t.TempDir()
+		if err := unzipStream(&buf, directory); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if actual := readFiles(t, directory); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", len(expected), len(actual), compressionLevel)
+		}
+	}
+}
+
+func Test_UnzipStreamFormats(t *testing.T) {
+	goWriter := func(create func(zw *zip.Writer) error) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		if err := create(zw); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		return buf.Bytes()
+	}
+	readGolden := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join("testdata", "zip", name))
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		return data
+	}
+
+	table := []struct {
+		name     string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name: "deflated with data descriptors",
+			data: goWriter(func(zw *zip.Writer) error {
+				w, err := zw.Create("dir/a.txt")
+				if err != nil {
+					return err
+				}
+				_, err = w.Write([]byte("hello"))
+				return err
+			}),
+			expected: map[string]string{"dir/": "", "dir/a.txt": "hello"},
+		},
+		{
+			name: "stored with sizes",
+			data: goWriter(func(zw *zip.Writer) error {
+				w, err := zw.CreateRaw(&zip.FileHeader{Name: "a.txt", Method: zip.Store, CRC32: 0x3610a686, CompressedSize64: 5, UncompressedSize64: 5})
+				if err != nil {
+					return err
+				}
+				_, err = w.Write([]byte("hello"))
+				return err
+			}),
+			expected: map[string]string{"a.txt": "hello"},
+		},
+		{
+			name:     "empty",
+			data:     goWriter(func(zw *zip.Writer) error { return nil }),
+			expected: map[string]string{},
+		},
+		{
+			// Created with the zipfile module of Python
+			name:     "python.zip",
+			data:     readGolden("python.zip"),
+			expected: map[string]string{"dir/": "", "dir/stored.txt": "stored content\n", "deflated.txt": strings.Repeat("deflated content\n", 100)},
+		},
+		{
+			// Created with `zip > infozip-stream.zip` from the standard input, with zip64 sizes
+			name:     "infozip-stream.zip",
+			data:     readGolden("infozip-stream.zip"),
+			expected: map[string]string{"-": strings.Repeat("hello streaming zip\n", 1000)},
+		},
+		{
+			// Created with `zip | cat > infozip-pipe.zip`, with a zip64 data descriptor
+			name:     "infozip-pipe.zip",
+			data:     readGolden("infozip-pipe.zip"),
+			expected: map[string]string{"-": strings.Repeat("hello streaming zip\n", 1000)},
+		},
+	}
+	for _, tt := range table {
+		directory := t.TempDir()
+		r := bytes.NewReader(tt.data)
+		if err := unzipStream(r, directory); err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if r.Len() != 0 {
+			t.Fatalf("expected the zip to be read to the end, but %d bytes were left.\ntest case: %v", r.Len(), tt.name)
+		}
+		if actual := readFiles(t, directory); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+}
+
+func Test_UnzipStreamErrors(t *testing.T) {
+	var valid bytes.Buffer
+	zw := zip.NewWriter(&valid)
+	w, _ := zw.Create("a.txt")
+	w.Write([]byte(strings.Repeat("hello", 100)))
+	zw.Close()
+
+	var storedWithDescriptor bytes.Buffer
+	zw = zip.NewWriter(&storedWithDescriptor)
+	w, _ = zw.CreateHeader(&zip.FileHeader{Name: "a.txt", Method: zip.Store})
+	w.Write([]byte("hello"))
+	zw.Close()
+
+	corrupted := append([]byte(nil), valid.Bytes()...)
+	corrupted[len("PK\x03\x04")+26+len("a.txt")+2] ^= 0xff
+
+	table := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{name: "truncated", data: valid.Bytes()[:40], expected: "unexpected EOF"},
+		{name: "corrupted", data: corrupted, expected: "a.txt"},
+		{name: "not a zip", data: []byte("not a zip file"), expected: "zip: not a valid zip file"},
+		{name: "no data", data: nil, expected: "zip: not a valid zip file"},
+		{name: "stored with data descriptor", data: storedWithDescriptor.Bytes(), expected: "zip: stored entry a.txt with a data descriptor is not supported"},
+	}
+	for _, tt := range table {
+		err := unzipStream(bytes.NewReader(tt.data), t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.expected, err, tt.name)
+		}
+	}
+}
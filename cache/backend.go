@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Backend stores the cache entries. The Actions cache service is used by default; a
+// FileSystemBackend can be used where the service is not available.
+type Backend interface {
+	// GetCacheEntry returns the entry of the first key matching exactly, or else by prefix with
+	// the newest entry first, among the entries of the version. It returns nil if none matches.
+	GetCacheEntry(ctx context.Context, keys []string, version string) (*ArtifactCacheEntry, error)
+
+	// DownloadCache downloads the archive of the entry to archivePath.
+	DownloadCache(ctx context.Context, entry *ArtifactCacheEntry, archivePath string, options DownloadOptions) error
+
+	// SaveCache stores the archive as the entry of the key and the version, and returns its id.
+	// It returns a *ReserveCacheError if the entry already exists or is being saved.
+	SaveCache(ctx context.Context, key string, version string, archivePath string, options UploadOptions) (int64, error)
+}
+
+type serviceBackend struct{}
+
+// NewServiceBackend returns the backend using the Actions cache service at ACTIONS_CACHE_URL.
+func NewServiceBackend() Backend {
+	return serviceBackend{}
+}
+
+func (serviceBackend) GetCacheEntry(ctx context.Context, keys []string, version string) (*ArtifactCacheEntry, error) {
+	return getCacheEntry(ctx, keys, version)
+}
+
+func (serviceBackend) DownloadCache(ctx context.Context, entry *ArtifactCacheEntry, archivePath string, options DownloadOptions) error {
+	return downloadCache(ctx, entry.ArchiveLocation, archivePath, options)
+}
+
+func (serviceBackend) SaveCache(ctx context.Context, key string, version string, archivePath string, options UploadOptions) (int64, error) {
+	const fileSizeLimit = 10 * 1024 * 1024 * 1024 // 10GB per repo limit
+	archiveFileSize, err := getArchiveFileSizeInBytes(archivePath)
+	if err != nil {
+		return -1, err
+	}
+	if archiveFileSize > fileSizeLimit && !isGhes() {
+		return -1, fmt.Errorf("Cache size of ~%d MB (%d B) is over the 10GB limit, not saving cache.", archiveFileSize/(1024*1024), archiveFileSize)
+	}
+
+	cacheID, statusCode, err := reserveCache(ctx, key, version, archiveFileSize)
+	if err != nil && statusCode == 0 {
+		return -1, err
+	}
+	if cacheID == 0 {
+		if statusCode == http.StatusBadRequest {
+			if err != nil {
+				return -1, err
+			}
+			return -1, fmt.Errorf("Cache size of ~%d MB (%d B) is over the data cap limit, not saving cache.", archiveFileSize/(1024*1024), archiveFileSize)
+		}
+		message := ""
+		if err != nil {
+			message = err.Error()
+		}
+		return -1, &ReserveCacheError{Message: fmt.Sprintf("Unable to reserve cache with key %s, another job may be creating this cache. More details: %s", key, message)}
+	}
+
+	if err := saveCache(ctx, cacheID, archivePath, options); err != nil {
+		return -1, err
+	}
+	return cacheID, nil
+}
@@ -0,0 +1,160 @@
+// Package cache saves and restores dependencies and build outputs with the Actions cache
+// service, like @actions/cache.
+//
+// Entries are tar archives of paths relative to GITHUB_WORKSPACE, compressed with zstd when it is
+// installed and gzip otherwise, keyed by a user provided key and a version derived from the paths
+// and the compression method. They are compatible with the entries of actions/cache.
+//
+// Entries are stored by a Backend: the Actions cache service by default, or a FileSystemBackend
+// on self-hosted runners without access to it.
+// The service is located with ACTIONS_CACHE_URL and authenticated with ACTIONS_RUNTIME_TOKEN.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// ValidationError is returned when the paths or keys are not valid.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ReserveCacheError is returned by SaveCache when the entry cannot be reserved, typically
+// because another job is creating it.
+type ReserveCacheError struct {
+	Message string
+}
+
+func (e *ReserveCacheError) Error() string {
+	return e.Message
+}
+
+func checkPaths(paths []string) error {
+	if len(paths) == 0 {
+		return &ValidationError{Message: "Path Validation Error: At least one directory or file path is required"}
+	}
+	return nil
+}
+
+func checkKey(key string) error {
+	const maxKeyLength = 512
+	if len(utf16.Encode([]rune(key))) > maxKeyLength {
+		return &ValidationError{Message: fmt.Sprintf("Key Validation Error: %s cannot be larger than %d characters.", key, maxKeyLength)}
+	}
+	if strings.Contains(key, ",") {
+		return &ValidationError{Message: fmt.Sprintf("Key Validation Error: %s cannot contain commas.", key)}
+	}
+	return nil
+}
+
+// IsFeatureAvailable reports whether the cache service is available.
+func IsFeatureAvailable() bool {
+	return os.Getenv("ACTIONS_CACHE_URL") != ""
+}
+
+// RestoreCache restores the cache entry of the first key matching exactly or by prefix,
+// and returns that key. An empty key is returned when no entry matches. options may be nil.
+//
+// @actions/cache only fails on a *ValidationError; callers typically log other errors as
+// warnings and continue without the cache.
+func RestoreCache(ctx context.Context, paths []string, primaryKey string, restoreKeys []string, options *DownloadOptions) (string, error) {
+	if err := checkPaths(paths); err != nil {
+		return "", err
+	}
+
+	keys := append([]string{primaryKey}, restoreKeys...)
+	if len(keys) > 10 {
+		return "", &ValidationError{Message: "Key Validation Error: Keys are limited to a maximum of 10."}
+	}
+	for _, key := range keys {
+		if err := checkKey(key); err != nil {
+			return "", err
+		}
+	}
+
+	opts := getDownloadOptions(options)
+	compressionMethod := GetCompressionMethod(ctx)
+	version := GetCacheVersion(paths, compressionMethod, *opts.EnableCrossOsArchive)
+	cacheEntry, err := opts.Backend.GetCacheEntry(ctx, keys, version)
+	if err != nil {
+		return "", fmt.Errorf("Failed to restore: %w", err)
+	}
+	if cacheEntry == nil {
+		// Cache not found
+		return "", nil
+	}
+	if *opts.LookupOnly {
+		return cacheEntry.CacheKey, nil
+	}
+
+	archiveFolder, err := createTempDirectory()
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(archiveFolder)
+	archivePath := filepath.Join(archiveFolder, GetCacheFileName(compressionMethod))
+
+	if err := opts.Backend.DownloadCache(ctx, cacheEntry, archivePath, opts); err != nil {
+		return "", fmt.Errorf("Failed to restore: %w", err)
+	}
+	if err := ExtractTar(ctx, archivePath, compressionMethod); err != nil {
+		return "", fmt.Errorf("Failed to restore: %w", err)
+	}
+	return cacheEntry.CacheKey, nil
+}
+
+// SaveCache archives the paths, which may be glob patterns, and saves them with the key.
+// It returns the id of the saved entry. options may be nil.
+//
+// A *ReserveCacheError is returned when an entry with the key already exists or is being
+// created by another job.
+func SaveCache(ctx context.Context, paths []string, key string, options *UploadOptions) (int64, error) {
+	if err := checkPaths(paths); err != nil {
+		return -1, err
+	}
+	if err := checkKey(key); err != nil {
+		return -1, err
+	}
+
+	opts := getUploadOptions(options)
+	compressionMethod := GetCompressionMethod(ctx)
+	cachePaths, err := resolvePaths(ctx, paths)
+	if err != nil {
+		return -1, err
+	}
+	if len(cachePaths) == 0 {
+		return -1, errors.New("Path Validation Error: Path(s) specified in the action for caching do(es) not exist, hence no cache is being saved.")
+	}
+
+	archiveFolder, err := createTempDirectory()
+	if err != nil {
+		return -1, err
+	}
+	defer os.RemoveAll(archiveFolder)
+	archivePath := filepath.Join(archiveFolder, GetCacheFileName(compressionMethod))
+
+	if err := CreateTar(ctx, archiveFolder, cachePaths, compressionMethod); err != nil {
+		return -1, fmt.Errorf("Failed to save: %w", err)
+	}
+
+	version := GetCacheVersion(paths, compressionMethod, *opts.EnableCrossOsArchive)
+	cacheID, err := opts.Backend.SaveCache(ctx, key, version, archivePath, opts)
+	if err != nil {
+		var reserveErr *ReserveCacheError
+		if errors.As(err, &reserveErr) {
+			return -1, err
+		}
+		return -1, fmt.Errorf("Failed to save: %w", err)
+	}
+	return cacheID, nil
+}
@@ -0,0 +1,434 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ci-tools/toolkit/httpclient"
+)
+
+const (
+	userAgent            = "actions/cache"
+	defaultRetryAttempts = 2
+)
+
+// The delay between attempts and the size of the download ranges, overridden by tests.
+var (
+	retryDelay        = 5 * time.Second
+	downloadBlockSize = int64(4 * 1024 * 1024)
+)
+
+// ArtifactCacheEntry is an entry found by a Backend.
+type ArtifactCacheEntry struct {
+	CacheKey        string `json:"cacheKey,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+	CreationTime    string `json:"creationTime,omitempty"`
+	ArchiveLocation string `json:"archiveLocation,omitempty"`
+}
+
+type reserveCacheRequest struct {
+	Key       string `json:"key"`
+	Version   string `json:"version,omitempty"`
+	CacheSize int64  `json:"cacheSize,omitempty"`
+}
+
+type reserveCacheResponse struct {
+	CacheID int64 `json:"cacheId"`
+}
+
+type commitCacheRequest struct {
+	Size int64 `json:"size"`
+}
+
+func getCacheAPIURL(resource string) (string, error) {
+	baseURL := os.Getenv("ACTIONS_CACHE_URL")
+	if baseURL == "" {
+		return "", errors.New("Cache Service Url not found, unable to restore cache.")
+	}
+	return baseURL + "_apis/artifactcache/" + resource, nil
+}
+
+func createHTTPClient() *httpclient.Client {
+	token := os.Getenv("ACTIONS_RUNTIME_TOKEN")
+	handler := &httpclient.BearerCredentialHandler{Token: token}
+	return httpclient.NewClient(userAgent, []httpclient.RequestHandler{handler}, &httpclient.RequestOptions{
+		Headers: http.Header{"Accept": {"application/json;api-version=6.0-preview.1"}},
+	})
+}
+
+func isSuccessStatusCode(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+func isServerErrorStatusCode(statusCode int) bool {
+	return statusCode >= 500
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
+
+// retry calls method until it returns a status code which is not a server error.
+// Errors and retryable status codes are retried once after retryDelay.
+func retry(ctx context.Context, name string, method func() (int, error)) error {
+	var errorMessage string
+	for attempt := 1; attempt <= defaultRetryAttempts; attempt++ {
+		statusCode, err := method()
+		isRetryable := false
+		if err != nil {
+			isRetryable = true
+			errorMessage = err.Error()
+		} else if !isServerErrorStatusCode(statusCode) {
+			return nil
+		} else {
+			isRetryable = isRetryableStatusCode(statusCode)
+			errorMessage = fmt.Sprintf("Cache service responded with %d", statusCode)
+		}
+		if !isRetryable || ctx.Err() != nil || attempt == defaultRetryAttempts {
+			break
+		}
+
+		timer := time.NewTimer(retryDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("%s failed: %s", name, errorMessage)
+}
+
+// retryTypedResponse retries a JSON request. A response with an error status code is returned
+// with the *httpclient.HTTPError instead of being retried, unless it is a server error.
+func retryTypedResponse(ctx context.Context, name string, method func() (*httpclient.TypedResponse, error)) (int, error) {
+	var (
+		statusCode int
+		httpErr    error
+	)
+	err := retry(ctx, name, func() (int, error) {
+		resp, err := method()
+		var e *httpclient.HTTPError
+		if errors.As(err, &e) {
+			statusCode, httpErr = e.StatusCode, e
+			return statusCode, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		statusCode, httpErr = resp.StatusCode, nil
+		return statusCode, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return statusCode, httpErr
+}
+
+// retryHTTPClientResponse retries a request, returning the response of the last attempt.
+// The caller must close the response body.
+func retryHTTPClientResponse(ctx context.Context, name string, method func() (*http.Response, error)) (*http.Response, error) {
+	var resp *http.Response
+	err := retry(ctx, name, func() (int, error) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		var err error
+		resp, err = method()
+		if err != nil {
+			resp = nil
+			return 0, err
+		}
+		return resp.StatusCode, nil
+	})
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getCacheEntry looks up the first entry matching the keys and the version, or returns nil.
+func getCacheEntry(ctx context.Context, keys []string, version string) (*ArtifactCacheEntry, error) {
+	resource := fmt.Sprintf("cache?keys=%s&version=%s", url.QueryEscape(strings.Join(keys, ",")), version)
+	resourceURL, err := getCacheAPIURL(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	client := createHTTPClient()
+	var cacheResult ArtifactCacheEntry
+	statusCode, err := retryTypedResponse(ctx, "getCacheEntry", func() (*httpclient.TypedResponse, error) {
+		return client.GetJSON(ctx, resourceURL, &cacheResult, nil)
+	})
+	// Cache not found
+	if statusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if err != nil && statusCode == 0 {
+		return nil, err
+	}
+	if !isSuccessStatusCode(statusCode) {
+		return nil, fmt.Errorf("Cache service responded with %d", statusCode)
+	}
+
+	if cacheResult.ArchiveLocation == "" {
+		// Cache achiveLocation not found. This should never happen, and hence bail out.
+		return nil, errors.New("Cache not found.")
+	}
+	return &cacheResult, nil
+}
+
+// downloadCache downloads the archive with concurrent range requests.
+func downloadCache(ctx context.Context, archiveLocation string, archivePath string, options DownloadOptions) (err error) {
+	timeout := *options.Timeout
+	client := httpclient.NewClient(userAgent, nil, &httpclient.RequestOptions{Timeout: &timeout})
+
+	resp, err := retryHTTPClientResponse(ctx, "downloadCacheMetadata", func() (*http.Response, error) {
+		return client.Head(ctx, archiveLocation, nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if !isSuccessStatusCode(resp.StatusCode) {
+		return fmt.Errorf("Unexpected HTTP response: %d", resp.StatusCode)
+	}
+	lengthHeader := resp.Header.Get("Content-Length")
+	if lengthHeader == "" {
+		return errors.New("Content-Length not found on blob response")
+	}
+	length, err := strconv.ParseInt(lengthHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Could not interpret Content-Length: %s", lengthHeader)
+	}
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offsets := make(chan int64)
+	errs := make(chan error, *options.DownloadConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < *options.DownloadConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				count := downloadBlockSize
+				if length-offset < count {
+					count = length - offset
+				}
+				if err := downloadSegment(ctx, client, archiveLocation, f, offset, count); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(offsets)
+		for offset := int64(0); offset < length; offset += downloadBlockSize {
+			select {
+			case offsets <- offset:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return nil
+}
+
+// downloadSegment downloads count bytes at offset into the file.
+func downloadSegment(ctx context.Context, client *httpclient.Client, archiveLocation string, f *os.File, offset, count int64) error {
+	headers := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", offset, offset+count-1)}}
+	resp, err := retryHTTPClientResponse(ctx, "downloadCachePart", func() (*http.Response, error) {
+		return client.Get(ctx, archiveLocation, headers)
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent && !(resp.StatusCode == http.StatusOK && offset == 0 && resp.ContentLength == count) {
+		return fmt.Errorf("Unexpected HTTP response: %d", resp.StatusCode)
+	}
+
+	n, err := io.Copy(&offsetWriter{f: f, offset: offset}, io.LimitReader(resp.Body, count))
+	if err != nil {
+		return err
+	}
+	if n != count {
+		return fmt.Errorf("Incomplete download. Expected file size: %d, actual file size: %d", count, n)
+	}
+	return nil
+}
+
+// offsetWriter writes sequentially into the file from offset.
+type offsetWriter struct {
+	f      *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+// reserveCache reserves an entry for the key, returning the status code and the error of the
+// response when the entry cannot be reserved.
+func reserveCache(ctx context.Context, key string, version string, cacheSize int64) (int64, int, error) {
+	resourceURL, err := getCacheAPIURL("caches")
+	if err != nil {
+		return 0, 0, err
+	}
+	reserveCacheRequest := reserveCacheRequest{
+		Key:       key,
+		Version:   version,
+		CacheSize: cacheSize,
+	}
+
+	client := createHTTPClient()
+	var result reserveCacheResponse
+	statusCode, err := retryTypedResponse(ctx, "reserveCache", func() (*httpclient.TypedResponse, error) {
+		return client.PostJSON(ctx, resourceURL, reserveCacheRequest, &result, nil)
+	})
+	return result.CacheID, statusCode, err
+}
+
+// saveCache uploads the archive in parallel chunks and commits the entry.
+func saveCache(ctx context.Context, cacheID int64, archivePath string, options UploadOptions) error {
+	client := createHTTPClient()
+	if err := uploadFile(ctx, client, cacheID, archivePath, options); err != nil {
+		return err
+	}
+
+	// Commit Cache
+	cacheSize, err := getArchiveFileSizeInBytes(archivePath)
+	if err != nil {
+		return err
+	}
+	resourceURL, err := getCacheAPIURL(fmt.Sprintf("caches/%d", cacheID))
+	if err != nil {
+		return err
+	}
+	statusCode, err := retryTypedResponse(ctx, "commitCache", func() (*httpclient.TypedResponse, error) {
+		return client.PostJSON(ctx, resourceURL, commitCacheRequest{Size: cacheSize}, nil, nil)
+	})
+	if err != nil && statusCode == 0 {
+		return err
+	}
+	if !isSuccessStatusCode(statusCode) {
+		return fmt.Errorf("Cache service responded with %d during commit cache.", statusCode)
+	}
+	return nil
+}
+
+func uploadFile(ctx context.Context, client *httpclient.Client, cacheID int64, archivePath string, options UploadOptions) error {
+	fileSize, err := getArchiveFileSizeInBytes(archivePath)
+	if err != nil {
+		return err
+	}
+	resourceURL, err := getCacheAPIURL(fmt.Sprintf("caches/%d", cacheID))
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := *options.UploadConcurrency
+	maxChunkSize := *options.UploadChunkSize
+	var (
+		mu       sync.Mutex
+		offset   int64
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if offset >= fileSize || firstErr != nil {
+					mu.Unlock()
+					return
+				}
+				chunkSize := fileSize - offset
+				if chunkSize > maxChunkSize {
+					chunkSize = maxChunkSize
+				}
+				start := offset
+				end := offset + chunkSize - 1
+				offset += maxChunkSize
+				mu.Unlock()
+
+				if err := uploadChunk(ctx, client, resourceURL, f, start, end); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func uploadChunk(ctx context.Context, client *httpclient.Client, resourceURL string, f *os.File, start, end int64) error {
+	headers := http.Header{
+		"Content-Type":  {"application/octet-stream"},
+		"Content-Range": {fmt.Sprintf("bytes %d-%d/*", start, end)},
+	}
+	resp, err := retryHTTPClientResponse(ctx, fmt.Sprintf("uploadChunk (start: %d, end: %d)", start, end), func() (*http.Response, error) {
+		return client.SendStream(ctx, http.MethodPatch, resourceURL, io.NewSectionReader(f, start, end-start+1), headers)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if !isSuccessStatusCode(resp.StatusCode) {
+		return fmt.Errorf("Cache service responded with %d during upload chunk.", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/cache/cachetest"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func setupServer(t *testing.T) (server *cachetest.Server, workspace string) {
+	server = cachetest.NewServer()
+	t.Cleanup(server.Close)
+
+	workspace = filepath.Join(t.TempDir(), "workspace")
+	if err := os.MkdirAll(workspace, 0o755); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	t.Setenv("ACTIONS_CACHE_URL", server.CacheURL())
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", server.Token)
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	delay, blockSize := retryDelay, downloadBlockSize
+	retryDelay, downloadBlockSize = 0, 1000
+	t.Cleanup(func() {
+		retryDelay, downloadBlockSize = delay, blockSize
+	})
+	return server, workspace
+}
+
+func writeFiles(t *testing.T, root string, files map[string][]byte) {
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if err := os.WriteFile(p, content, 0o644); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+	}
+}
+
+func Test_Validation(t *testing.T) {
+	setupServer(t)
+	ctx := context.Background()
+
+	table := []struct {
+		paths       []string
+		primaryKey  string
+		restoreKeys []string
+		message     string
+	}{
+		{paths: nil, primaryKey: "key", message: "Path Validation Error: At least one directory or file path is required"},
+		{paths: []string{"node_modules"}, primaryKey: strings.Repeat("a", 513), message: "Key Validation Error: " + strings.Repeat("a", 513) + " cannot be larger than 512 characters."},
+		{paths: []string{"node_modules"}, primaryKey: "comma,comma", message: "Key Validation Error: comma,comma cannot contain commas."},
+		{paths: []string{"node_modules"}, primaryKey: "key", restoreKeys: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}, message: "Key Validation Error: Keys are limited to a maximum of 10."},
+		{paths: []string{"node_modules"}, primaryKey: "key", restoreKeys: []string{"comma,comma"}, message: "Key Validation Error: comma,comma cannot contain commas."},
+	}
+	for _, tt := range table {
+		_, err := RestoreCache(ctx, tt.paths, tt.primaryKey, tt.restoreKeys, nil)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || err.Error() != tt.message {
+			t.Fatalf("expected error is %q, but result was %v.\ntest case: %v %v", tt.message, err, tt.primaryKey, tt.restoreKeys)
+		}
+		if len(tt.restoreKeys) > 0 {
+			continue
+		}
+		_, err = SaveCache(ctx, tt.paths, tt.primaryKey, nil)
+		if !errors.As(err, &validationErr) || err.Error() != tt.message {
+			t.Fatalf("expected error is %q, but result was %v.\ntest case: %v", tt.message, err, tt.primaryKey)
+		}
+	}
+
+	if _, err := SaveCache(ctx, []string{"missing"}, "key", nil); err == nil || !strings.HasPrefix(err.Error(), "Path Validation Error: Path(s) specified") {
+		t.Fatalf("expected a path validation error, but result was %v.", err)
+	}
+}
+
+func Test_GetCacheVersion(t *testing.T) {
+	table := []struct {
+		paths                []string
+		compressionMethod    CompressionMethod
+		enableCrossOsArchive bool
+		expected             string
+		windows              string
+	}{
+		{
+			paths:             []string{"node_modules"},
+			compressionMethod: Gzip,
+			expected:          "470e252814dbffc9524891b17cf4e5749b26c1b5026e63dd3f00972db2393117",
+		},
+		{
+			paths:             []string{"node_modules"},
+			compressionMethod: Zstd,
+			expected:          "273877e14fd65d270b87a198edbfa2db5a43de567c9a548d2a2505b408befe24",
+		},
+		{
+			paths:             []string{"node_modules"},
+			compressionMethod: ZstdWithoutLong,
+			expected:          "7fcda33c1e1d849a13bcc06f49b9ab64efc01ca9dabe4d7a8d0d387feef4fc88",
+			windows:           "809ada9ac328ef09e7a0035d561946e904cc9cb3e1af153caf2fb96ccee78675",
+		},
+		{
+			paths:                []string{"node_modules"},
+			compressionMethod:    ZstdWithoutLong,
+			enableCrossOsArchive: true,
+			expected:             "7fcda33c1e1d849a13bcc06f49b9ab64efc01ca9dabe4d7a8d0d387feef4fc88",
+			windows:              "7fcda33c1e1d849a13bcc06f49b9ab64efc01ca9dabe4d7a8d0d387feef4fc88",
+		},
+		{
+			paths:             []string{"node_modules", "~/.npm"},
+			compressionMethod: ZstdWithoutLong,
+			expected:          "1cbc60d75cb9cbdfdc7cee051451c7a3706c8311eed5c886ca3ea62cbc8efee6",
+		},
+	}
+	for _, tt := range table {
+		expected := tt.expected
+		if runtime.GOOS == "windows" {
+			if tt.windows == "" {
+				continue
+			}
+			expected = tt.windows
+		}
+		if val := GetCacheVersion(tt.paths, tt.compressionMethod, tt.enableCrossOsArchive); val != expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", expected, val, tt)
+		}
+	}
+}
+
+func Test_SaveAndRestore(t *testing.T) {
+	server, workspace := setupServer(t)
+	ctx := context.Background()
+
+	big := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(big)
+	files := map[string][]byte{
+		"deps/a.txt":        []byte("a"),
+		"deps/nested/b.txt": []byte("b"),
+		"build/out.bin":     big,
+		"build/skip.txt":    []byte("not cached"),
+	}
+	writeFiles(t, workspace, files)
+
+	// Relative patterns are resolved from the current directory, which is the workspace on runners
+	paths := []string{filepath.Join(workspace, "deps"), filepath.Join(workspace, "build", "*.bin")}
+	// Small chunks exercise the parallel upload, a failed chunk the retries
+	server.FailNext(http.MethodPatch, "caches/", 1)
+	cacheID, err := SaveCache(ctx, paths, "npm-linux-1", &UploadOptions{UploadChunkSize: ptr.Int64(4096), UploadConcurrency: ptr.Int(3)})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	entries := server.Entries()
+	if len(entries) != 1 || entries[0].ID != cacheID || !entries[0].Committed || entries[0].Key != "npm-linux-1" {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+	if expected := GetCacheVersion(paths, GetCompressionMethod(ctx), false); entries[0].Version != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, entries[0].Version)
+	}
+
+	// The same key cannot be saved twice
+	_, err = SaveCache(ctx, paths, "npm-linux-1", nil)
+	var reserveErr *ReserveCacheError
+	if !errors.As(err, &reserveErr) {
+		t.Fatalf("expected a reserve cache error, but result was %v.", err)
+	}
+
+	if err := os.RemoveAll(workspace); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	// Different paths lead to a different version
+	key, err := RestoreCache(ctx, paths[:1], "npm-linux-1", nil, nil)
+	if err != nil || key != "" {
+		t.Fatalf("expected a cache miss, but result was %q %v.", key, err)
+	}
+
+	key, err = RestoreCache(ctx, paths, "npm-linux-2", []string{"npm-linux-"}, &DownloadOptions{LookupOnly: ptr.Bool(true)})
+	if err != nil || key != "npm-linux-1" {
+		t.Fatalf("expected value is %q, but result was %q %v.", "npm-linux-1", key, err)
+	}
+	if _, err := os.Stat(workspace); !os.IsNotExist(err) {
+		t.Fatal("expected the lookup to skip the download")
+	}
+
+	server.FailNext(http.MethodGet, "cache", 1)
+	key, err = RestoreCache(ctx, paths, "npm-linux-2", []string{"npm-linux-"}, &DownloadOptions{DownloadConcurrency: ptr.Int(3)})
+	if err != nil || key != "npm-linux-1" {
+		t.Fatalf("expected value is %q, but result was %q %v.", "npm-linux-1", key, err)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(workspace, filepath.FromSlash(name)))
+		if name == "build/skip.txt" {
+			if !os.IsNotExist(err) {
+				t.Fatalf("expected %s not to be restored", name)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, content) {
+			t.Fatalf("unexpected content of %s: %v", name, err)
+		}
+	}
+}
+
+func Test_RestorePrefersNewestEntry(t *testing.T) {
+	server, _ := setupServer(t)
+	ctx := context.Background()
+
+	paths := []string{"deps"}
+	version := GetCacheVersion(paths, GetCompressionMethod(ctx), false)
+	now := time.Now()
+	server.AddEntry("deps-old", version, nil, now.Add(-2*time.Hour))
+	server.AddEntry("deps-new", version, nil, now.Add(-time.Hour))
+	server.AddEntry("deps-exact", version, nil, now.Add(-3*time.Hour))
+	server.AddEntry("other", version, nil, now)
+
+	table := []struct {
+		primaryKey  string
+		restoreKeys []string
+		expected    string
+	}{
+		{primaryKey: "deps-exact", restoreKeys: []string{"deps-"}, expected: "deps-exact"},
+		{primaryKey: "deps-missing", restoreKeys: []string{"deps-"}, expected: "deps-new"},
+		{primaryKey: "deps-missing", restoreKeys: []string{"deps-old", "deps-"}, expected: "deps-old"},
+		{primaryKey: "deps-missing", restoreKeys: []string{"nope"}, expected: ""},
+	}
+	for _, tt := range table {
+		key, err := RestoreCache(ctx, paths, tt.primaryKey, tt.restoreKeys, &DownloadOptions{LookupOnly: ptr.Bool(true)})
+		if err != nil || key != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q %v.\ntest case: %v", tt.expected, key, err, tt)
+		}
+	}
+}
+
+func Test_ServiceErrors(t *testing.T) {
+	server, workspace := setupServer(t)
+	ctx := context.Background()
+	writeFiles(t, workspace, map[string][]byte{"deps/a.txt": []byte("a")})
+
+	// 401 is not retried
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", "wrong")
+	if _, err := RestoreCache(ctx, []string{"deps"}, "key", nil, nil); err == nil || !strings.Contains(err.Error(), "Cache service responded with 401") {
+		t.Fatalf("expected an authentication error, but result was %v.", err)
+	}
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", server.Token)
+
+	// Server errors are retried once
+	server.FailNext(http.MethodPost, "caches", 2)
+	if _, err := SaveCache(ctx, []string{filepath.Join(workspace, "deps")}, "key", nil); err == nil || err.Error() != "Failed to save: reserveCache failed: Cache service responded with 503" {
+		t.Fatalf("expected a retry error, but result was %v.", err)
+	}
+
+	t.Setenv("ACTIONS_CACHE_URL", "")
+	if IsFeatureAvailable() {
+		t.Fatal("expected the cache service not to be available")
+	}
+	if _, err := RestoreCache(ctx, []string{"deps"}, "key", nil, nil); err == nil || !strings.Contains(err.Error(), "Cache Service Url not found") {
+		t.Fatalf("expected a missing url error, but result was %v.", err)
+	}
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ci-tools/toolkit/glob"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// CompressionMethod is the compression of the cache archive. It is part of the cache version,
+// so entries are only restored with the compression they were saved with.
+type CompressionMethod string
+
+const (
+	// Gzip compresses the archive with gzip. It is used when zstd is not installed.
+	Gzip CompressionMethod = "gzip"
+
+	// ZstdWithoutLong compresses the archive with zstd. It is used when zstd is installed.
+	ZstdWithoutLong CompressionMethod = "zstd-without-long"
+
+	// Zstd compresses the archive with zstd with long distance matching (--long=30),
+	// as earlier versions of actions/cache did.
+	Zstd CompressionMethod = "zstd"
+)
+
+// versionSalt changes the cache version of all entries when the archive format changes.
+const versionSalt = "1.0"
+
+const manifestFilename = "manifest.txt"
+
+// GetCompressionMethod returns the compression method actions/cache uses on this machine:
+// zstd if it is installed, gzip otherwise.
+func GetCompressionMethod(ctx context.Context) CompressionMethod {
+	versionOutput := getVersion(ctx, "zstd", "--quiet")
+	if versionOutput == "" {
+		return Gzip
+	}
+	return ZstdWithoutLong
+}
+
+// getVersion returns the trimmed output of the app called with --version, or an empty string
+// if the app is not installed.
+func getVersion(ctx context.Context, app string, additionalArgs ...string) string {
+	args := append(append([]string(nil), additionalArgs...), "--version")
+	output, _ := exec.CommandContext(ctx, app, args...).CombinedOutput()
+	return strings.TrimSpace(string(output))
+}
+
+// GetCacheFileName returns the file name of an archive compressed with the compression method.
+func GetCacheFileName(compressionMethod CompressionMethod) string {
+	if compressionMethod == Gzip {
+		return "cache.tgz"
+	}
+	return "cache.tzst"
+}
+
+// GetCacheVersion returns the version of a cache entry of the paths, as given to SaveCache and
+// RestoreCache. An entry is only restored when the versions match, so the paths, the compression
+// method and the operating system must be the same unless enableCrossOsArchive is set.
+func GetCacheVersion(paths []string, compressionMethod CompressionMethod, enableCrossOsArchive bool) string {
+	components := append([]string(nil), paths...)
+	// Add compression method to cache version to restore
+	// compressed cache as per compression method
+	if compressionMethod != "" {
+		components = append(components, string(compressionMethod))
+	}
+	// Only check for windows platforms if enableCrossOsArchive is false
+	if runtime.GOOS == "windows" && !enableCrossOsArchive {
+		components = append(components, "windows-only")
+	}
+	// Add salt to cache version to support breaking changes in cache entry
+	components = append(components, versionSalt)
+
+	h := sha256.Sum256([]byte(strings.Join(components, "|")))
+	return hex.EncodeToString(h[:])
+}
+
+// resolvePaths expands the patterns and returns the matches relative to the workspace,
+// with forward slashes.
+func resolvePaths(ctx context.Context, patterns []string) ([]string, error) {
+	workspace, err := getWorkingDirectory()
+	if err != nil {
+		return nil, err
+	}
+	globber, err := glob.Create(strings.Join(patterns, "\n"), &glob.Options{ImplicitDescendants: ptr.Bool(false)})
+	if err != nil {
+		return nil, err
+	}
+	files, err := globber.Glob(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, file := range files {
+		relativeFile, err := filepath.Rel(workspace, file)
+		if err != nil {
+			return nil, err
+		}
+		// Paths are made relative so the tar entries are all relative to the root of the workspace.
+		// The workspace itself is ".".
+		paths = append(paths, filepath.ToSlash(relativeFile))
+	}
+	return paths, nil
+}
+
+// getWorkingDirectory returns GITHUB_WORKSPACE, or the current directory.
+func getWorkingDirectory() (string, error) {
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		return workspace, nil
+	}
+	return os.Getwd()
+}
+
+// createTempDirectory creates a new directory in RUNNER_TEMP.
+func createTempDirectory() (string, error) {
+	tempDirectory := os.Getenv("RUNNER_TEMP")
+	if tempDirectory == "" {
+		var baseLocation string
+		switch runtime.GOOS {
+		case "windows":
+			// On Windows use the USERPROFILE env variable
+			baseLocation = os.Getenv("USERPROFILE")
+			if baseLocation == "" {
+				baseLocation = `C:\`
+			}
+		case "darwin":
+			baseLocation = "/Users"
+		default:
+			baseLocation = "/home"
+		}
+		tempDirectory = filepath.Join(baseLocation, "actions", "temp")
+	}
+
+	dest := filepath.Join(tempDirectory, newUUID())
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func getArchiveFileSizeInBytes(filePath string) (int64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// isGhes reports whether the workflow runs on GitHub Enterprise Server.
+func isGhes() bool {
+	serverURL := os.Getenv("GITHUB_SERVER_URL")
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return false
+	}
+	return strings.ToUpper(u.Hostname()) != "GITHUB.COM"
+}
+
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,337 @@
+// Package cachetest provides an in-memory fake of the Actions cache service for testing
+// code using the cache package without network access.
+//
+//	server := cachetest.NewServer()
+//	defer server.Close()
+//	os.Setenv("ACTIONS_CACHE_URL", server.CacheURL())
+//	os.Setenv("ACTIONS_RUNTIME_TOKEN", server.Token)
+package cachetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const apiPrefix = "/_apis/artifactcache/"
+
+var contentRangeRegexp = regexp.MustCompile(`^bytes (\d+)-(\d+)/\*$`)
+
+// Entry is a cache entry stored by the server.
+type Entry struct {
+	ID           int64
+	Key          string
+	Version      string
+	Scope        string
+	CreationTime time.Time
+
+	// Size is the size reserved by the client, which may be zero
+	Size int64
+
+	// Committed reports whether the upload is complete. Only committed entries are restored
+	Committed bool
+
+	Data []byte
+}
+
+// Server is a fake cache service. Its methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Token is the bearer token the requests must be authenticated with.
+	// An empty token disables the check.
+	Token string
+
+	// Scope is the scope of the saved entries. Defaults to refs/heads/main
+	Scope string
+
+	mu      sync.Mutex
+	entries []*Entry
+	nextID  int64
+	failing map[string]int
+}
+
+// NewServer starts a fake cache service. The caller must call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		Token:   "token",
+		Scope:   "refs/heads/main",
+		nextID:  1,
+		failing: map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// CacheURL returns the value of ACTIONS_CACHE_URL pointing to the server.
+func (s *Server) CacheURL() string {
+	return s.URL + "/"
+}
+
+// Entries returns a copy of the stored entries, in the order they were reserved.
+func (s *Server) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entry := *e
+		entry.Data = append([]byte(nil), e.Data...)
+		result = append(result, entry)
+	}
+	return result
+}
+
+// AddEntry stores a committed entry, e.g. an archive created by another implementation.
+// The creation time orders entries matched by prefix.
+func (s *Server) AddEntry(key, version string, data []byte, creationTime time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &Entry{
+		ID:           s.nextID,
+		Key:          key,
+		Version:      version,
+		Scope:        s.Scope,
+		CreationTime: creationTime,
+		Size:         int64(len(data)),
+		Committed:    true,
+		Data:         append([]byte(nil), data...),
+	}
+	s.nextID++
+	s.entries = append(s.entries, entry)
+	return entry.ID
+}
+
+// FailNext makes the next count requests with the method and the path prefix, relative to
+// /_apis/artifactcache/, respond with 503 Service Unavailable.
+func (s *Server) FailNext(method, pathPrefix string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing[method+" "+pathPrefix] = count
+}
+
+func (s *Server) shouldFail(r *http.Request, resource string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for prefix, count := range s.failing {
+		if count > 0 && strings.HasPrefix(r.Method+" "+resource, prefix) {
+			s.failing[prefix] = count - 1
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	resource := strings.TrimPrefix(r.URL.Path, apiPrefix)
+
+	// Archives are downloaded from signed URLs, without authentication
+	if strings.HasPrefix(resource, "archives/") {
+		s.downloadArchive(w, r, strings.TrimPrefix(resource, "archives/"))
+		return
+	}
+
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeMessage(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if s.shouldFail(r, resource) {
+		writeMessage(w, http.StatusServiceUnavailable, "Service Unavailable")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && resource == "cache":
+		s.getCacheEntry(w, r)
+	case r.Method == http.MethodPost && resource == "caches":
+		s.reserveCache(w, r)
+	case r.Method == http.MethodPatch && strings.HasPrefix(resource, "caches/"):
+		s.uploadChunk(w, r, strings.TrimPrefix(resource, "caches/"))
+	case r.Method == http.MethodPost && strings.HasPrefix(resource, "caches/"):
+		s.commitCache(w, r, strings.TrimPrefix(resource, "caches/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// getCacheEntry matches the keys in order, each one exactly first and then by prefix, newest first.
+func (s *Server) getCacheEntry(w http.ResponseWriter, r *http.Request) {
+	keys := strings.Split(r.URL.Query().Get("keys"), ",")
+	version := r.URL.Query().Get("version")
+
+	s.mu.Lock()
+	var candidates []*Entry
+	for _, e := range s.entries {
+		if e.Committed && e.Version == version {
+			candidates = append(candidates, e)
+		}
+	}
+	s.mu.Unlock()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CreationTime.After(candidates[j].CreationTime)
+	})
+
+	var match *Entry
+	for _, key := range keys {
+		for _, e := range candidates {
+			if e.Key == key {
+				match = e
+				break
+			}
+		}
+		if match != nil {
+			break
+		}
+		for _, e := range candidates {
+			if key != "" && strings.HasPrefix(e.Key, key) {
+				match = e
+				break
+			}
+		}
+		if match != nil {
+			break
+		}
+	}
+	if match == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"cacheKey":        match.Key,
+		"scope":           match.Scope,
+		"creationTime":    match.CreationTime.UTC().Format(time.RFC3339),
+		"archiveLocation": fmt.Sprintf("%s%sarchives/%d", s.URL, apiPrefix, match.ID),
+	})
+}
+
+func (s *Server) reserveCache(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Key       string `json:"key"`
+		Version   string `json:"version"`
+		CacheSize int64  `json:"cacheSize"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Key == "" {
+		writeMessage(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.Key == req.Key && e.Version == req.Version {
+			writeMessage(w, http.StatusConflict, fmt.Sprintf("Cache already exists. Scope: %s, Key: %s, Version: %s", e.Scope, e.Key, e.Version))
+			return
+		}
+	}
+	entry := &Entry{
+		ID:           s.nextID,
+		Key:          req.Key,
+		Version:      req.Version,
+		Scope:        s.Scope,
+		CreationTime: time.Now(),
+		Size:         req.CacheSize,
+	}
+	s.nextID++
+	s.entries = append(s.entries, entry)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"cacheId": entry.ID})
+}
+
+func (s *Server) uploadChunk(w http.ResponseWriter, r *http.Request, id string) {
+	m := contentRangeRegexp.FindStringSubmatch(r.Header.Get("Content-Range"))
+	if m == nil {
+		writeMessage(w, http.StatusBadRequest, "Invalid Content-Range")
+		return
+	}
+	start, _ := strconv.ParseInt(m[1], 10, 64)
+	end, _ := strconv.ParseInt(m[2], 10, 64)
+	data, err := io.ReadAll(r.Body)
+	if err != nil || int64(len(data)) != end-start+1 {
+		writeMessage(w, http.StatusBadRequest, "Content-Range does not match the body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.findEntry(id)
+	if entry == nil || entry.Committed {
+		writeMessage(w, http.StatusNotFound, "Cache not found")
+		return
+	}
+	if int64(len(entry.Data)) <= end {
+		entry.Data = append(entry.Data, make([]byte, end+1-int64(len(entry.Data)))...)
+	}
+	copy(entry.Data[start:], data)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) commitCache(w http.ResponseWriter, r *http.Request, id string) {
+	var req struct {
+		Size int64 `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.findEntry(id)
+	if entry == nil || entry.Committed {
+		writeMessage(w, http.StatusNotFound, "Cache not found")
+		return
+	}
+	if int64(len(entry.Data)) != req.Size {
+		writeMessage(w, http.StatusBadRequest, fmt.Sprintf("Size mismatch: uploaded %d bytes, committed %d bytes", len(entry.Data), req.Size))
+		return
+	}
+	entry.Committed = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// downloadArchive serves the archive, supporting HEAD and range requests.
+func (s *Server) downloadArchive(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	entry := s.findEntry(id)
+	var data []byte
+	if entry != nil && entry.Committed {
+		data = entry.Data
+	}
+	s.mu.Unlock()
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (s *Server) findEntry(id string) *Entry {
+	for _, e := range s.entries {
+		if strconv.FormatInt(e.ID, 10) == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeMessage(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"message": message})
+}
@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	fsArchiveName  = "archive"
+	fsMetadataName = "entry.json"
+	fsTempDir      = ".tmp"
+)
+
+// FileSystemBackendOptions controls a FileSystemBackend.
+type FileSystemBackendOptions struct {
+	/** Optional. Maximum total size in bytes of the archives. The least recently used entries are evicted when saving exceeds it. Defaults to no limit */
+	MaxSize *int64
+
+	/** Optional. Scope of the saved entries, e.g. the branch. Only the entries of the scope or of RestoreScopes are restored. Defaults to GITHUB_REF */
+	Scope *string
+
+	/** Optional. Other scopes the entries are restored from when the scope has none, in order, e.g. the default branch. Defaults to the base branch of a pull request, from GITHUB_BASE_REF */
+	RestoreScopes []string
+}
+
+// FileSystemBackend stores the entries in a local or network file system, for self-hosted
+// runners without access to the Actions cache service. It can be shared by several runners.
+//
+// Each entry is a directory <root>/<version>/<hash of the scope and the key> containing the archive and its
+// metadata. Entries are saved into a temporary directory and renamed into place, so readers
+// never see partial entries. Restoring an entry marks it as recently used.
+//
+// Like the branch scoping of the service, the entries are scoped: a key can be saved once per
+// scope, and the entries of other scopes than Scope and RestoreScopes are not restored.
+type FileSystemBackend struct {
+	root          string
+	maxSize       int64
+	scope         string
+	restoreScopes []string
+}
+
+type fsEntryMetadata struct {
+	ID           int64     `json:"id"`
+	Key          string    `json:"key"`
+	Version      string    `json:"version"`
+	Scope        string    `json:"scope,omitempty"`
+	Size         int64     `json:"size"`
+	CreationTime time.Time `json:"creationTime"`
+}
+
+// fsEntry is an entry found on disk.
+type fsEntry struct {
+	dir          string
+	metadata     fsEntryMetadata
+	lastAccessed time.Time
+}
+
+// NewFileSystemBackend creates a backend storing the entries in root. options may be nil.
+func NewFileSystemBackend(root string, options *FileSystemBackendOptions) *FileSystemBackend {
+	b := &FileSystemBackend{root: root, scope: os.Getenv("GITHUB_REF")}
+	if baseRef := os.Getenv("GITHUB_BASE_REF"); baseRef != "" {
+		b.restoreScopes = []string{"refs/heads/" + baseRef}
+	}
+	if options != nil {
+		if options.MaxSize != nil {
+			b.maxSize = *options.MaxSize
+		}
+		if options.Scope != nil {
+			b.scope = *options.Scope
+		}
+		if options.RestoreScopes != nil {
+			b.restoreScopes = options.RestoreScopes
+		}
+	}
+	return b
+}
+
+// GetCacheEntry returns the entry of the first key matching exactly, or else by prefix with the
+// newest entry first, among the entries of the version in the scope, or else in the first
+// restore scope with a match. It returns nil if none matches.
+func (b *FileSystemBackend) GetCacheEntry(ctx context.Context, keys []string, version string) (*ArtifactCacheEntry, error) {
+	entries, err := b.listEntries(filepath.Join(b.root, version))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].metadata.CreationTime.After(entries[j].metadata.CreationTime)
+	})
+
+	for _, scope := range append([]string{b.scope}, b.restoreScopes...) {
+		var scoped []fsEntry
+		for _, entry := range entries {
+			if entry.metadata.Scope == scope {
+				scoped = append(scoped, entry)
+			}
+		}
+		if entry := findEntryOfKeys(scoped, keys); entry != nil {
+			// Mark the entry as recently used for the eviction
+			now := time.Now()
+			if err := os.Chtimes(filepath.Join(entry.dir, fsMetadataName), now, now); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			return &ArtifactCacheEntry{
+				CacheKey:        entry.metadata.Key,
+				Scope:           entry.metadata.Scope,
+				CreationTime:    entry.metadata.CreationTime.UTC().Format(time.RFC3339),
+				ArchiveLocation: filepath.Join(entry.dir, fsArchiveName),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// findEntryOfKeys returns the entry of the first key with one.
+func findEntryOfKeys(entries []fsEntry, keys []string) *fsEntry {
+	for _, key := range keys {
+		if entry := findEntry(entries, key); entry != nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// findEntry returns the entry with the key, or else the first entry prefixed with it.
+func findEntry(entries []fsEntry, key string) *fsEntry {
+	for i := range entries {
+		if entries[i].metadata.Key == key {
+			return &entries[i]
+		}
+	}
+	for i := range entries {
+		if strings.HasPrefix(entries[i].metadata.Key, key) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// DownloadCache copies the archive of the entry to archivePath.
+func (b *FileSystemBackend) DownloadCache(ctx context.Context, entry *ArtifactCacheEntry, archivePath string, options DownloadOptions) error {
+	_, err := copyFile(entry.ArchiveLocation, archivePath)
+	return err
+}
+
+// SaveCache copies the archive into the backend and evicts the least recently used entries
+// if the maximum size is exceeded. It returns a *ReserveCacheError if the entry already exists.
+// The errors of the eviction are ignored, as the entry is saved, and the next save evicts again.
+func (b *FileSystemBackend) SaveCache(ctx context.Context, key string, version string, archivePath string, options UploadOptions) (int64, error) {
+	entryDir := b.entryDir(version, key)
+	if _, err := os.Stat(entryDir); err == nil {
+		return -1, &ReserveCacheError{Message: fmt.Sprintf("Unable to reserve cache with key %s, another job may be creating this cache. More details: Cache already exists.", key)}
+	}
+
+	tempDir := filepath.Join(b.root, fsTempDir, newUUID())
+	if err := os.MkdirAll(tempDir, 0o755); err != nil {
+		return -1, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	size, err := copyFile(archivePath, filepath.Join(tempDir, fsArchiveName))
+	if err != nil {
+		return -1, err
+	}
+	metadata := fsEntryMetadata{
+		ID:           entryID(b.scope, key, version),
+		Key:          key,
+		Version:      version,
+		Scope:        b.scope,
+		Size:         size,
+		CreationTime: time.Now().UTC(),
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return -1, err
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, fsMetadataName), data, 0o644); err != nil {
+		return -1, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entryDir), 0o755); err != nil {
+		return -1, err
+	}
+	// Renaming fails if another job saved the entry in the meantime
+	if err := os.Rename(tempDir, entryDir); err != nil {
+		if _, statErr := os.Stat(entryDir); statErr == nil {
+			return -1, &ReserveCacheError{Message: fmt.Sprintf("Unable to reserve cache with key %s, another job may be creating this cache. More details: %s", key, err)}
+		}
+		return -1, err
+	}
+
+	_ = b.evict(entryDir)
+	return metadata.ID, nil
+}
+
+// evict removes the least recently used entries, except keep, until the total size of the
+// archives does not exceed the maximum size.
+func (b *FileSystemBackend) evict(keep string) error {
+	if b.maxSize <= 0 {
+		return nil
+	}
+
+	var entries []fsEntry
+	versions, err := os.ReadDir(b.root)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if !version.IsDir() || version.Name() == fsTempDir {
+			continue
+		}
+		versionEntries, err := b.listEntries(filepath.Join(b.root, version.Name()))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, versionEntries...)
+	}
+
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.metadata.Size
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].lastAccessed.Before(entries[j].lastAccessed)
+	})
+	for _, entry := range entries {
+		if totalSize <= b.maxSize {
+			break
+		}
+		if entry.dir == keep {
+			continue
+		}
+		if err := b.removeEntry(entry.dir); err != nil {
+			return err
+		}
+		totalSize -= entry.metadata.Size
+	}
+	return nil
+}
+
+// removeEntry moves the entry out of the way before deleting it, so it disappears at once.
+func (b *FileSystemBackend) removeEntry(dir string) error {
+	tempDir := filepath.Join(b.root, fsTempDir, newUUID())
+	if err := os.MkdirAll(filepath.Dir(tempDir), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(dir, tempDir); err != nil {
+		// Already evicted by another job
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.RemoveAll(tempDir)
+}
+
+// listEntries returns the entries in the directory of a version.
+func (b *FileSystemBackend) listEntries(versionDir string) ([]fsEntry, error) {
+	dirs, err := os.ReadDir(versionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []fsEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entryDir := filepath.Join(versionDir, dir.Name())
+		metadataPath := filepath.Join(entryDir, fsMetadataName)
+		data, err := os.ReadFile(metadataPath)
+		if err != nil {
+			// Evicted meanwhile
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		info, err := os.Stat(metadataPath)
+		if err != nil {
+			continue
+		}
+		entry := fsEntry{dir: entryDir, lastAccessed: info.ModTime()}
+		if err := json.Unmarshal(data, &entry.metadata); err != nil {
+			return nil, fmt.Errorf("invalid cache entry %s: %w", entryDir, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// entryDir returns the directory of the entry of the key in the scope of the backend.
+func (b *FileSystemBackend) entryDir(version, key string) string {
+	name := key
+	if b.scope != "" {
+		name = b.scope + "\n" + key
+	}
+	return filepath.Join(b.root, version, hashKey(name))
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// entryID derives a positive id from the scope, the key and the version.
+func entryID(scope, key, version string) int64 {
+	if scope != "" {
+		key = scope + "\n" + key
+	}
+	h := sha256.Sum256([]byte(key + "|" + version))
+	return int64(binary.BigEndian.Uint64(h[:8]) >> 1)
+}
+
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return 0, err
+	}
+	return n, out.Close()
+}
@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func Test_FileSystemBackend(t *testing.T) {
+	workspace := filepath.Join(t.TempDir(), "workspace")
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	t.Setenv("ACTIONS_CACHE_URL", "")
+	ctx := context.Background()
+
+	root := t.TempDir()
+	backend := NewFileSystemBackend(root, &FileSystemBackendOptions{Scope: ptr.String("refs/heads/main")})
+	uploadOptions := &UploadOptions{Backend: backend}
+	downloadOptions := &DownloadOptions{Backend: backend}
+	paths := []string{filepath.Join(workspace, "deps")}
+
+	writeFiles(t, workspace, map[string][]byte{"deps/a.txt": []byte("first")})
+	firstID, err := SaveCache(ctx, paths, "deps-1", uploadOptions)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	writeFiles(t, workspace, map[string][]byte{"deps/a.txt": []byte("second")})
+	secondID, err := SaveCache(ctx, paths, "deps-2", uploadOptions)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if firstID <= 0 || secondID <= 0 || firstID == secondID {
+		t.Fatalf("unexpected ids: %d %d", firstID, secondID)
+	}
+
+	// Entries are stored by version and hashed scope and key
+	version := GetCacheVersion(paths, GetCompressionMethod(ctx), false)
+	for _, key := range []string{"deps-1", "deps-2"} {
+		for _, name := range []string{"archive", "entry.json"} {
+			if _, err := os.Stat(filepath.Join(root, version, hashKey("refs/heads/main\n"+key), name)); err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+		}
+	}
+
+	_, err = SaveCache(ctx, paths, "deps-1", uploadOptions)
+	var reserveErr *ReserveCacheError
+	if !errors.As(err, &reserveErr) {
+		t.Fatalf("expected a reserve cache error, but result was %v.", err)
+	}
+
+	table := []struct {
+		primaryKey  string
+		restoreKeys []string
+		expected    string
+		content     string
+	}{
+		{primaryKey: "deps-1", restoreKeys: []string{"deps-"}, expected: "deps-1", content: "first"},
+		{primaryKey: "deps-3", restoreKeys: []string{"deps-"}, expected: "deps-2", content: "second"},
+		{primaryKey: "deps-3", restoreKeys: []string{"other-", "deps-1"}, expected: "deps-1", content: "first"},
+		{primaryKey: "deps-3", restoreKeys: []string{"other-"}, expected: ""},
+	}
+	for _, tt := range table {
+		if err := os.RemoveAll(workspace); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		key, err := RestoreCache(ctx, paths, tt.primaryKey, tt.restoreKeys, downloadOptions)
+		if err != nil || key != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q %v.\ntest case: %v", tt.expected, key, err, tt)
+		}
+		if tt.expected == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(workspace, "deps", "a.txt"))
+		if err != nil || string(data) != tt.content {
+			t.Fatalf("expected value is %q, but result was %q %v.\ntest case: %v", tt.content, data, err, tt)
+		}
+	}
+
+	entry, err := backend.GetCacheEntry(ctx, []string{"deps-1"}, version)
+	if err != nil || entry == nil || entry.Scope != "refs/heads/main" || entry.ArchiveLocation != filepath.Join(backend.entryDir(version, "deps-1"), "archive") {
+		t.Fatalf("unexpected entry: %v %v", entry, err)
+	}
+}
+
+func Test_FileSystemBackendScopes(t *testing.T) {
+	t.Setenv("GITHUB_BASE_REF", "main")
+	ctx := context.Background()
+	root := t.TempDir()
+	archivePath := filepath.Join(t.TempDir(), "cache.tzst")
+	if err := os.WriteFile(archivePath, []byte("archive"), 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	base := NewFileSystemBackend(root, &FileSystemBackendOptions{Scope: ptr.String("refs/heads/main")})
+	if _, err := base.SaveCache(ctx, "deps-1", "v1", archivePath, UploadOptions{}); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	table := []struct {
+		name          string
+		scope         string
+		restoreScopes []string
+		expected      string
+	}{
+		{name: "same scope", scope: "refs/heads/main", expected: "refs/heads/main"},
+		// The base branch of pull requests by default
+		{name: "restore scope", scope: "refs/pull/1/merge", expected: "refs/heads/main"},
+		{name: "other scope", scope: "refs/heads/feature", restoreScopes: []string{}},
+		{name: "other restore scope", scope: "refs/heads/feature", restoreScopes: []string{"refs/heads/dev"}},
+	}
+	for _, tt := range table {
+		backend := NewFileSystemBackend(root, &FileSystemBackendOptions{Scope: ptr.String(tt.scope), RestoreScopes: tt.restoreScopes})
+		entry, err := backend.GetCacheEntry(ctx, []string{"deps-"}, "v1")
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		var actual string
+		if entry != nil {
+			actual = entry.Scope
+		}
+		if actual != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+
+	// The key can be saved in another scope, with another ID, and its entries are restored first
+	feature := NewFileSystemBackend(root, &FileSystemBackendOptions{Scope: ptr.String("refs/heads/feature"), RestoreScopes: []string{"refs/heads/main"}})
+	id, err := feature.SaveCache(ctx, "deps-1", "v1", archivePath, UploadOptions{})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if baseID := entryID("refs/heads/main", "deps-1", "v1"); id == baseID {
+		t.Fatalf("expected an ID other than %v.", baseID)
+	}
+	if entry, err := feature.GetCacheEntry(ctx, []string{"deps-1"}, "v1"); err != nil || entry == nil || entry.Scope != "refs/heads/feature" {
+		t.Fatalf("unexpected entry: %v %v", entry, err)
+	}
+}
+
+func Test_FileSystemBackendEviction(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	backend := NewFileSystemBackend(root, &FileSystemBackendOptions{MaxSize: ptr.Int64(350)})
+
+	archivePath := filepath.Join(t.TempDir(), "cache.tzst")
+	if err := os.WriteFile(archivePath, make([]byte, 100), 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	// Entries are last used in order a, c, b
+	lastUsed := time.Now().Add(-time.Hour)
+	for _, key := range []string{"a", "c", "b"} {
+		if _, err := backend.SaveCache(ctx, key, "v1", archivePath, UploadOptions{}); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		lastUsed = lastUsed.Add(time.Minute)
+		if err := os.Chtimes(filepath.Join(backend.entryDir("v1", key), "entry.json"), lastUsed, lastUsed); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+	}
+
+	// Restoring a marks it as recently used
+	if entry, err := backend.GetCacheEntry(ctx, []string{"a"}, "v1"); err != nil || entry == nil {
+		t.Fatalf("unexpected entry: %v %v", entry, err)
+	}
+	if _, err := backend.SaveCache(ctx, "d", "v2", archivePath, UploadOptions{}); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	var keys []string
+	for _, version := range []string{"v1", "v2"} {
+		entries, err := backend.listEntries(filepath.Join(root, version))
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		for _, entry := range entries {
+			keys = append(keys, entry.metadata.Key)
+		}
+	}
+	expected := map[string]bool{"a": true, "b": true, "d": true}
+	actual := map[string]bool{}
+	for _, key := range keys {
+		actual[key] = true
+	}
+	if !reflect.DeepEqual(actual, expected) || len(keys) != 3 {
+		t.Fatalf("expected value is %v, but result was %v.", expected, keys)
+	}
+
+	// Evicted entries do not leave temporary directories behind
+	if entries, err := os.ReadDir(filepath.Join(root, ".tmp")); err != nil || len(entries) != 0 {
+		t.Fatalf("unexpected temporary entries: %v %v", entries, err)
+	}
+
+	// The entry is saved even if the eviction fails
+	invalid := filepath.Join(root, "v3", "invalid")
+	if err := os.MkdirAll(invalid, 0o755); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if err := os.WriteFile(filepath.Join(invalid, "entry.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	id, err := backend.SaveCache(ctx, "e", "v1", archivePath, UploadOptions{})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := entryID(backend.scope, "e", "v1"); id != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, id)
+	}
+}
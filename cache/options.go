@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// UploadOptions controls SaveCache.
+type UploadOptions struct {
+	/** Optional. Number of parallel cache upload. Defaults to 4 */
+	UploadConcurrency *int
+
+	/** Optional. Maximum chunk size in bytes for cache upload. Defaults to 32MB */
+	UploadChunkSize *int64
+
+	/** Optional. Whether the cache can be restored on other operating systems. Defaults to false */
+	EnableCrossOsArchive *bool
+
+	/** Optional. The backend storing the entries. Defaults to the Actions cache service */
+	Backend Backend
+}
+
+// DownloadOptions controls RestoreCache.
+type DownloadOptions struct {
+	/** Optional. Number of parallel downloads. Defaults to 8 */
+	DownloadConcurrency *int
+
+	/** Optional. Maximum time for each download request. Defaults to 30 seconds */
+	Timeout *time.Duration
+
+	/** Optional. Weather to skip downloading the cache entry. If lookupOnly is set to true, the restore function will only check if a matching cache entry exists and return the cache key if it does. Defaults to false */
+	LookupOnly *bool
+
+	/** Optional. Whether the cache can be restored on other operating systems. Defaults to false */
+	EnableCrossOsArchive *bool
+
+	/** Optional. The backend storing the entries. Defaults to the Actions cache service */
+	Backend Backend
+}
+
+// getUploadOptions returns a copy of the upload options with defaults applied.
+func getUploadOptions(options *UploadOptions) UploadOptions {
+	result := UploadOptions{
+		UploadConcurrency:    ptr.Int(4),
+		UploadChunkSize:      ptr.Int64(32 * 1024 * 1024),
+		EnableCrossOsArchive: ptr.Bool(false),
+		Backend:              NewServiceBackend(),
+	}
+	if options == nil {
+		return result
+	}
+	if options.UploadConcurrency != nil && *options.UploadConcurrency > 0 {
+		result.UploadConcurrency = ptr.Int(*options.UploadConcurrency)
+	}
+	if options.UploadChunkSize != nil && *options.UploadChunkSize > 0 {
+		result.UploadChunkSize = ptr.Int64(*options.UploadChunkSize)
+	}
+	if options.EnableCrossOsArchive != nil {
+		result.EnableCrossOsArchive = ptr.Bool(*options.EnableCrossOsArchive)
+	}
+	if options.Backend != nil {
+		result.Backend = options.Backend
+	}
+	return result
+}
+
+// getDownloadOptions returns a copy of the download options with defaults applied.
+func getDownloadOptions(options *DownloadOptions) DownloadOptions {
+	timeout := 30 * time.Second
+	result := DownloadOptions{
+		DownloadConcurrency:  ptr.Int(8),
+		Timeout:              &timeout,
+		LookupOnly:           ptr.Bool(false),
+		EnableCrossOsArchive: ptr.Bool(false),
+		Backend:              NewServiceBackend(),
+	}
+	if options == nil {
+		return result
+	}
+	if options.DownloadConcurrency != nil && *options.DownloadConcurrency > 0 {
+		result.DownloadConcurrency = ptr.Int(*options.DownloadConcurrency)
+	}
+	if options.Timeout != nil {
+		timeout = *options.Timeout
+	}
+	if options.LookupOnly != nil {
+		result.LookupOnly = ptr.Bool(*options.LookupOnly)
+	}
+	if options.EnableCrossOsArchive != nil {
+		result.EnableCrossOsArchive = ptr.Bool(*options.EnableCrossOsArchive)
+	}
+	if options.Backend != nil {
+		result.Backend = options.Backend
+	}
+	return result
+}
@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// tarFilename is the uncompressed archive used when BSD tar cannot run zstd itself.
+const tarFilename = "cache.tar"
+
+// archiveTool is the tar command used to create and extract archives.
+type archiveTool struct {
+	path string
+	gnu  bool
+}
+
+// getTarPath returns the tar command, preferring GNU tar.
+func getTarPath() (archiveTool, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if gnuTar, err := exec.LookPath(filepath.Join(os.Getenv("ProgramFiles"), "Git", "usr", "bin", "tar.exe")); err == nil {
+			return archiveTool{path: gnuTar, gnu: true}, nil
+		}
+		if systemTar, err := exec.LookPath(filepath.Join(os.Getenv("SYSTEMDRIVE")+`\`, "Windows", "System32", "tar.exe")); err == nil {
+			return archiveTool{path: systemTar}, nil
+		}
+	case "darwin":
+		if gnuTar, err := exec.LookPath("gtar"); err == nil {
+			return archiveTool{path: gnuTar, gnu: true}, nil
+		}
+		if bsdTar, err := exec.LookPath("tar"); err == nil {
+			return archiveTool{path: bsdTar}, nil
+		}
+	}
+	tar, err := exec.LookPath("tar")
+	if err != nil {
+		return archiveTool{}, err
+	}
+	return archiveTool{path: tar, gnu: true}, nil
+}
+
+// isBSDTarZstd reports whether zstd must run separately from tar, because BSD tar on Windows
+// cannot use it as the compression program.
+func isBSDTarZstd(tarPath archiveTool, compressionMethod CompressionMethod) bool {
+	return !tarPath.gnu && compressionMethod != Gzip && runtime.GOOS == "windows"
+}
+
+// getTarArgs returns the arguments for creating, extracting or listing an archive.
+func getTarArgs(tarPath archiveTool, compressionMethod CompressionMethod, kind string, archivePath string) ([]string, error) {
+	args := []string{tarPath.path}
+	cacheFileName := GetCacheFileName(compressionMethod)
+	workingDirectory, err := getWorkingDirectory()
+	if err != nil {
+		return nil, err
+	}
+	bsdTarZstd := isBSDTarZstd(tarPath, compressionMethod)
+
+	// Method specific args
+	switch kind {
+	case "create":
+		archive := strings.ReplaceAll(cacheFileName, `\`, "/")
+		if bsdTarZstd {
+			archive = tarFilename
+		}
+		args = append(args,
+			"--posix",
+			"-cf", archive,
+			"--exclude", archive,
+			"-P",
+			"-C", strings.ReplaceAll(workingDirectory, `\`, "/"),
+			"--files-from", manifestFilename,
+		)
+	case "extract":
+		archive := strings.ReplaceAll(archivePath, `\`, "/")
+		if bsdTarZstd {
+			archive = tarFilename
+		}
+		args = append(args,
+			"-xf", archive,
+			"-P",
+			"-C", strings.ReplaceAll(workingDirectory, `\`, "/"),
+		)
+	case "list":
+		archive := strings.ReplaceAll(archivePath, `\`, "/")
+		if bsdTarZstd {
+			archive = tarFilename
+		}
+		args = append(args,
+			"-tf", archive,
+			"-P",
+		)
+	}
+
+	// Platform specific args
+	if tarPath.gnu {
+		switch runtime.GOOS {
+		case "windows":
+			args = append(args, "--force-local")
+		case "darwin":
+			args = append(args, "--delay-directory-restore")
+		}
+	}
+	return args, nil
+}
+
+// getCompressionProgram returns the arguments to compress the archive. With BSD tar on Windows
+// it is a separate zstd command compressing cache.tar.
+func getCompressionProgram(tarPath archiveTool, compressionMethod CompressionMethod) []string {
+	cacheFileName := GetCacheFileName(compressionMethod)
+	bsdTarZstd := isBSDTarZstd(tarPath, compressionMethod)
+
+	// -T#: Compress using # working thread. If # is 0, attempt to detect and use the number of physical CPU cores.
+	// zstdmt is equivalent to 'zstd -T0'
+	// --long=#: Enables long distance matching with # bits. Maximum is 30 (1GB) on 32-bit OS and 31 (2GB) on 64-bit.
+	// Using 30 here because we also support 32-bit self-hosted runners.
+	switch compressionMethod {
+	case Zstd:
+		if bsdTarZstd {
+			return []string{"zstd", "-T0", "--long=30", "--force", "-o", cacheFileName, tarFilename}
+		}
+		if runtime.GOOS == "windows" {
+			return []string{"--use-compress-program", "zstd -T0 --long=30"}
+		}
+		return []string{"--use-compress-program", "zstdmt --long=30"}
+	case ZstdWithoutLong:
+		if bsdTarZstd {
+			return []string{"zstd", "-T0", "--force", "-o", cacheFileName, tarFilename}
+		}
+		if runtime.GOOS == "windows" {
+			return []string{"--use-compress-program", "zstd -T0"}
+		}
+		return []string{"--use-compress-program", "zstdmt"}
+	default:
+		return []string{"-z"}
+	}
+}
+
+// getDecompressionProgram returns the arguments to decompress the archive. With BSD tar on Windows
+// it is a separate zstd command decompressing into cache.tar.
+func getDecompressionProgram(tarPath archiveTool, compressionMethod CompressionMethod, archivePath string) []string {
+	bsdTarZstd := isBSDTarZstd(tarPath, compressionMethod)
+
+	// -d: Decompress.
+	// unzstd is equivalent to 'zstd -d'
+	switch compressionMethod {
+	case Zstd:
+		if bsdTarZstd {
+			return []string{"zstd", "-d", "--long=30", "--force", "-o", tarFilename, strings.ReplaceAll(archivePath, `\`, "/")}
+		}
+		if runtime.GOOS == "windows" {
+			return []string{"--use-compress-program", "zstd -d --long=30"}
+		}
+		return []string{"--use-compress-program", "unzstd --long=30"}
+	case ZstdWithoutLong:
+		if bsdTarZstd {
+			return []string{"zstd", "-d", "--force", "-o", tarFilename, strings.ReplaceAll(archivePath, `\`, "/")}
+		}
+		if runtime.GOOS == "windows" {
+			return []string{"--use-compress-program", "zstd -d"}
+		}
+		return []string{"--use-compress-program", "unzstd"}
+	default:
+		return []string{"-z"}
+	}
+}
+
+// getCommands returns the commands to run in order, each one the program followed by its arguments.
+func getCommands(compressionMethod CompressionMethod, kind string, archivePath string) ([][]string, error) {
+	tarPath, err := getTarPath()
+	if err != nil {
+		return nil, err
+	}
+	tarArgs, err := getTarArgs(tarPath, compressionMethod, kind, archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var compressionArgs []string
+	if kind == "create" {
+		compressionArgs = getCompressionProgram(tarPath, compressionMethod)
+	} else {
+		compressionArgs = getDecompressionProgram(tarPath, compressionMethod, archivePath)
+	}
+
+	if isBSDTarZstd(tarPath, compressionMethod) {
+		if kind == "create" {
+			return [][]string{tarArgs, compressionArgs}, nil
+		}
+		return [][]string{compressionArgs, tarArgs}, nil
+	}
+	return [][]string{append(tarArgs, compressionArgs...)}, nil
+}
+
+// ListTar returns the output of listing the archive, one entry per line.
+func ListTar(ctx context.Context, archivePath string, compressionMethod CompressionMethod) (string, error) {
+	commands, err := getCommands(compressionMethod, "list", archivePath)
+	if err != nil {
+		return "", err
+	}
+	// With BSD tar, cache.tar is written next to the archive
+	return execCommands(ctx, commands, filepath.Dir(archivePath))
+}
+
+// ExtractTar extracts the archive into GITHUB_WORKSPACE, or the current directory.
+func ExtractTar(ctx context.Context, archivePath string, compressionMethod CompressionMethod) error {
+	// Create directory to extract tar into
+	workingDirectory, err := getWorkingDirectory()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workingDirectory, 0o755); err != nil {
+		return err
+	}
+	commands, err := getCommands(compressionMethod, "extract", archivePath)
+	if err != nil {
+		return err
+	}
+	_, err = execCommands(ctx, commands, filepath.Dir(archivePath))
+	return err
+}
+
+// CreateTar archives the source directories, relative to GITHUB_WORKSPACE or the current
+// directory, into archiveFolder/GetCacheFileName(compressionMethod).
+//
+// The archive is created like actions/cache does, so it can be restored by actions/cache
+// and vice versa: a POSIX tar with the paths written as given, compressed with gzip or zstd.
+func CreateTar(ctx context.Context, archiveFolder string, sourceDirectories []string, compressionMethod CompressionMethod) error {
+	// Write source directories to manifest.txt to avoid command length limits
+	manifest := strings.Join(sourceDirectories, "\n")
+	if err := os.WriteFile(filepath.Join(archiveFolder, manifestFilename), []byte(manifest), 0o644); err != nil {
+		return err
+	}
+	commands, err := getCommands(compressionMethod, "create", "")
+	if err != nil {
+		return err
+	}
+	_, err = execCommands(ctx, commands, archiveFolder)
+	return err
+}
+
+func execCommands(ctx context.Context, commands [][]string, cwd string) (string, error) {
+	var stdout bytes.Buffer
+	for _, command := range commands {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Dir = cwd
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s failed with error: %w\n%s", command[0], err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return stdout.String(), nil
+}
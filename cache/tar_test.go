@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The golden archives in testdata/golden were created by running the commands of actions/cache
+// on a workspace containing goldenFiles, with the paths `deps` and `dist/app.txt`.
+var goldenFiles = map[string]string{
+	"deps/lib/index.js":     "module.exports = 42\n",
+	"deps/lib/package.json": `{"name":"lib","version":"1.0.0"}` + "\n",
+	"dist/app.txt":          "hello\n",
+}
+
+func readGoldenListing(t *testing.T) []string {
+	data, err := os.ReadFile(filepath.Join("testdata", "golden", "listing.txt"))
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return sortedLines(string(data))
+}
+
+func sortedLines(s string) []string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n")), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func requireZstd(t *testing.T, compressionMethod CompressionMethod) {
+	if compressionMethod == Gzip {
+		return
+	}
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd is not installed")
+	}
+}
+
+func Test_GetCacheFileName(t *testing.T) {
+	table := []struct {
+		compressionMethod CompressionMethod
+		expected          string
+	}{
+		{compressionMethod: Gzip, expected: "cache.tgz"},
+		{compressionMethod: Zstd, expected: "cache.tzst"},
+		{compressionMethod: ZstdWithoutLong, expected: "cache.tzst"},
+	}
+	for _, tt := range table {
+		if val := GetCacheFileName(tt.compressionMethod); val != tt.expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, val, tt)
+		}
+	}
+}
+
+func Test_GetCompressionMethod(t *testing.T) {
+	expected := Gzip
+	if _, err := exec.LookPath("zstd"); err == nil {
+		expected = ZstdWithoutLong
+	}
+	if val := GetCompressionMethod(context.Background()); val != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, val)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if val := GetCompressionMethod(context.Background()); val != Gzip {
+		t.Fatalf("expected value is %v, but result was %v.", Gzip, val)
+	}
+}
+
+func Test_GetCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands differ on windows")
+	}
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	tarPath, err := getTarPath()
+	if err != nil {
+		t.Skip("tar is not installed")
+	}
+
+	platformArgs := []string{}
+	if runtime.GOOS == "darwin" && tarPath.gnu {
+		platformArgs = []string{"--delay-directory-restore"}
+	}
+	create := []string{tarPath.path, "--posix", "-cf", "", "--exclude", "", "-P", "-C", workspace, "--files-from", "manifest.txt"}
+	extract := []string{tarPath.path, "-xf", "/tmp/archive", "-P", "-C", workspace}
+
+	table := []struct {
+		compressionMethod CompressionMethod
+		kind              string
+		expected          []string
+	}{
+		{compressionMethod: Gzip, kind: "create", expected: []string{"cache.tgz", "-z"}},
+		{compressionMethod: Zstd, kind: "create", expected: []string{"cache.tzst", "--use-compress-program", "zstdmt --long=30"}},
+		{compressionMethod: ZstdWithoutLong, kind: "create", expected: []string{"cache.tzst", "--use-compress-program", "zstdmt"}},
+		{compressionMethod: Gzip, kind: "extract", expected: []string{"-z"}},
+		{compressionMethod: Zstd, kind: "extract", expected: []string{"--use-compress-program", "unzstd --long=30"}},
+		{compressionMethod: ZstdWithoutLong, kind: "extract", expected: []string{"--use-compress-program", "unzstd"}},
+	}
+	for _, tt := range table {
+		var expected []string
+		if tt.kind == "create" {
+			expected = append(expected, create...)
+			expected[3], expected[5] = tt.expected[0], tt.expected[0]
+			expected = append(expected, platformArgs...)
+			expected = append(expected, tt.expected[1:]...)
+		} else {
+			expected = append(expected, extract...)
+			expected = append(expected, platformArgs...)
+			expected = append(expected, tt.expected...)
+		}
+
+		commands, err := getCommands(tt.compressionMethod, tt.kind, "/tmp/archive")
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if len(commands) != 1 || !reflect.DeepEqual(commands[0], expected) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", expected, commands, tt)
+		}
+	}
+}
+
+func Test_ExtractGoldenArchives(t *testing.T) {
+	table := []struct {
+		file              string
+		compressionMethod CompressionMethod
+	}{
+		{file: "cache.tgz", compressionMethod: Gzip},
+		{file: "cache-long.tzst", compressionMethod: Zstd},
+		{file: "cache-without-long.tzst", compressionMethod: ZstdWithoutLong},
+	}
+	for _, tt := range table {
+		t.Run(tt.file, func(t *testing.T) {
+			requireZstd(t, tt.compressionMethod)
+			workspace := t.TempDir()
+			t.Setenv("GITHUB_WORKSPACE", workspace)
+
+			archivePath, err := filepath.Abs(filepath.Join("testdata", "golden", tt.file))
+			if err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+			listing, err := ListTar(context.Background(), archivePath, tt.compressionMethod)
+			if err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+			if expected := readGoldenListing(t); !reflect.DeepEqual(sortedLines(listing), expected) {
+				t.Fatalf("expected value is %v, but result was %v.", expected, sortedLines(listing))
+			}
+
+			if err := ExtractTar(context.Background(), archivePath, tt.compressionMethod); err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+			for name, content := range goldenFiles {
+				data, err := os.ReadFile(filepath.Join(workspace, filepath.FromSlash(name)))
+				if err != nil || string(data) != content {
+					t.Fatalf("unexpected content of %s: %q %v", name, data, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_CreateTar(t *testing.T) {
+	for _, compressionMethod := range []CompressionMethod{Gzip, Zstd, ZstdWithoutLong} {
+		t.Run(string(compressionMethod), func(t *testing.T) {
+			requireZstd(t, compressionMethod)
+			workspace := t.TempDir()
+			t.Setenv("GITHUB_WORKSPACE", workspace)
+			files := map[string][]byte{"dist/ignored.txt": []byte("ignored")}
+			for name, content := range goldenFiles {
+				files[name] = []byte(content)
+			}
+			writeFiles(t, workspace, files)
+
+			archiveFolder := t.TempDir()
+			if err := CreateTar(context.Background(), archiveFolder, []string{"deps", "dist/app.txt"}, compressionMethod); err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+			archivePath := filepath.Join(archiveFolder, GetCacheFileName(compressionMethod))
+
+			// The archive has the same layout as the archives of actions/cache
+			listing, err := ListTar(context.Background(), archivePath, compressionMethod)
+			if err != nil {
+				t.Fatal("errors are not expected but found:", err)
+			}
+			if expected := readGoldenListing(t); !reflect.DeepEqual(sortedLines(listing), expected) {
+				t.Fatalf("expected value is %v, but result was %v.", expected, sortedLines(listing))
+			}
+
+			if compressionMethod == Gzip {
+				assertPAXFormat(t, archivePath)
+			}
+		})
+	}
+}
+
+// assertPAXFormat checks that the gzip compressed archive was created with --posix.
+func assertPAXFormat(t *testing.T, archivePath string) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if hdr.Format&tar.FormatPAX == 0 {
+			t.Fatalf("expected %s to be in the PAX format, but was %v", hdr.Name, hdr.Format)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The writer of the workflow commands, overridden by tests.
+var stdout io.Writer = os.Stdout
+
+// commandProperty is a property of a workflow command. The properties are kept in order.
+type commandProperty struct {
+	key   string
+	value string
+}
+
+// issueCommand writes a workflow command, e.g.
+//
+//	::name key=value,key=value::message
+func issueCommand(command string, properties []commandProperty, message string) {
+	fmt.Fprintln(stdout, commandString(command, properties, message))
+}
+
+func commandString(command string, properties []commandProperty, message string) string {
+	var b strings.Builder
+	b.WriteString("::")
+	b.WriteString(command)
+	for i, property := range properties {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(property.key + "=" + escapeProperty(property.value))
+	}
+	b.WriteString("::")
+	b.WriteString(escapeData(message))
+	return b.String()
+}
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeData(s string) string {
+	return dataEscaper.Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return propertyEscaper.Replace(s)
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"testing"
+)
+
+func Test_IssueCommand(t *testing.T) {
+	table := []struct {
+		name       string
+		command    string
+		properties []commandProperty
+		message    string
+		expected   string
+	}{
+		{name: "command only", command: "some-command", expected: "::some-command::\n"},
+		{name: "escapes message", command: "some-command", message: "percent % percent % cr \r cr \r lf \n lf \n", expected: "::some-command::percent %25 percent %25 cr %0D cr %0D lf %0A lf %0A\n"},
+		{name: "escapes message with simple line breaks", command: "some-command", message: "%25 %25 %0D %0D %0A %0A", expected: "::some-command::%2525 %2525 %250D %250D %250A %250A\n"},
+		{
+			name:       "escapes property",
+			command:    "some-command",
+			properties: []commandProperty{{key: "name", value: "percent % percent % cr \r cr \r lf \n lf \n colon : colon : comma , comma ,"}},
+			expected:   "::some-command name=percent %25 percent %25 cr %0D cr %0D lf %0A lf %0A colon %3A colon %3A comma %2C comma %2C::\n",
+		},
+		{name: "with message", command: "some-command", message: "some message", expected: "::some-command::some message\n"},
+		{
+			name:       "with message and properties",
+			command:    "some-command",
+			properties: []commandProperty{{key: "prop1", value: "value 1"}, {key: "prop2", value: "value 2"}},
+			message:    "some message",
+			expected:   "::some-command prop1=value 1,prop2=value 2::some message\n",
+		},
+	}
+	for _, tt := range table {
+		buf := captureStdout(t)
+		issueCommand(tt.command, tt.properties, tt.message)
+		if buf.String() != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, buf.String(), tt.name)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -78,6 +79,12 @@ func GetBooleanInput(name string, options *InputOptions) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	return ParseBoolean(val)
+}
+
+// ParseBoolean parses a boolean value of the YAML 1.2 "core schema" specification, like
+// GetBooleanInput does with the value of an input.
+func ParseBoolean(val string) (bool, error) {
 	switch val {
 	case "true", "True", "TRUE":
 		return true, nil
@@ -89,3 +96,72 @@ func GetBooleanInput(name string, options *InputOptions) (bool, error) {
 }
 
 const getBooleanErrFmt = "Input does not meet YAML 1.2 \"Core Schema\" specification: %s\nSupport boolean input list: \\`true | True | TRUE | false | False | FALSE\\`"
+
+// SetSecret registers a secret which will get masked from logs.
+func SetSecret(secret string) {
+	issueCommand("add-mask", nil, secret)
+}
+
+// AnnotationProperties are the optional properties of an annotation, e.g. its location.
+type AnnotationProperties struct {
+	/** Optional. A title for the annotation. */
+	Title *string
+
+	/** Optional. The path of the file for which the annotation should be created. Absolute paths in GITHUB_WORKSPACE are made relative to it. */
+	File *string
+
+	/** Optional. The start line for the annotation. */
+	StartLine *int
+
+	/** Optional. The end line for the annotation. Defaults to `startLine` when `startLine` is provided. */
+	EndLine *int
+
+	/** Optional. The start column for the annotation. Cannot be sent when `startLine` and `endLine` are different values. */
+	StartColumn *int
+
+	/** Optional. The end column for the annotation. Cannot be sent when `startLine` and `endLine` are different values. Defaults to `startColumn` when `startColumn` is provided. */
+	EndColumn *int
+}
+
+// toCommandProperties maps the annotation properties to the properties of the workflow command.
+func toCommandProperties(properties *AnnotationProperties) []commandProperty {
+	if properties == nil {
+		return nil
+	}
+	var res []commandProperty
+	if properties.Title != nil {
+		res = append(res, commandProperty{key: "title", value: *properties.Title})
+	}
+	if properties.File != nil {
+		res = append(res, commandProperty{key: "file", value: RelativeToWorkspace(*properties.File)})
+	}
+	for _, p := range []struct {
+		key   string
+		value *int
+	}{
+		{key: "line", value: properties.StartLine},
+		{key: "endLine", value: properties.EndLine},
+		{key: "col", value: properties.StartColumn},
+		{key: "endColumn", value: properties.EndColumn},
+	} {
+		if p.value != nil {
+			res = append(res, commandProperty{key: p.key, value: strconv.Itoa(*p.value)})
+		}
+	}
+	return res
+}
+
+// Error adds an error issue. properties may be nil.
+func Error(message string, properties *AnnotationProperties) {
+	issueCommand("error", toCommandProperties(properties), message)
+}
+
+// Warning adds a warning issue. properties may be nil.
+func Warning(message string, properties *AnnotationProperties) {
+	issueCommand("warning", toCommandProperties(properties), message)
+}
+
+// Notice adds a notice issue. properties may be nil.
+func Notice(message string, properties *AnnotationProperties) {
+	issueCommand("notice", toCommandProperties(properties), message)
+}
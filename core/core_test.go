@@ -1,6 +1,8 @@
 package core
 
 import (
+	"bytes"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -144,6 +146,89 @@ func Test_GetBooleanInput(t *testing.T) {
 	}
 }
 
+// captureStdout replaces the writer of the workflow commands with a buffer for the duration of the test.
+func captureStdout(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	original := stdout
+	stdout = &buf
+	t.Cleanup(func() { stdout = original })
+	return &buf
+}
+
+func Test_SetSecret(t *testing.T) {
+	buf := captureStdout(t)
+	SetSecret("secret val")
+	if expected := "::add-mask::secret val\n"; buf.String() != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, buf.String())
+	}
+}
+
+func Test_Annotations(t *testing.T) {
+	properties := &AnnotationProperties{
+		Title:       ptr.String("A title"),
+		File:        ptr.String("root/test.txt"),
+		StartColumn: ptr.Int(1),
+		EndColumn:   ptr.Int(2),
+		StartLine:   ptr.Int(5),
+		EndLine:     ptr.Int(5),
+	}
+	table := []struct {
+		name       string
+		annotate   func(string, *AnnotationProperties)
+		message    string
+		properties *AnnotationProperties
+		expected   string
+	}{
+		{name: "error", annotate: Error, message: "Error message", expected: "::error::Error message\n"},
+		{name: "error escapes the message", annotate: Error, message: "Error message\r\n\n", expected: "::error::Error message%0D%0A%0A\n"},
+		{name: "error with properties", annotate: Error, message: "this is my error message", properties: properties, expected: "::error title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::this is my error message\n"},
+		{name: "warning", annotate: Warning, message: "Warning", expected: "::warning::Warning\n"},
+		{name: "warning escapes the message", annotate: Warning, message: "\r\nwarning\n", expected: "::warning::%0D%0Awarning%0A\n"},
+		{name: "warning with properties", annotate: Warning, message: "this is my error message", properties: properties, expected: "::warning title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::this is my error message\n"},
+		{name: "notice", annotate: Notice, message: "Notice", expected: "::notice::Notice\n"},
+		{name: "notice escapes the message", annotate: Notice, message: "\r\nnotice\n", expected: "::notice::%0D%0Anotice%0A\n"},
+		{name: "notice with properties", annotate: Notice, message: "this is my error message", properties: properties, expected: "::notice title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::this is my error message\n"},
+		{name: "empty properties", annotate: Notice, message: "Notice", properties: &AnnotationProperties{}, expected: "::notice::Notice\n"},
+	}
+	for _, tt := range table {
+		buf := captureStdout(t)
+		tt.annotate(tt.message, tt.properties)
+		if buf.String() != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, buf.String(), tt.name)
+		}
+	}
+}
+
+func Test_ToCommandProperties(t *testing.T) {
+	actual := toCommandProperties(&AnnotationProperties{
+		Title:       ptr.String("A title"),
+		File:        ptr.String("root/test.txt"),
+		StartColumn: ptr.Int(1),
+		EndColumn:   ptr.Int(2),
+		StartLine:   ptr.Int(5),
+		EndLine:     ptr.Int(5),
+	})
+	expected := []commandProperty{
+		{key: "title", value: "A title"},
+		{key: "file", value: "root/test.txt"},
+		{key: "line", value: "5"},
+		{key: "endLine", value: "5"},
+		{key: "col", value: "1"},
+		{key: "endColumn", value: "2"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, actual)
+	}
+
+	// Absolute paths in the workspace are made relative to it
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	actual = toCommandProperties(&AnnotationProperties{File: ptr.String(filepath.Join(workspace, "root", "test.txt"))})
+	if expected := []commandProperty{{key: "file", value: "root/test.txt"}}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, actual)
+	}
+}
+
 /* TODO: delete cases below when implemented
 // MIT License
 // Copyright 2019 GitHub
@@ -214,11 +299,6 @@ describe('@actions/core', () => {
     )
   })
 
-  it('setSecret produces the correct command', () => {
-    core.setSecret('secret val')
-    assertWriteCalls([`::add-mask::secret val${os.EOL}`])
-  })
-
   it('prependPath produces the correct commands and sets the env', () => {
     const command = 'PATH'
     createFileCommandFile(command)
@@ -281,119 +361,24 @@ describe('@actions/core', () => {
     assertWriteCalls([`::error::Error: ${message}${os.EOL}`])
   })
 
-  it('error sets the correct error message', () => {
-    core.error('Error message')
-    assertWriteCalls([`::error::Error message${os.EOL}`])
-  })
-
-  it('error escapes the error message', () => {
-    core.error('Error message\r\n\n')
-    assertWriteCalls([`::error::Error message%0D%0A%0A${os.EOL}`])
-  })
-
   it('error handles an error object', () => {
     const message = 'this is my error message'
     core.error(new Error(message))
     assertWriteCalls([`::error::Error: ${message}${os.EOL}`])
   })
 
-  it('error handles parameters correctly', () => {
-    const message = 'this is my error message'
-    core.error(new Error(message), {
-      title: 'A title',
-      file: 'root/test.txt',
-      startColumn: 1,
-      endColumn: 2,
-      startLine: 5,
-      endLine: 5
-    })
-    assertWriteCalls([
-      `::error title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::Error: ${message}${os.EOL}`
-    ])
-  })
-
-  it('warning sets the correct message', () => {
-    core.warning('Warning')
-    assertWriteCalls([`::warning::Warning${os.EOL}`])
-  })
-
-  it('warning escapes the message', () => {
-    core.warning('\r\nwarning\n')
-    assertWriteCalls([`::warning::%0D%0Awarning%0A${os.EOL}`])
-  })
-
   it('warning handles an error object', () => {
     const message = 'this is my error message'
     core.warning(new Error(message))
     assertWriteCalls([`::warning::Error: ${message}${os.EOL}`])
   })
 
-  it('warning handles parameters correctly', () => {
-    const message = 'this is my error message'
-    core.warning(new Error(message), {
-      title: 'A title',
-      file: 'root/test.txt',
-      startColumn: 1,
-      endColumn: 2,
-      startLine: 5,
-      endLine: 5
-    })
-    assertWriteCalls([
-      `::warning title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::Error: ${message}${os.EOL}`
-    ])
-  })
-
-  it('notice sets the correct message', () => {
-    core.notice('Notice')
-    assertWriteCalls([`::notice::Notice${os.EOL}`])
-  })
-
-  it('notice escapes the message', () => {
-    core.notice('\r\nnotice\n')
-    assertWriteCalls([`::notice::%0D%0Anotice%0A${os.EOL}`])
-  })
-
   it('notice handles an error object', () => {
     const message = 'this is my error message'
     core.notice(new Error(message))
     assertWriteCalls([`::notice::Error: ${message}${os.EOL}`])
   })
 
-  it('notice handles parameters correctly', () => {
-    const message = 'this is my error message'
-    core.notice(new Error(message), {
-      title: 'A title',
-      file: 'root/test.txt',
-      startColumn: 1,
-      endColumn: 2,
-      startLine: 5,
-      endLine: 5
-    })
-    assertWriteCalls([
-      `::notice title=A title,file=root/test.txt,line=5,endLine=5,col=1,endColumn=2::Error: ${message}${os.EOL}`
-    ])
-  })
-
-  it('annotations map field names correctly', () => {
-    const commandProperties = toCommandProperties({
-      title: 'A title',
-      file: 'root/test.txt',
-      startColumn: 1,
-      endColumn: 2,
-      startLine: 5,
-      endLine: 5
-    })
-    expect(commandProperties.title).toBe('A title')
-    expect(commandProperties.file).toBe('root/test.txt')
-    expect(commandProperties.col).toBe(1)
-    expect(commandProperties.endColumn).toBe(2)
-    expect(commandProperties.line).toBe(5)
-    expect(commandProperties.endLine).toBe(5)
-
-    expect(commandProperties.startColumn).toBeUndefined()
-    expect(commandProperties.startLine).toBeUndefined()
-  })
-
   it('startGroup starts a new group', () => {
     core.startGroup('my-group')
     assertWriteCalls([`::group::my-group${os.EOL}`])
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AddMatcher registers the problem matchers of a JSON file, so that the runner annotates the
+// lines of the output of the step they match.
+func AddMatcher(path string) {
+	issueCommand("add-matcher", nil, path)
+}
+
+// RemoveMatcher unregisters the problem matcher of an owner.
+func RemoveMatcher(owner string) {
+	issueCommand("remove-matcher", []commandProperty{{key: "owner", value: owner}}, "")
+}
+
+// ProblemMatchers is the content of a problem matcher file.
+type ProblemMatchers struct {
+	ProblemMatcher []*ProblemMatcher `json:"problemMatcher"`
+}
+
+// ProblemMatcher matches the problems of an output, like the problem matchers of the runner.
+// A matcher keeps the state of multi-line patterns between the lines it matches, so it is
+// not safe for concurrent use.
+type ProblemMatcher struct {
+	Owner string `json:"owner"`
+
+	// Severity is the severity of the problems without a severity group: error, warning or
+	// notice. Defaults to error
+	Severity string `json:"severity,omitempty"`
+
+	Pattern []ProblemPattern `json:"pattern"`
+
+	regexps []*regexp.Regexp
+	index   int
+	partial Problem
+	// partialFromPath is the fromPath of the partial problem, joined with its file once complete
+	partialFromPath string
+}
+
+// ProblemPattern is a pattern of a problem matcher. The properties other than Regexp and
+// Loop are the indexes of the groups of the regexp capturing them, or 0. The regexps are
+// compiled as Go RE2 regexps, not .NET ones like the runner does, so the matchers using
+// lookarounds or backreferences fail to compile.
+type ProblemPattern struct {
+	Regexp   string `json:"regexp"`
+	File     int    `json:"file,omitempty"`
+	FromPath int    `json:"fromPath,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity int    `json:"severity,omitempty"`
+	Code     int    `json:"code,omitempty"`
+	Message  int    `json:"message,omitempty"`
+
+	// Loop repeats the last pattern of a multi-line matcher, producing a problem per line
+	Loop bool `json:"loop,omitempty"`
+}
+
+// Problem is a problem matched in an output.
+type Problem struct {
+	Owner string
+
+	// Severity is error, warning or notice
+	Severity string
+
+	// File is the path of the file, relative to the directory of the fromPath group if any
+	File   string
+	Line   int
+	Column int
+	Code   string
+
+	Message string
+}
+
+// LoadProblemMatchers reads and validates the problem matchers of a JSON file.
+func LoadProblemMatchers(path string) ([]*ProblemMatcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProblemMatchers(data)
+}
+
+// ParseProblemMatchers parses and validates problem matchers in JSON.
+func ParseProblemMatchers(data []byte) ([]*ProblemMatcher, error) {
+	var matchers ProblemMatchers
+	if err := json.Unmarshal(data, &matchers); err != nil {
+		return nil, fmt.Errorf("failed to parse the problem matchers: %w", err)
+	}
+	for _, matcher := range matchers.ProblemMatcher {
+		if err := matcher.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return matchers.ProblemMatcher, nil
+}
+
+// compile validates the matcher and compiles its patterns.
+func (m *ProblemMatcher) compile() error {
+	if m.Owner == "" {
+		return errors.New("the owner of a problem matcher is required")
+	}
+	switch m.Severity {
+	case "", "error", "warning", "notice":
+	default:
+		return fmt.Errorf("the severity %q of the problem matcher %s is not error, warning or notice", m.Severity, m.Owner)
+	}
+	if len(m.Pattern) == 0 {
+		return fmt.Errorf("the problem matcher %s has no pattern", m.Owner)
+	}
+
+	set := map[string]bool{}
+	m.regexps = make([]*regexp.Regexp, len(m.Pattern))
+	for i, pattern := range m.Pattern {
+		re, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return fmt.Errorf("the regexp of the pattern %d of the problem matcher %s is not valid: %w", i+1, m.Owner, err)
+		}
+		m.regexps[i] = re
+
+		if pattern.Loop {
+			switch {
+			case len(m.Pattern) == 1:
+				return fmt.Errorf("the problem matcher %s loops on a single pattern", m.Owner)
+			case i != len(m.Pattern)-1:
+				return fmt.Errorf("only the last pattern of the problem matcher %s can loop", m.Owner)
+			case pattern.Message == 0:
+				return fmt.Errorf("the loop pattern of the problem matcher %s must capture the message", m.Owner)
+			}
+		}
+		for _, group := range []struct {
+			name  string
+			index int
+		}{
+			{name: "file", index: pattern.File},
+			{name: "fromPath", index: pattern.FromPath},
+			{name: "line", index: pattern.Line},
+			{name: "column", index: pattern.Column},
+			{name: "severity", index: pattern.Severity},
+			{name: "code", index: pattern.Code},
+			{name: "message", index: pattern.Message},
+		} {
+			if group.index == 0 {
+				continue
+			}
+			if group.index < 0 || group.index > re.NumSubexp() {
+				return fmt.Errorf("the %s of the pattern %d of the problem matcher %s is not a group of its regexp", group.name, i+1, m.Owner)
+			}
+			if set[group.name] {
+				return fmt.Errorf("the %s of the problem matcher %s is captured by several patterns", group.name, m.Owner)
+			}
+			set[group.name] = true
+		}
+	}
+	if !set["message"] {
+		return fmt.Errorf("the problem matcher %s must capture the message", m.Owner)
+	}
+	return nil
+}
+
+// Match matches a line of an output, and returns the problem it completes or nil. The lines
+// matched by the patterns of a multi-line matcher but the last are remembered until the last
+// pattern matches or a line does not match the next pattern. Like the runner, the problems
+// with a captured severity other than error, warning or notice are dropped. A matcher not
+// created by ParseProblemMatchers is validated on its first line, and matches nothing if it is
+// invalid.
+func (m *ProblemMatcher) Match(line string) *Problem {
+	if m.regexps == nil {
+		if err := m.compile(); err != nil {
+			return nil
+		}
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	if m.index > 0 {
+		if problem, ok := m.matchPattern(m.index, line); ok {
+			return problem
+		}
+		// The line does not continue the problem, but may start another one
+		m.reset()
+	}
+	problem, _ := m.matchPattern(0, line)
+	return problem
+}
+
+// matchPattern matches a line with the pattern i, and returns the problem it completes and
+// whether the line matched.
+func (m *ProblemMatcher) matchPattern(i int, line string) (*Problem, bool) {
+	groups := m.regexps[i].FindStringSubmatch(line)
+	if groups == nil {
+		return nil, false
+	}
+	pattern := m.Pattern[i]
+	problem := m.partial
+	set := func(target *string, index int) {
+		if index > 0 {
+			*target = groups[index]
+		}
+	}
+	set(&problem.File, pattern.File)
+	set(&problem.Severity, pattern.Severity)
+	set(&problem.Code, pattern.Code)
+	set(&problem.Message, pattern.Message)
+	fromPath := m.partialFromPath
+	set(&fromPath, pattern.FromPath)
+	if pattern.Line > 0 {
+		problem.Line, _ = strconv.Atoi(groups[pattern.Line])
+	}
+	if pattern.Column > 0 {
+		problem.Column, _ = strconv.Atoi(groups[pattern.Column])
+	}
+
+	if i < len(m.Pattern)-1 {
+		m.partial = problem
+		m.partialFromPath = fromPath
+		m.index = i + 1
+		return nil, true
+	}
+	if pattern.Loop {
+		// The next lines may match the last pattern again, with the groups of the previous
+		// patterns
+		m.index = i
+	} else {
+		m.reset()
+	}
+	if problem.Message == "" {
+		return nil, true
+	}
+	if fromPath != "" && problem.File != "" && !filepath.IsAbs(problem.File) {
+		problem.File = filepath.Join(filepath.Dir(fromPath), problem.File)
+	}
+	problem.Owner = m.Owner
+	if problem.Severity = m.severity(problem.Severity); problem.Severity == "" {
+		return nil, true
+	}
+	return &problem, true
+}
+
+func (m *ProblemMatcher) reset() {
+	m.index = 0
+	m.partial = Problem{}
+	m.partialFromPath = ""
+}
+
+// severity returns the severity of a problem like the runner: the captured severity if it is
+// error, warning or notice ignoring the case, else the severity of the matcher if nothing was
+// captured, else an empty string as the runner drops the problem.
+func (m *ProblemMatcher) severity(captured string) string {
+	switch captured = strings.ToLower(captured); captured {
+	case "error", "warning", "notice":
+		return captured
+	case "":
+		if m.Severity != "" {
+			return m.Severity
+		}
+		return "error"
+	default:
+		return ""
+	}
+}
+
+// MatchProblems matches the lines of an output with problem matchers. Like the runner, the
+// matchers are tried in order and a line is only matched by the first matcher it matches.
+func MatchProblems(r io.Reader, matchers []*ProblemMatcher) ([]Problem, error) {
+	var problems []Problem
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		for _, matcher := range matchers {
+			if problem := matcher.Match(scanner.Text()); problem != nil {
+				problems = append(problems, *problem)
+				break
+			}
+		}
+	}
+	return problems, scanner.Err()
+}
+
+// Annotate writes the problem as an annotation of its severity.
+func (p *Problem) Annotate() {
+	properties := &AnnotationProperties{}
+	if p.Code != "" {
+		properties.Title = &p.Code
+	}
+	if p.File != "" {
+		properties.File = &p.File
+	}
+	if p.Line > 0 {
+		properties.StartLine = &p.Line
+	}
+	if p.Column > 0 {
+		properties.StartColumn = &p.Column
+	}
+	switch p.Severity {
+	case "warning":
+		Warning(p.Message, properties)
+	case "notice":
+		Notice(p.Message, properties)
+	default:
+		Error(p.Message, properties)
+	}
+}
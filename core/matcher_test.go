@@ -0,0 +1,237 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_AddMatcher(t *testing.T) {
+	buf := captureStdout(t)
+	AddMatcher("/tmp/eslint-compact.json")
+	RemoveMatcher("eslint-compact")
+	if expected := "::add-matcher::/tmp/eslint-compact.json\n::remove-matcher owner=eslint-compact::\n"; buf.String() != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, buf.String())
+	}
+}
+
+func Test_ParseProblemMatchers(t *testing.T) {
+	table := []struct {
+		name     string
+		matchers string
+		err      string
+	}{
+		{name: "valid", matchers: `{"problemMatcher": [{"owner": "go", "pattern": [{"regexp": "^(.+):(\\d+): (.+)$", "file": 1, "line": 2, "message": 3}]}]}`},
+		{name: "invalid JSON", matchers: `{"problemMatcher": {}}`, err: "failed to parse the problem matchers: json: cannot unmarshal object into Go struct field ProblemMatchers.problemMatcher of type []*core.ProblemMatcher"},
+		{name: "no owner", matchers: `{"problemMatcher": [{"pattern": [{"regexp": "(.+)", "message": 1}]}]}`, err: "the owner of a problem matcher is required"},
+		{name: "severity", matchers: `{"problemMatcher": [{"owner": "a", "severity": "fatal", "pattern": [{"regexp": "(.+)", "message": 1}]}]}`, err: `the severity "fatal" of the problem matcher a is not error, warning or notice`},
+		{name: "no pattern", matchers: `{"problemMatcher": [{"owner": "a", "pattern": []}]}`, err: "the problem matcher a has no pattern"},
+		{name: "invalid regexp", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(", "message": 1}]}]}`, err: "the regexp of the pattern 1 of the problem matcher a is not valid: error parsing regexp: missing closing ): `(`"},
+		{name: "missing group", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "message": 2}]}]}`, err: "the message of the pattern 1 of the problem matcher a is not a group of its regexp"},
+		{name: "no message", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "file": 1}]}]}`, err: "the problem matcher a must capture the message"},
+		{name: "captured twice", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "file": 1}, {"regexp": "(.+) (.+)", "file": 1, "message": 2}]}]}`, err: "the file of the problem matcher a is captured by several patterns"},
+		{name: "single loop", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "message": 1, "loop": true}]}]}`, err: "the problem matcher a loops on a single pattern"},
+		{name: "loop not last", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "file": 1, "loop": true}, {"regexp": "(.+)", "message": 1}]}]}`, err: "only the last pattern of the problem matcher a can loop"},
+		{name: "loop without message", matchers: `{"problemMatcher": [{"owner": "a", "pattern": [{"regexp": "(.+)", "message": 1}, {"regexp": "(.+)", "file": 1, "loop": true}]}]}`, err: "the loop pattern of the problem matcher a must capture the message"},
+	}
+	for _, tt := range table {
+		matchers, err := ParseProblemMatchers([]byte(tt.matchers))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+			}
+			continue
+		}
+		if err != nil || len(matchers) != 1 {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+	}
+}
+
+const testMatchers = `{
+  "problemMatcher": [
+    {
+      "owner": "eslint-compact",
+      "pattern": [
+        {
+          "regexp": "^(.+):\\sline\\s(\\d+),\\scol\\s(\\d+),\\s(Error|Warning|Info)\\s-\\s(.+)\\s\\((.+)\\)$",
+          "file": 1,
+          "line": 2,
+          "column": 3,
+          "severity": 4,
+          "message": 5,
+          "code": 6
+        }
+      ]
+    },
+    {
+      "owner": "eslint-stylish",
+      "severity": "warning",
+      "pattern": [
+        {
+          "regexp": "^([^\\s].*)$",
+          "file": 1
+        },
+        {
+          "regexp": "^\\s+(\\d+):(\\d+)\\s+(error|warning|info)?\\s+(.*?)\\s\\s+(.*)$",
+          "line": 1,
+          "column": 2,
+          "severity": 3,
+          "message": 4,
+          "code": 5,
+          "loop": true
+        }
+      ]
+    },
+    {
+      "owner": "msbuild",
+      "pattern": [
+        {
+          "regexp": "^(.+)\\((\\d+),(\\d+)\\): (error|warning) (\\w+): (.+) \\[(.+)\\]$",
+          "file": 1,
+          "line": 2,
+          "column": 3,
+          "severity": 4,
+          "code": 5,
+          "message": 6,
+          "fromPath": 7
+        }
+      ]
+    }
+  ]
+}`
+
+func Test_MatchProblems(t *testing.T) {
+	matchers, err := ParseProblemMatchers([]byte(testMatchers))
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	output := strings.Join([]string{
+		"> eslint --format compact",
+		"src/a.js: line 1, col 10, Error - 'x' is not defined. (no-undef)",
+		"src/b.js: line 2, col 1, Warning - Unexpected console statement. (no-console)",
+		"",
+		"src/c.js",
+		"  3:5   error  'y' is assigned a value but never used  no-unused-vars",
+		"  4:1          Missing semicolon                       semi",
+		// The runner drops the problems of other severities
+		"  5:1   info   Unexpected var                          no-var",
+		"",
+		"  2 problems",
+		"Program.cs(12,8): error CS1002: ; expected [/src/app/app.csproj]",
+	}, "\n")
+
+	problems, err := MatchProblems(strings.NewReader(output), matchers)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	expected := []Problem{
+		{Owner: "eslint-compact", Severity: "error", File: "src/a.js", Line: 1, Column: 10, Code: "no-undef", Message: "'x' is not defined."},
+		{Owner: "eslint-compact", Severity: "warning", File: "src/b.js", Line: 2, Column: 1, Code: "no-console", Message: "Unexpected console statement."},
+		{Owner: "eslint-stylish", Severity: "error", File: "src/c.js", Line: 3, Column: 5, Code: "no-unused-vars", Message: "'y' is assigned a value but never used"},
+		{Owner: "eslint-stylish", Severity: "warning", File: "src/c.js", Line: 4, Column: 1, Code: "semi", Message: "Missing semicolon"},
+		{Owner: "msbuild", Severity: "error", File: filepath.Join("/src/app", "Program.cs"), Line: 12, Column: 8, Code: "CS1002", Message: "; expected"},
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, problems)
+	}
+}
+
+func Test_ProblemMatcherMatch(t *testing.T) {
+	matcher := &ProblemMatcher{
+		Owner: "multi-line",
+		Pattern: []ProblemPattern{
+			{Regexp: `^File: (.+)$`, File: 1},
+			{Regexp: `^Line: (\d+)$`, Line: 1},
+			{Regexp: `^Message: (.+)$`, Message: 1},
+		},
+	}
+	table := []struct {
+		line     string
+		expected *Problem
+	}{
+		{line: "File: a.go"},
+		{line: "Line: 1"},
+		{line: "Message: first", expected: &Problem{Owner: "multi-line", Severity: "error", File: "a.go", Line: 1, Message: "first"}},
+		{line: "Message: not after a file"},
+		{line: "File: b.go"},
+		// A line interrupting the problem can start another one
+		{line: "File: c.go"},
+		{line: "Line: 3\r\n"},
+		{line: "Message: second", expected: &Problem{Owner: "multi-line", Severity: "error", File: "c.go", Line: 3, Message: "second"}},
+	}
+	for _, tt := range table {
+		if actual := matcher.Match(tt.line); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, actual, tt.line)
+		}
+	}
+
+	// The fromPath of a line applies to the file of the next lines
+	fromPath := &ProblemMatcher{
+		Owner: "from-path",
+		Pattern: []ProblemPattern{
+			{Regexp: `^In (.+)$`, FromPath: 1},
+			{Regexp: `^(.+):(\d+): (.+)$`, File: 1, Line: 2, Message: 3, Loop: true},
+		},
+	}
+	fromPathTable := []struct {
+		line     string
+		expected *Problem
+	}{
+		{line: "In sub/dir/go.mod"},
+		{line: "main.go:3: bad", expected: &Problem{Owner: "from-path", Severity: "error", File: filepath.Join("sub", "dir", "main.go"), Line: 3, Message: "bad"}},
+		{line: "util.go:5: worse", expected: &Problem{Owner: "from-path", Severity: "error", File: filepath.Join("sub", "dir", "util.go"), Line: 5, Message: "worse"}},
+	}
+	for _, tt := range fromPathTable {
+		if actual := fromPath.Match(tt.line); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, actual, tt.line)
+		}
+	}
+
+	// Invalid matchers match nothing
+	invalid := &ProblemMatcher{Owner: "invalid", Pattern: []ProblemPattern{{Regexp: `(.+)`}}}
+	if actual := invalid.Match("line"); actual != nil {
+		t.Fatalf("expected value is %v, but result was %+v.", nil, actual)
+	}
+}
+
+func Test_LoadProblemMatchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matchers.json")
+	if err := os.WriteFile(path, []byte(testMatchers), 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	matchers, err := LoadProblemMatchers(path)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	var owners []string
+	for _, matcher := range matchers {
+		owners = append(owners, matcher.Owner)
+	}
+	if expected := []string{"eslint-compact", "eslint-stylish", "msbuild"}; !reflect.DeepEqual(owners, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, owners)
+	}
+	if _, err := LoadProblemMatchers(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func Test_ProblemAnnotate(t *testing.T) {
+	table := []struct {
+		problem  Problem
+		expected string
+	}{
+		{problem: Problem{Severity: "error", File: "src/a.js", Line: 1, Column: 10, Code: "no-undef", Message: "'x' is not defined."}, expected: "::error title=no-undef,file=src/a.js,line=1,col=10::'x' is not defined.\n"},
+		{problem: Problem{Severity: "warning", File: "src/b.js", Message: "warning"}, expected: "::warning file=src/b.js::warning\n"},
+		{problem: Problem{Severity: "notice", Message: "notice"}, expected: "::notice::notice\n"},
+	}
+	for _, tt := range table {
+		buf := captureStdout(t)
+		tt.problem.Annotate()
+		if buf.String() != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.", tt.expected, buf.String())
+		}
+	}
+}
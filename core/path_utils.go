@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ToPosixPath converts the given path to the posix form. On Windows, \\ will be
+// replaced with /.
+func ToPosixPath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// ToWin32Path converts the given path to the win32 form. On Linux, / will be
+// replaced with \\.
+func ToWin32Path(path string) string {
+	return strings.ReplaceAll(path, "/", `\`)
+}
+
+// ToPlatformPath converts the given path to a platform-specific path. It does
+// this by replacing instances of / and \ with the platform-specific path
+// separator.
+func ToPlatformPath(path string) string {
+	return strings.NewReplacer("/", string(filepath.Separator), `\`, string(filepath.Separator)).Replace(path)
+}
+
+// RelativeToWorkspace converts an absolute path in GITHUB_WORKSPACE to a posix path relative to
+// it, e.g. /home/runner/work/repo/repo/src/main.go to src/main.go, which is how the runner
+// links the files of annotations to the repository. Other paths are returned unchanged.
+func RelativeToWorkspace(path string) string {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return ToPosixPath(rel)
+}
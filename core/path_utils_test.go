@@ -0,0 +1,60 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_PathUtils(t *testing.T) {
+	table := []struct {
+		name     string
+		input    string
+		posix    string
+		win32    string
+		platform string
+	}{
+		{name: "empty string", input: "", posix: "", win32: ""},
+		{name: "single value", input: "foo", posix: "foo", win32: "foo"},
+		{name: "with posix relative", input: "foo/bar/baz", posix: "foo/bar/baz", win32: `foo\bar\baz`},
+		{name: "with posix absolute", input: "/foo/bar/baz", posix: "/foo/bar/baz", win32: `\foo\bar\baz`},
+		{name: "with win32 relative", input: `foo\bar\baz`, posix: "foo/bar/baz", win32: `foo\bar\baz`},
+		{name: "with win32 absolute", input: `\foo\bar\baz`, posix: "/foo/bar/baz", win32: `\foo\bar\baz`},
+		{name: "with a mix", input: `\foo/bar/baz`, posix: "/foo/bar/baz", win32: `\foo\bar\baz`},
+	}
+	for _, tt := range table {
+		if actual := ToPosixPath(tt.input); actual != tt.posix {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.posix, actual, tt.name)
+		}
+		if actual := ToWin32Path(tt.input); actual != tt.win32 {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.win32, actual, tt.name)
+		}
+		expected := strings.ReplaceAll(tt.posix, "/", string(filepath.Separator))
+		if actual := ToPlatformPath(tt.input); actual != expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", expected, actual, tt.name)
+		}
+	}
+}
+
+func Test_RelativeToWorkspace(t *testing.T) {
+	workspace := t.TempDir()
+	table := []struct {
+		name      string
+		workspace string
+		path      string
+		expected  string
+	}{
+		{name: "in the workspace", workspace: workspace, path: filepath.Join(workspace, "src", "main.go"), expected: "src/main.go"},
+		{name: "workspace with a trailing separator", workspace: workspace + string(filepath.Separator), path: filepath.Join(workspace, "main.go"), expected: "main.go"},
+		{name: "relative", workspace: workspace, path: "src/main.go", expected: "src/main.go"},
+		{name: "outside of the workspace", workspace: workspace, path: filepath.Join(filepath.Dir(workspace), "main.go"), expected: filepath.Join(filepath.Dir(workspace), "main.go")},
+		{name: "sibling with the same prefix", workspace: workspace, path: workspace + "-other", expected: workspace + "-other"},
+		{name: "no workspace", path: filepath.Join(workspace, "main.go"), expected: filepath.Join(workspace, "main.go")},
+	}
+	for _, tt := range table {
+		t.Setenv("GITHUB_WORKSPACE", tt.workspace)
+		if actual := RelativeToWorkspace(tt.path); actual != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+const summaryEnvVar = "GITHUB_STEP_SUMMARY"
+
+// SummaryDocsURL is the documentation of the job summaries.
+const SummaryDocsURL = "https://docs.github.com/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary"
+
+// eol is the end of line of the summaries, like os.EOL.
+var eol = func() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}()
+
+// SummaryTableCell is a cell of a summary table.
+type SummaryTableCell struct {
+	/** Cell content */
+	Data string
+
+	/** Optional. Render cell as header. Defaults to false */
+	Header *bool
+
+	/** Optional. Number of columns the cell extends. Defaults to 1 */
+	Colspan *string
+
+	/** Optional. Number of rows the cell extends. Defaults to 1 */
+	Rowspan *string
+}
+
+// SummaryTableRow is a row of a summary table.
+type SummaryTableRow []SummaryTableCell
+
+type SummaryImageOptions struct {
+	/** Optional. The width of the image in pixels. Must be an integer without a unit. */
+	Width *string
+
+	/** Optional. The height of the image in pixels. Must be an integer without a unit. */
+	Height *string
+}
+
+type SummaryWriteOptions struct {
+	/** Optional. Replace all existing content in summary file with buffer contents. Defaults to false */
+	Overwrite *bool
+
+	initOnce sync.Once
+}
+
+func initializeSummaryWriteOptions(options *SummaryWriteOptions) *SummaryWriteOptions {
+	if options == nil {
+		options = &SummaryWriteOptions{}
+	}
+	options.initOnce.Do(options.init)
+	return options
+}
+
+// DO NOT CALL THIS OUTSIDE initializeSummaryWriteOptions
+func (o *SummaryWriteOptions) init() {
+	if o.Overwrite == nil {
+		o.Overwrite = ptr.Bool(false)
+	}
+}
+
+// Summary builds the job summary of the step, written to the file of GITHUB_STEP_SUMMARY. The
+// methods adding content return the summary, so that they can be chained.
+type Summary struct {
+	buffer strings.Builder
+}
+
+// NewSummary creates an empty summary.
+func NewSummary() *Summary {
+	return &Summary{}
+}
+
+// htmlAttribute is an attribute of an HTML element. The attributes are kept in order.
+type htmlAttribute struct {
+	key   string
+	value string
+}
+
+// wrap wraps content in an HTML tag, adding any HTML attributes.
+func wrap(tag string, content string, attrs ...htmlAttribute) string {
+	var htmlAttrs strings.Builder
+	for _, attr := range attrs {
+		fmt.Fprintf(&htmlAttrs, ` %s="%s"`, attr.key, attr.value)
+	}
+	if content == "" {
+		return fmt.Sprintf("<%s%s>", tag, htmlAttrs.String())
+	}
+	return fmt.Sprintf("<%s%s>%s</%s>", tag, htmlAttrs.String(), content, tag)
+}
+
+// summaryFilePath finds the summary file path from the environment.
+func summaryFilePath() (string, error) {
+	pathFromEnv := os.Getenv(summaryEnvVar)
+	if pathFromEnv == "" {
+		return "", fmt.Errorf("Unable to find environment variable for $%s. Check if your runtime environment supports job summaries.", summaryEnvVar)
+	}
+	file, err := os.OpenFile(pathFromEnv, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("Unable to access summary file: '%s'. Check if the file has correct read/write permissions.", pathFromEnv)
+	}
+	file.Close()
+	return pathFromEnv, nil
+}
+
+// Write writes text in the buffer to the summary buffer file and empties the buffer. Will
+// append by default. options may be nil.
+func (s *Summary) Write(options *SummaryWriteOptions) error {
+	options = initializeSummaryWriteOptions(options)
+	filePath, err := summaryFilePath()
+	if err != nil {
+		return err
+	}
+	flag := os.O_WRONLY | os.O_APPEND
+	if *options.Overwrite {
+		flag = os.O_WRONLY | os.O_TRUNC
+	}
+	file, err := os.OpenFile(filePath, flag, 0)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(s.buffer.String())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	s.EmptyBuffer()
+	return nil
+}
+
+// Clear clears the summary buffer and wipes the summary file.
+func (s *Summary) Clear() error {
+	return s.EmptyBuffer().Write(&SummaryWriteOptions{Overwrite: ptr.Bool(true)})
+}
+
+// Stringify returns the current summary buffer as a string.
+func (s *Summary) Stringify() string {
+	return s.buffer.String()
+}
+
+// IsEmptyBuffer returns whether the summary buffer is empty.
+func (s *Summary) IsEmptyBuffer() bool {
+	return s.buffer.Len() == 0
+}
+
+// EmptyBuffer resets the summary buffer without writing to the summary file.
+func (s *Summary) EmptyBuffer() *Summary {
+	s.buffer.Reset()
+	return s
+}
+
+// AddRaw adds raw text to the summary buffer.
+func (s *Summary) AddRaw(text string) *Summary {
+	s.buffer.WriteString(text)
+	return s
+}
+
+// AddEOL adds the operating system-specific end-of-line marker to the buffer.
+func (s *Summary) AddEOL() *Summary {
+	return s.AddRaw(eol)
+}
+
+// AddCodeBlock adds an HTML codeblock to the summary buffer. lang is the language for
+// syntax highlighting, or an empty string.
+func (s *Summary) AddCodeBlock(code string, lang string) *Summary {
+	var attrs []htmlAttribute
+	if lang != "" {
+		attrs = append(attrs, htmlAttribute{key: "lang", value: lang})
+	}
+	element := wrap("pre", wrap("code", code), attrs...)
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddList adds an HTML list to the summary buffer, ordered if ordered is true.
+func (s *Summary) AddList(items []string, ordered bool) *Summary {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	var listItems strings.Builder
+	for _, item := range items {
+		listItems.WriteString(wrap("li", item))
+	}
+	element := wrap(tag, listItems.String())
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddTable adds an HTML table to the summary buffer.
+func (s *Summary) AddTable(rows []SummaryTableRow) *Summary {
+	var tableBody strings.Builder
+	for _, row := range rows {
+		var cells strings.Builder
+		for _, cell := range row {
+			tag := "td"
+			if cell.Header != nil && *cell.Header {
+				tag = "th"
+			}
+			var attrs []htmlAttribute
+			if cell.Colspan != nil && *cell.Colspan != "" {
+				attrs = append(attrs, htmlAttribute{key: "colspan", value: *cell.Colspan})
+			}
+			if cell.Rowspan != nil && *cell.Rowspan != "" {
+				attrs = append(attrs, htmlAttribute{key: "rowspan", value: *cell.Rowspan})
+			}
+			cells.WriteString(wrap(tag, cell.Data, attrs...))
+		}
+		tableBody.WriteString(wrap("tr", cells.String()))
+	}
+	element := wrap("table", tableBody.String())
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddDetails adds a collapsable HTML details element to the summary buffer.
+func (s *Summary) AddDetails(label string, content string) *Summary {
+	element := wrap("details", wrap("summary", label)+content)
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddImage adds an HTML image tag to the summary buffer. options may be nil.
+func (s *Summary) AddImage(src string, alt string, options *SummaryImageOptions) *Summary {
+	attrs := []htmlAttribute{{key: "src", value: src}, {key: "alt", value: alt}}
+	if options != nil && options.Width != nil && *options.Width != "" {
+		attrs = append(attrs, htmlAttribute{key: "width", value: *options.Width})
+	}
+	if options != nil && options.Height != nil && *options.Height != "" {
+		attrs = append(attrs, htmlAttribute{key: "height", value: *options.Height})
+	}
+	element := wrap("img", "", attrs...)
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddHeading adds an HTML section heading element. level is the heading level from 1 to 6;
+// other levels render as 1.
+func (s *Summary) AddHeading(text string, level int) *Summary {
+	tag := "h1"
+	if level >= 1 && level <= 6 {
+		tag = fmt.Sprintf("h%d", level)
+	}
+	element := wrap(tag, text)
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddSeparator adds an HTML thematic break (<hr>) to the summary buffer.
+func (s *Summary) AddSeparator() *Summary {
+	element := wrap("hr", "")
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddBreak adds an HTML line break (<br>) to the summary buffer.
+func (s *Summary) AddBreak() *Summary {
+	element := wrap("br", "")
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddQuote adds an HTML blockquote to the summary buffer. cite is the citation URL, or an
+// empty string.
+func (s *Summary) AddQuote(text string, cite string) *Summary {
+	var attrs []htmlAttribute
+	if cite != "" {
+		attrs = append(attrs, htmlAttribute{key: "cite", value: cite})
+	}
+	element := wrap("blockquote", text, attrs...)
+	return s.AddRaw(element).AddEOL()
+}
+
+// AddLink adds an HTML anchor tag to the summary buffer.
+func (s *Summary) AddLink(text string, href string) *Summary {
+	element := wrap("a", text, htmlAttribute{key: "href", value: href})
+	return s.AddRaw(element).AddEOL()
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func setSummaryFile(t *testing.T) string {
+	filePath := filepath.Join(t.TempDir(), "step_summary")
+	if err := os.WriteFile(filePath, nil, 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", filePath)
+	return filePath
+}
+
+func readSummaryFile(t *testing.T, filePath string) string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return string(data)
+}
+
+func Test_SummaryWrite(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := NewSummary().AddRaw("# h1 text").Write(nil); err == nil || err.Error() != "Unable to find environment variable for $GITHUB_STEP_SUMMARY. Check if your runtime environment supports job summaries." {
+		t.Fatalf("expected value is %v, but result was %v.", "an error", err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("GITHUB_STEP_SUMMARY", missing)
+	if err := NewSummary().AddRaw("# h1 text").Write(nil); err == nil || err.Error() != "Unable to access summary file: '"+missing+"'. Check if the file has correct read/write permissions." {
+		t.Fatalf("expected value is %v, but result was %v.", "an error", err)
+	}
+
+	filePath := setSummaryFile(t)
+	summary := NewSummary()
+	if err := summary.AddRaw("# h1 text").AddEOL().Write(nil); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if err := summary.AddRaw("# h1 text").AddEOL().Write(nil); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := "# h1 text" + eol + "# h1 text" + eol; readSummaryFile(t, filePath) != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, readSummaryFile(t, filePath))
+	}
+	if !summary.IsEmptyBuffer() {
+		t.Fatalf("expected the buffer to be emptied, but result was %q.", summary.Stringify())
+	}
+
+	if err := summary.AddRaw("# overwritten").Write(&SummaryWriteOptions{Overwrite: ptr.Bool(true)}); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := "# overwritten"; readSummaryFile(t, filePath) != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, readSummaryFile(t, filePath))
+	}
+
+	summary.AddRaw("# not written")
+	if err := summary.Clear(); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if readSummaryFile(t, filePath) != "" || !summary.IsEmptyBuffer() {
+		t.Fatalf("expected the summary to be cleared, but result was %q %q.", readSummaryFile(t, filePath), summary.Stringify())
+	}
+}
+
+func Test_SummaryBuilder(t *testing.T) {
+	table := []struct {
+		name     string
+		build    func(s *Summary) *Summary
+		expected string
+	}{
+		{name: "raw", build: func(s *Summary) *Summary { return s.AddRaw("# h1 text") }, expected: "# h1 text"},
+		{
+			name:     "code block",
+			build:    func(s *Summary) *Summary { return s.AddCodeBlock("func fork() {\n  for {\n    go fork()\n  }\n}", "") },
+			expected: "<pre><code>func fork() {\n  for {\n    go fork()\n  }\n}</code></pre>" + eol,
+		},
+		{
+			name:     "code block with language",
+			build:    func(s *Summary) *Summary { return s.AddCodeBlock("func fork() {}", "go") },
+			expected: `<pre lang="go"><code>func fork() {}</code></pre>` + eol,
+		},
+		{
+			name:     "unordered list",
+			build:    func(s *Summary) *Summary { return s.AddList([]string{"foo", "bar", "baz", "💣"}, false) },
+			expected: "<ul><li>foo</li><li>bar</li><li>baz</li><li>💣</li></ul>" + eol,
+		},
+		{
+			name:     "ordered list",
+			build:    func(s *Summary) *Summary { return s.AddList([]string{"foo", "bar", "baz", "💣"}, true) },
+			expected: "<ol><li>foo</li><li>bar</li><li>baz</li><li>💣</li></ol>" + eol,
+		},
+		{
+			name: "table",
+			build: func(s *Summary) *Summary {
+				return s.AddTable([]SummaryTableRow{
+					{{Data: "foo", Header: ptr.Bool(true)}, {Data: "bar", Header: ptr.Bool(true)}, {Data: "baz", Header: ptr.Bool(true)}, {Data: "tall", Rowspan: ptr.String("3")}},
+					{{Data: "one"}, {Data: "two"}, {Data: "three"}},
+					{{Data: "wide", Colspan: ptr.String("3")}},
+				})
+			},
+			expected: `<table><tr><th>foo</th><th>bar</th><th>baz</th><td rowspan="3">tall</td></tr><tr><td>one</td><td>two</td><td>three</td></tr><tr><td colspan="3">wide</td></tr></table>` + eol,
+		},
+		{
+			name:     "details",
+			build:    func(s *Summary) *Summary { return s.AddDetails("open me", "🎉 surprise") },
+			expected: "<details><summary>open me</summary>🎉 surprise</details>" + eol,
+		},
+		{
+			name:     "image",
+			build:    func(s *Summary) *Summary { return s.AddImage("https://github.com/actions.png", "actions logo", nil) },
+			expected: `<img src="https://github.com/actions.png" alt="actions logo">` + eol,
+		},
+		{
+			name: "image with size",
+			build: func(s *Summary) *Summary {
+				return s.AddImage("https://github.com/actions.png", "actions logo", &SummaryImageOptions{Width: ptr.String("32"), Height: ptr.String("32")})
+			},
+			expected: `<img src="https://github.com/actions.png" alt="actions logo" width="32" height="32">` + eol,
+		},
+		{
+			name: "headings",
+			build: func(s *Summary) *Summary {
+				for level := 1; level <= 6; level++ {
+					s.AddHeading("heading", level)
+				}
+				return s.AddHeading("heading", 0).AddHeading("heading", 7)
+			},
+			expected: "<h1>heading</h1>" + eol + "<h2>heading</h2>" + eol + "<h3>heading</h3>" + eol + "<h4>heading</h4>" + eol + "<h5>heading</h5>" + eol + "<h6>heading</h6>" + eol + "<h1>heading</h1>" + eol + "<h1>heading</h1>" + eol,
+		},
+		{name: "separator", build: func(s *Summary) *Summary { return s.AddSeparator() }, expected: "<hr>" + eol},
+		{name: "break", build: func(s *Summary) *Summary { return s.AddBreak() }, expected: "<br>" + eol},
+		{
+			name:     "quote",
+			build:    func(s *Summary) *Summary { return s.AddQuote("Where the world builds software", "") },
+			expected: "<blockquote>Where the world builds software</blockquote>" + eol,
+		},
+		{
+			name: "quote with citation",
+			build: func(s *Summary) *Summary {
+				return s.AddQuote("Where the world builds software", "https://github.com/about")
+			},
+			expected: `<blockquote cite="https://github.com/about">Where the world builds software</blockquote>` + eol,
+		},
+		{
+			name:     "link",
+			build:    func(s *Summary) *Summary { return s.AddLink("GitHub", "https://github.com/") },
+			expected: `<a href="https://github.com/">GitHub</a>` + eol,
+		},
+	}
+	for _, tt := range table {
+		actual := tt.build(NewSummary()).Stringify()
+		if actual != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+}
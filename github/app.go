@@ -0,0 +1,207 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ci-tools/toolkit/core"
+)
+
+const (
+	// jwtLifetime is the lifetime of the JWTs of an app. GitHub accepts at most 10 minutes.
+	jwtLifetime = 10 * time.Minute
+
+	// jwtClockDrift is subtracted from the issue time of the JWTs to allow for clock drift
+	jwtClockDrift = 30 * time.Second
+
+	// tokenRefreshMargin is the time before their expiry when the tokens are renewed
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// The function masking the installation tokens, overridden by tests.
+var setSecret = core.SetSecret
+
+// Installation is an installation of a GitHub App on a user or an organization.
+type Installation struct {
+	ID                  int64             `json:"id"`
+	AppID               int64             `json:"app_id"`
+	Account             User              `json:"account"`
+	TargetType          string            `json:"target_type"`
+	RepositorySelection string            `json:"repository_selection"`
+	Permissions         map[string]string `json:"permissions"`
+}
+
+// InstallationTokenOptions scopes an installation token.
+type InstallationTokenOptions struct {
+	/** Optional. The names of the repositories the token can access, without their owner. Defaults to all the repositories of the installation */
+	Repositories []string
+
+	/** Optional. The IDs of the repositories the token can access. Defaults to all the repositories of the installation */
+	RepositoryIDs []int64
+
+	/** Optional. The permissions of the token, e.g. {"issues": "write"}. Defaults to all the permissions of the installation */
+	Permissions map[string]string
+}
+
+// InstallationToken is an installation access token, valid for an hour.
+type InstallationToken struct {
+	Token               string            `json:"token"`
+	ExpiresAt           time.Time         `json:"expires_at"`
+	Permissions         map[string]string `json:"permissions"`
+	RepositorySelection string            `json:"repository_selection"`
+}
+
+type installationTokenRequest struct {
+	Repositories  []string          `json:"repositories,omitempty"`
+	RepositoryIDs []int64           `json:"repository_ids,omitempty"`
+	Permissions   map[string]string `json:"permissions,omitempty"`
+}
+
+// App authenticates as a GitHub App with JWTs signed by its private key, and as its
+// installations with installation tokens. The tokens are cached and renewed before they
+// expire, and masked from the logs.
+type App struct {
+	appID  string
+	key    *rsa.PrivateKey
+	client *Client
+
+	mu          sync.Mutex
+	jwt         string
+	jwtExpireAt time.Time
+	tokens      map[string]*InstallationToken
+}
+
+// NewApp creates a GitHub App from its app ID or client ID and its PEM encoded private key,
+// e.g. from an input. Escaped line breaks of the key are accepted. options may be nil.
+func NewApp(appID string, privateKey string, options *ClientOptions) (*App, error) {
+	if appID == "" {
+		return nil, errors.New("the app ID is required")
+	}
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	a := &App{appID: appID, key: key, tokens: map[string]*InstallationToken{}}
+	a.client = newClient(options, func(context.Context) (string, error) {
+		return a.JWT()
+	})
+	return a, nil
+}
+
+func parsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(privateKey, `\n`, "\n")))
+	if block == nil {
+		return nil, errors.New("the private key is not a PEM encoded key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// JWT returns a JWT authenticating as the app, reusing the last one until it nearly expires.
+func (a *App) JWT() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jwt != "" && a.jwtExpireAt.Sub(now()) > time.Minute {
+		return a.jwt, nil
+	}
+
+	issuedAt := now().Add(-jwtClockDrift)
+	expiresAt := issuedAt.Add(jwtLifetime)
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{"iat": issuedAt.Unix(), "exp": expiresAt.Unix(), "iss": a.appID})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	a.jwt = unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	a.jwtExpireAt = expiresAt
+	return a.jwt, nil
+}
+
+// Client returns a client authenticated as the app, for the endpoints of the app such as
+// /app/installations.
+func (a *App) Client() *Client {
+	return a.client
+}
+
+// GetRepoInstallation returns the installation of the app on the repository.
+func (a *App) GetRepoInstallation(ctx context.Context, owner, repo string) (*Installation, error) {
+	installation := &Installation{}
+	if _, err := a.client.Do(ctx, http.MethodGet, repoPath(owner, repo, "installation"), nil, installation); err != nil {
+		return nil, err
+	}
+	return installation, nil
+}
+
+// CreateInstallationToken returns an installation token, optionally scoped to some
+// repositories and permissions. options may be nil. The token is cached and a new one is
+// created when it expires in less than 5 minutes. New tokens are masked from the logs.
+func (a *App) CreateInstallationToken(ctx context.Context, installationID int64, options *InstallationTokenOptions) (*InstallationToken, error) {
+	request := &installationTokenRequest{}
+	if options != nil {
+		request.Repositories = options.Repositories
+		request.RepositoryIDs = options.RepositoryIDs
+		request.Permissions = options.Permissions
+	}
+	scope, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := fmt.Sprintf("%d:%s", installationID, scope)
+
+	a.mu.Lock()
+	token := a.tokens[cacheKey]
+	a.mu.Unlock()
+	if token != nil && token.ExpiresAt.Sub(now()) > tokenRefreshMargin {
+		return token, nil
+	}
+
+	token = &InstallationToken{}
+	path := fmt.Sprintf("/app/installations/%d/access_tokens", installationID)
+	if _, err := a.client.Do(ctx, http.MethodPost, path, request, token); err != nil {
+		return nil, err
+	}
+	setSecret(token.Token)
+
+	a.mu.Lock()
+	a.tokens[cacheKey] = token
+	a.mu.Unlock()
+	return token, nil
+}
+
+// InstallationClient returns a client authenticated as the installation. Its token is
+// created on the first request and renewed before it expires. options may be nil.
+func (a *App) InstallationClient(installationID int64, tokenOptions *InstallationTokenOptions, options *ClientOptions) *Client {
+	return newClient(options, func(ctx context.Context) (string, error) {
+		token, err := a.CreateInstallationToken(ctx, installationID, tokenOptions)
+		if err != nil {
+			return "", err
+		}
+		return token.Token, nil
+	})
+}
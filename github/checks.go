@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ci-tools/toolkit/core"
+)
+
+// maxAnnotationsPerRequest is the maximum number of annotations of a request creating or
+// updating a check run.
+const maxAnnotationsPerRequest = 50
+
+// The functions writing the annotations of the fallback, overridden by tests.
+var (
+	coreError   = core.Error
+	coreWarning = core.Warning
+	coreNotice  = core.Notice
+)
+
+// PublishCheckRun creates a check run, or updates the check run checkRunID when it is not 0,
+// with any number of annotations. The API accepts at most 50 annotations per request, so the
+// first 50 annotations are sent with the request and the others by updating the check run
+// with batches of 50 annotations.
+//
+// When the token is not allowed to write the checks, e.g. for pull requests from forks, the
+// annotations are written as workflow command annotations instead, and the returned check
+// run is nil.
+func (c *Client) PublishCheckRun(ctx context.Context, owner, repo string, checkRunID int64, request *CheckRunRequest) (*CheckRun, error) {
+	first := *request
+	var annotations []CheckRunAnnotation
+	if request.Output != nil {
+		output := *request.Output
+		annotations = output.Annotations
+		if len(annotations) > maxAnnotationsPerRequest {
+			output.Annotations = annotations[:maxAnnotationsPerRequest]
+		}
+		first.Output = &output
+	}
+
+	var checkRun *CheckRun
+	var err error
+	if checkRunID == 0 {
+		checkRun, err = c.CreateCheckRun(ctx, owner, repo, &first)
+	} else {
+		checkRun, err = c.UpdateCheckRun(ctx, owner, repo, checkRunID, &first)
+	}
+	if isForbidden(err) {
+		writeAnnotations(annotations)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := maxAnnotationsPerRequest; i < len(annotations); i += maxAnnotationsPerRequest {
+		end := i + maxAnnotationsPerRequest
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		// The title and the summary are required with the output. The images are added to
+		// the check run, so they are only sent once.
+		output := *first.Output
+		output.Annotations = annotations[i:end]
+		output.Images = nil
+		if checkRun, err = c.UpdateCheckRun(ctx, owner, repo, checkRun.ID, &CheckRunRequest{Output: &output}); err != nil {
+			return nil, err
+		}
+	}
+	return checkRun, nil
+}
+
+// isForbidden returns whether the error is a response forbidding the request, but not a rate
+// limit.
+func isForbidden(err error) bool {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return false
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+}
+
+// writeAnnotations writes the annotations of a check run as workflow command annotations.
+func writeAnnotations(annotations []CheckRunAnnotation) {
+	for _, annotation := range annotations {
+		annotation := annotation
+		properties := &core.AnnotationProperties{
+			Title:       annotation.Title,
+			File:        &annotation.Path,
+			StartLine:   &annotation.StartLine,
+			EndLine:     &annotation.EndLine,
+			StartColumn: annotation.StartColumn,
+			EndColumn:   annotation.EndColumn,
+		}
+		switch annotation.AnnotationLevel {
+		case "failure":
+			coreError(annotation.Message, properties)
+		case "warning":
+			coreWarning(annotation.Message, properties)
+		default:
+			coreNotice(annotation.Message, properties)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ci-tools/toolkit/core"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// recordAnnotations replaces the functions writing the annotations of the fallback for the
+// duration of the test.
+func recordAnnotations(t *testing.T) *[]string {
+	var annotations []string
+	record := func(level string) func(string, *core.AnnotationProperties) {
+		return func(message string, properties *core.AnnotationProperties) {
+			annotations = append(annotations, fmt.Sprintf("%s %s:%d:%d %s", level, *properties.File, *properties.StartLine, *properties.EndLine, message))
+		}
+	}
+	originalError, originalWarning, originalNotice := coreError, coreWarning, coreNotice
+	coreError, coreWarning, coreNotice = record("error"), record("warning"), record("notice")
+	t.Cleanup(func() { coreError, coreWarning, coreNotice = originalError, originalWarning, originalNotice })
+	return &annotations
+}
+
+func newAnnotations(n int) []CheckRunAnnotation {
+	annotations := make([]CheckRunAnnotation, n)
+	for i := range annotations {
+		annotations[i] = CheckRunAnnotation{Path: "main.go", StartLine: i + 1, EndLine: i + 1, AnnotationLevel: "warning", Message: fmt.Sprint("message ", i+1)}
+	}
+	return annotations
+}
+
+func Test_PublishCheckRun(t *testing.T) {
+	type request struct {
+		method      string
+		path        string
+		annotations int
+		images      int
+		summary     string
+	}
+	table := []struct {
+		name        string
+		checkRunID  int64
+		annotations int
+		expected    []request
+	}{
+		{
+			name:     "no annotations",
+			expected: []request{{method: "POST", path: "/repos/owner/repo/check-runs", images: 1, summary: "summary"}},
+		},
+		{
+			name:        "one batch",
+			annotations: 50,
+			expected:    []request{{method: "POST", path: "/repos/owner/repo/check-runs", annotations: 50, images: 1, summary: "summary"}},
+		},
+		{
+			name:        "batches",
+			annotations: 120,
+			expected: []request{
+				{method: "POST", path: "/repos/owner/repo/check-runs", annotations: 50, images: 1, summary: "summary"},
+				{method: "PATCH", path: "/repos/owner/repo/check-runs/7", annotations: 50, summary: "summary"},
+				{method: "PATCH", path: "/repos/owner/repo/check-runs/7", annotations: 20, summary: "summary"},
+			},
+		},
+		{
+			name:        "update",
+			checkRunID:  7,
+			annotations: 51,
+			expected: []request{
+				{method: "PATCH", path: "/repos/owner/repo/check-runs/7", annotations: 50, images: 1, summary: "summary"},
+				{method: "PATCH", path: "/repos/owner/repo/check-runs/7", annotations: 1, summary: "summary"},
+			},
+		},
+	}
+	for _, tt := range table {
+		var requests []request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body CheckRunRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, request{method: r.Method, path: r.URL.Path, annotations: len(body.Output.Annotations), images: len(body.Output.Images), summary: body.Output.Summary})
+			fmt.Fprint(w, `{"id": 7, "name": "lint", "status": "completed"}`)
+		}))
+
+		c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+		checkRun, err := c.PublishCheckRun(context.Background(), "owner", "repo", tt.checkRunID, &CheckRunRequest{
+			Name:    ptr.String("lint"),
+			HeadSHA: ptr.String("sha"),
+			Status:  ptr.String("completed"),
+			Output: &CheckRunOutput{
+				Title:       "title",
+				Summary:     "summary",
+				Annotations: newAnnotations(tt.annotations),
+				Images:      []CheckRunImage{{Alt: "alt", ImageURL: "https://example.com/image.png"}},
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if checkRun == nil || checkRun.ID != 7 {
+			t.Fatalf("expected value is %v, but result was %+v.\ntest case: %v", 7, checkRun, tt.name)
+		}
+		if !reflect.DeepEqual(requests, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, requests, tt.name)
+		}
+	}
+}
+
+func Test_PublishCheckRunFallback(t *testing.T) {
+	annotations := recordAnnotations(t)
+	server, count := newSequenceServer(t, []response{
+		{status: 403, body: `{"message": "Resource not accessible by integration", "documentation_url": "https://docs.github.com/rest/checks/runs#create-a-check-run"}`},
+	})
+
+	c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+	request := &CheckRunRequest{
+		Name:    ptr.String("lint"),
+		HeadSHA: ptr.String("sha"),
+		Output: &CheckRunOutput{
+			Title:   "title",
+			Summary: "summary",
+			Annotations: append(newAnnotations(51), []CheckRunAnnotation{
+				{Path: "a.go", StartLine: 1, EndLine: 2, AnnotationLevel: "failure", Message: "failure"},
+				{Path: "b.go", StartLine: 3, EndLine: 3, AnnotationLevel: "notice", Message: "notice"},
+			}...),
+		},
+	}
+	checkRun, err := c.PublishCheckRun(context.Background(), "owner", "repo", 0, request)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if checkRun != nil || *count != 1 {
+		t.Fatalf("expected value is %v, but result was %+v %v.", nil, checkRun, *count)
+	}
+	if len(*annotations) != 53 {
+		t.Fatalf("expected value is %v, but result was %v.", 53, len(*annotations))
+	}
+	expected := []string{"warning main.go:1:1 message 1", "warning main.go:51:51 message 51", "error a.go:1:2 failure", "notice b.go:3:3 notice"}
+	actual := []string{(*annotations)[0], (*annotations)[50], (*annotations)[51], (*annotations)[52]}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, actual)
+	}
+
+	// Other errors are returned
+	server, _ = newSequenceServer(t, []response{{status: 422, body: `{"message": "Validation Failed"}`}})
+	c = NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+	if _, err := c.PublishCheckRun(context.Background(), "owner", "repo", 0, request); err == nil || err.Error() != "Validation Failed" {
+		t.Fatalf("expected value is %v, but result was %v.", "Validation Failed", err)
+	}
+}
@@ -0,0 +1,476 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ci-tools/toolkit/httpclient"
+)
+
+const (
+	userAgent  = "actions/github"
+	apiVersion = "2022-11-28"
+
+	// secondaryRateLimitWait is the wait after a secondary rate limit without a retry-after header
+	secondaryRateLimitWait = time.Minute
+)
+
+// The delay before the first retry on server errors, the clock and the sleep between
+// attempts, overridden by tests.
+var (
+	retryDelay = time.Second
+	now        = time.Now
+	sleep      = func(ctx context.Context, d time.Duration) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+)
+
+// ClientOptions controls the API client.
+type ClientOptions struct {
+	/** Optional. The base URL of the REST API. Defaults to GITHUB_API_URL or https://api.github.com */
+	BaseURL *string
+
+	/** Optional. The URL of the GraphQL API. Defaults to GITHUB_GRAPHQL_URL or https://api.github.com/graphql */
+	GraphQLURL *string
+
+	/** Optional. The user agent of the requests. Defaults to actions/github */
+	UserAgent *string
+
+	/** Optional. The maximum number of retries of idempotent requests failing with server errors, and of requests hitting a rate limit. Defaults to 3 */
+	MaxRetries *int
+
+	/** Optional. The longest wait for a rate limit to reset. Requests hitting a rate limit which resets later fail with a RateLimitError. Defaults to 15 minutes */
+	MaxRateLimitWait *time.Duration
+
+	/** Optional. The hosts, besides the ones of BaseURL and GraphQLURL, receiving the token with the requests to absolute URLs. Defaults to uploads.github.com when BaseURL is https://api.github.com */
+	TokenHosts []string
+
+	/** Optional. The transport used to send requests, e.g. to stub the network in tests. Defaults to a transport honoring the proxy environment variables */
+	Transport http.RoundTripper
+}
+
+// Client is an authenticated client of the GitHub REST and GraphQL APIs, like the Octokit
+// client of @actions/github. It follows the rate limits and retries idempotent requests.
+type Client struct {
+	baseURL          string
+	graphqlURL       string
+	maxRetries       int
+	maxRateLimitWait time.Duration
+	token            func(ctx context.Context) (string, error)
+	client           *httpclient.Client
+
+	// tokenHosts are the hosts receiving the token, so that it does not leak to other hosts
+	// through absolute URLs, e.g. the next links of the lists
+	tokenHosts map[string]bool
+}
+
+// NewClient creates a client authenticated with the token, e.g. GITHUB_TOKEN. The requests
+// are anonymous when the token is empty. options may be nil.
+func NewClient(token string, options *ClientOptions) *Client {
+	if token == "" {
+		return newClient(options, nil)
+	}
+	return newClient(options, func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// newClient creates a client getting the token of each request from the function, which
+// may be nil for anonymous requests.
+func newClient(options *ClientOptions, token func(ctx context.Context) (string, error)) *Client {
+	if options == nil {
+		options = &ClientOptions{}
+	}
+	c := &Client{
+		baseURL:          getEnvOrDefault("GITHUB_API_URL", "https://api.github.com"),
+		graphqlURL:       getEnvOrDefault("GITHUB_GRAPHQL_URL", "https://api.github.com/graphql"),
+		maxRetries:       3,
+		maxRateLimitWait: 15 * time.Minute,
+		token:            token,
+	}
+	if options.BaseURL != nil {
+		c.baseURL = *options.BaseURL
+	}
+	c.baseURL = strings.TrimSuffix(c.baseURL, "/")
+	if options.GraphQLURL != nil {
+		c.graphqlURL = *options.GraphQLURL
+	}
+	if options.MaxRetries != nil && *options.MaxRetries >= 0 {
+		c.maxRetries = *options.MaxRetries
+	}
+	if options.MaxRateLimitWait != nil {
+		c.maxRateLimitWait = *options.MaxRateLimitWait
+	}
+	c.tokenHosts = map[string]bool{}
+	tokenHosts := append([]string{urlHost(c.baseURL), urlHost(c.graphqlURL)}, options.TokenHosts...)
+	if options.TokenHosts == nil && urlHost(c.baseURL) == "api.github.com" {
+		tokenHosts = append(tokenHosts, "uploads.github.com")
+	}
+	for _, host := range tokenHosts {
+		if host != "" {
+			c.tokenHosts[strings.ToLower(host)] = true
+		}
+	}
+	agent := userAgent
+	if options.UserAgent != nil {
+		agent = *options.UserAgent
+	}
+
+	c.client = httpclient.NewClient(agent, nil, &httpclient.RequestOptions{
+		Headers: http.Header{
+			"Accept":               {"application/vnd.github+json"},
+			"X-GitHub-Api-Version": {apiVersion},
+		},
+		Transport: options.Transport,
+	})
+	return c
+}
+
+// urlHost returns the host of a URL, with its port if any, or an empty string.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// Rate is the rate limit reported by the headers of a response.
+type Rate struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+	Resource  string
+}
+
+// Response is the response of a request. The body is decoded into the value passed to the request.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Rate       Rate
+
+	// NextURL is the URL of the next page of a list, or empty on the last page
+	NextURL string
+}
+
+func newResponse(resp *http.Response) *Response {
+	r := &Response{StatusCode: resp.StatusCode, Header: resp.Header}
+	r.Rate.Limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	r.Rate.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	r.Rate.Used, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Used"))
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		r.Rate.Reset = time.Unix(reset, 0)
+	}
+	r.Rate.Resource = resp.Header.Get("X-RateLimit-Resource")
+	r.NextURL = parseLinks(resp.Header.Get("Link"))["next"]
+	return r
+}
+
+// parseLinks returns the URLs of a Link header by their relation,
+// e.g. `<https://api.github.com/repositories/1/issues?page=2>; rel="next"`.
+func parseLinks(header string) map[string]string {
+	links := map[string]string{}
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "rel=") {
+				for _, rel := range strings.Fields(strings.Trim(param[len("rel="):], `"`)) {
+					links[rel] = target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return links
+}
+
+// APIErrorDetail is an item of the errors of an APIError, e.g. a validation error of a field.
+type APIErrorDetail struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Some endpoints report the errors as strings.
+func (d *APIErrorDetail) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*d = APIErrorDetail{Message: message}
+		return nil
+	}
+	type detail APIErrorDetail
+	return json.Unmarshal(data, (*detail)(d))
+}
+
+// APIError is returned for responses with a status code greater than 299.
+type APIError struct {
+	StatusCode       int              `json:"-"`
+	Message          string           `json:"message"`
+	DocumentationURL string           `json:"documentation_url,omitempty"`
+	Errors           []APIErrorDetail `json:"errors,omitempty"`
+
+	// Response is the response of the failed request
+	Response *Response `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = fmt.Sprintf("Failed request: (%d)", e.StatusCode)
+	}
+	var details []string
+	for _, detail := range e.Errors {
+		switch {
+		case detail.Message != "":
+			details = append(details, detail.Message)
+		case detail.Field != "":
+			details = append(details, fmt.Sprintf("%s %s %s", detail.Resource, detail.Field, detail.Code))
+		}
+	}
+	if len(details) > 0 {
+		message += ": " + strings.Join(details, ", ")
+	}
+	if e.DocumentationURL != "" {
+		message += " - " + e.DocumentationURL
+	}
+	return message
+}
+
+// RateLimitError is returned when a request hits a rate limit which does not reset within
+// MaxRateLimitWait, or keeps hitting it after MaxRetries retries.
+type RateLimitError struct {
+	*APIError
+
+	// Secondary is true for the secondary rate limits, which apply to bursts of requests
+	Secondary bool
+
+	// RetryAfter is the wait before the rate limit resets
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.APIError.Error(), e.RetryAfter)
+}
+
+// Unwrap returns the APIError of the response.
+func (e *RateLimitError) Unwrap() error {
+	return e.APIError
+}
+
+// Do sends a request to the path of the REST API, e.g. "/repos/owner/repo/issues", or to an
+// absolute URL. body is sent as JSON unless it is nil, and the JSON response is decoded into
+// out unless it is nil. The token is only sent to the hosts of the APIs and to TokenHosts.
+func (c *Client) Do(ctx context.Context, method string, path string, body interface{}, out interface{}) (*Response, error) {
+	requestURL := path
+	authorized := true
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		requestURL = c.baseURL + path
+	} else {
+		authorized = c.tokenHosts[urlHost(path)]
+	}
+	var data []byte
+	headers := http.Header{}
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+		headers.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	for attempt := 0; ; attempt++ {
+		// The token is renewed before it expires, e.g. while waiting for a rate limit to reset
+		if c.token != nil && authorized {
+			token, err := c.token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			headers.Set("Authorization", "Bearer "+token)
+		}
+		var reader io.Reader
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		resp, err := c.client.Request(ctx, method, requestURL, reader, headers)
+		if err != nil {
+			if ctx.Err() != nil || !isIdempotent(method) || attempt >= c.maxRetries {
+				return nil, err
+			}
+			if err := sleep(ctx, getRetryDelay(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		response := newResponse(resp)
+		if resp.StatusCode < 300 {
+			return response, decodeResponse(resp, out)
+		}
+		apiErr := readAPIError(resp, response)
+		delay, err := c.checkRetry(method, apiErr, attempt)
+		if err != nil {
+			return response, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return response, err
+		}
+	}
+}
+
+// checkRetry returns the wait before retrying the failed request, or the error to return.
+func (c *Client) checkRetry(method string, apiErr *APIError, attempt int) (time.Duration, error) {
+	resp := apiErr.Response
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		rateLimitErr := &RateLimitError{APIError: apiErr}
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			rateLimitErr.Secondary = true
+			rateLimitErr.RetryAfter = parseRetryAfter(retryAfter)
+		} else if resp.Header.Get("X-RateLimit-Remaining") == "0" && !resp.Rate.Reset.IsZero() {
+			// The reset time has a precision of a second
+			rateLimitErr.RetryAfter = resp.Rate.Reset.Sub(now()) + time.Second
+			if rateLimitErr.RetryAfter < 0 {
+				rateLimitErr.RetryAfter = 0
+			}
+		} else if strings.Contains(strings.ToLower(apiErr.Message), "secondary rate limit") {
+			rateLimitErr.Secondary = true
+			rateLimitErr.RetryAfter = secondaryRateLimitWait
+		} else {
+			return 0, apiErr
+		}
+
+		// Requests hitting a rate limit are not processed and can be retried whatever the method
+		if attempt >= c.maxRetries || rateLimitErr.RetryAfter > c.maxRateLimitWait {
+			return 0, rateLimitErr
+		}
+		return rateLimitErr.RetryAfter, nil
+	}
+
+	if resp.StatusCode >= 500 && isIdempotent(method) && attempt < c.maxRetries {
+		return getRetryDelay(attempt), nil
+	}
+	return 0, apiErr
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date, falling back to
+// secondaryRateLimitWait when it is not valid.
+func parseRetryAfter(retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		if wait := date.Sub(now()); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return secondaryRateLimitWait
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func getRetryDelay(attempt int) time.Duration {
+	return retryDelay * time.Duration(math.Pow(2, float64(attempt)))
+}
+
+// decodeResponse decodes the JSON body of the response into out and closes the body.
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
+
+// readAPIError reads the error of the JSON body of the response and closes the body.
+func readAPIError(resp *http.Response, response *Response) *APIError {
+	defer resp.Body.Close()
+	apiErr := &APIError{}
+	contents, err := io.ReadAll(resp.Body)
+	if err == nil && json.Unmarshal(contents, apiErr) != nil {
+		apiErr = &APIError{Message: strings.TrimSpace(string(contents))}
+	}
+	apiErr.StatusCode = resp.StatusCode
+	apiErr.Response = response
+	return apiErr
+}
+
+// Pages iterates over the pages of a list, following the next links of the responses.
+//
+//	pages := client.Paginate("/repos/owner/repo/issues?per_page=100")
+//	for {
+//		var issues []github.IssueDetails
+//		if !pages.Next(ctx, &issues) {
+//			break
+//		}
+//		...
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type Pages struct {
+	client   *Client
+	next     string
+	response *Response
+	err      error
+}
+
+// Paginate returns an iterator over the pages of the list at the path of the REST API.
+func (c *Client) Paginate(path string) *Pages {
+	return &Pages{client: c, next: path}
+}
+
+// Next decodes the next page into out, which is usually a pointer to a slice. It returns
+// false after the last page or when the request fails.
+func (p *Pages) Next(ctx context.Context, out interface{}) bool {
+	if p.next == "" || p.err != nil {
+		return false
+	}
+	p.response, p.err = p.client.Do(ctx, http.MethodGet, p.next, nil, out)
+	if p.err != nil {
+		return false
+	}
+	p.next = p.response.NextURL
+	return true
+}
+
+// Response returns the response of the last page.
+func (p *Pages) Response() *Response {
+	return p.response
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (p *Pages) Err() error {
+	return p.err
+}
@@ -0,0 +1,390 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// recordSleeps replaces sleep with a function recording the waits, and stops the clock at
+// 1700000000 in Unix time, for the duration of the test.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	originalSleep, originalNow := sleep, now
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	now = func() time.Time { return time.Unix(1700000000, 0) }
+	t.Cleanup(func() { sleep, now = originalSleep, originalNow })
+	return &waits
+}
+
+// response is a canned response of a test server.
+type response struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// newSequenceServer returns a server replying with the responses in order, and the number of
+// requests it received.
+func newSequenceServer(t *testing.T, responses []response) (*httptest.Server, *int) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count >= len(responses) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp := responses[count]
+		count++
+		for key, value := range resp.header {
+			w.Header().Set(key, value)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &count
+}
+
+func Test_NewClient(t *testing.T) {
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		w.Write([]byte(`{"login": "octocat"}`))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL+"/api/v3/")
+
+	table := []struct {
+		name     string
+		token    string
+		options  *ClientOptions
+		expected http.Header
+	}{
+		{
+			name:  "authenticated",
+			token: "secret",
+			expected: http.Header{
+				"Authorization":        {"Bearer secret"},
+				"Accept":               {"application/vnd.github+json"},
+				"X-Github-Api-Version": {"2022-11-28"},
+				"User-Agent":           {"actions/github"},
+			},
+		},
+		{
+			name:    "anonymous",
+			options: &ClientOptions{UserAgent: ptr.String("my-action")},
+			expected: http.Header{
+				"Accept":               {"application/vnd.github+json"},
+				"X-Github-Api-Version": {"2022-11-28"},
+				"User-Agent":           {"my-action"},
+			},
+		},
+	}
+	for _, tt := range table {
+		c := NewClient(tt.token, tt.options)
+		var user User
+		if _, err := c.Do(context.Background(), http.MethodGet, "/user", nil, &user); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if user.Login != "octocat" || request.URL.Path != "/api/v3/user" {
+			t.Fatalf("expected value is %v, but result was %v %v.\ntest case: %v", "octocat /api/v3/user", user.Login, request.URL.Path, tt.name)
+		}
+		for key := range tt.expected {
+			if actual := request.Header.Get(key); actual != tt.expected.Get(key) {
+				t.Fatalf("expected value is %v, but result was %v.\ntest case: %v %v", tt.expected.Get(key), actual, tt.name, key)
+			}
+		}
+		if _, ok := tt.expected["Authorization"]; !ok && request.Header.Get("Authorization") != "" {
+			t.Fatalf("expected no authorization, but result was %v.\ntest case: %v", request.Header.Get("Authorization"), tt.name)
+		}
+	}
+}
+
+func Test_TokenHosts(t *testing.T) {
+	var authorizations []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	})
+	api := httptest.NewServer(handler)
+	defer api.Close()
+	uploads := httptest.NewServer(handler)
+	defer uploads.Close()
+	foreign := httptest.NewServer(handler)
+	defer foreign.Close()
+
+	table := []struct {
+		name     string
+		options  *ClientOptions
+		path     string
+		expected string
+	}{
+		{name: "path", path: "/user", expected: "Bearer token"},
+		{name: "API URL", path: api.URL + "/user", expected: "Bearer token"},
+		{name: "GraphQL URL", options: &ClientOptions{GraphQLURL: ptr.String(uploads.URL + "/graphql")}, path: uploads.URL + "/graphql", expected: "Bearer token"},
+		{name: "token host", options: &ClientOptions{TokenHosts: []string{strings.TrimPrefix(uploads.URL, "http://")}}, path: uploads.URL + "/upload", expected: "Bearer token"},
+		// The token does not leak to other hosts, e.g. through the next links of a list
+		{name: "foreign host", path: foreign.URL + "/issues?page=2"},
+	}
+	for _, tt := range table {
+		authorizations = nil
+		options := tt.options
+		if options == nil {
+			options = &ClientOptions{}
+		}
+		options.BaseURL = ptr.String(api.URL)
+		if _, err := NewClient("token", options).Do(context.Background(), http.MethodGet, tt.path, nil, nil); err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if expected := []string{tt.expected}; !reflect.DeepEqual(authorizations, expected) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", expected, authorizations, tt.name)
+		}
+	}
+
+	// The uploads of github.com are authorized by default
+	c := NewClient("token", &ClientOptions{BaseURL: ptr.String("https://api.github.com"), GraphQLURL: ptr.String("https://api.github.com/graphql")})
+	if expected := map[string]bool{"api.github.com": true, "uploads.github.com": true}; !reflect.DeepEqual(c.tokenHosts, expected) {
+		t.Fatalf("unexpected token hosts: %v", c.tokenHosts)
+	}
+}
+
+func Test_Retries(t *testing.T) {
+	reset := time.Unix(1700000000, 0).Add(10 * time.Second).Unix()
+	maxRateLimitWait := 5 * time.Second
+	table := []struct {
+		name      string
+		method    string
+		options   *ClientOptions
+		responses []response
+		waits     []time.Duration
+		err       string
+		rateLimit bool
+	}{
+		{
+			name:   "primary rate limit",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 403, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)}, body: `{"message": "API rate limit exceeded"}`},
+				{status: 201, body: `{}`},
+			},
+			waits: []time.Duration{11 * time.Second},
+		},
+		{
+			name:   "secondary rate limit with retry-after",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 403, header: map[string]string{"Retry-After": "30"}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+				{status: 429, header: map[string]string{"Retry-After": "5"}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+				{status: 201, body: `{}`},
+			},
+			waits: []time.Duration{30 * time.Second, 5 * time.Second},
+		},
+		{
+			name:   "secondary rate limit with a retry-after date",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 403, header: map[string]string{"Retry-After": time.Unix(1700000000, 0).Add(20 * time.Second).UTC().Format(http.TimeFormat)}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+				{status: 429, header: map[string]string{"Retry-After": "soon"}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+				{status: 201, body: `{}`},
+			},
+			waits: []time.Duration{20 * time.Second, time.Minute},
+		},
+		{
+			name:   "secondary rate limit without retry-after",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 403, body: `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`},
+				{status: 200, body: `{}`},
+			},
+			waits: []time.Duration{time.Minute},
+		},
+		{
+			name:    "rate limit resetting too late",
+			method:  http.MethodGet,
+			options: &ClientOptions{MaxRateLimitWait: &maxRateLimitWait},
+			responses: []response{
+				{status: 403, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)}, body: `{"message": "API rate limit exceeded"}`},
+			},
+			err:       "API rate limit exceeded (retry after ",
+			rateLimit: true,
+		},
+		{
+			name:    "rate limit retries exhausted",
+			method:  http.MethodGet,
+			options: &ClientOptions{MaxRetries: ptr.Int(1)},
+			responses: []response{
+				{status: 429, header: map[string]string{"Retry-After": "1"}, body: `{"message": "secondary rate limit"}`},
+				{status: 429, header: map[string]string{"Retry-After": "1"}, body: `{"message": "secondary rate limit"}`},
+			},
+			waits:     []time.Duration{time.Second},
+			err:       "secondary rate limit (retry after 1s)",
+			rateLimit: true,
+		},
+		{
+			name:   "forbidden",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 403, header: map[string]string{"X-RateLimit-Remaining": "4999"}, body: `{"message": "Resource not accessible by integration", "documentation_url": "https://docs.github.com/rest"}`},
+			},
+			err: "Resource not accessible by integration - https://docs.github.com/rest",
+		},
+		{
+			name:   "server errors of idempotent requests",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 502, body: `bad gateway`},
+				{status: 500, body: `{"message": "Server Error"}`},
+				{status: 200, body: `{}`},
+			},
+			waits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:   "server errors retries exhausted",
+			method: http.MethodDelete,
+			responses: []response{
+				{status: 503}, {status: 503}, {status: 503}, {status: 503},
+			},
+			waits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+			err:   "Failed request: (503)",
+		},
+		{
+			name:   "server errors of other requests",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 502, body: `bad gateway`},
+			},
+			err: "bad gateway",
+		},
+	}
+	for _, tt := range table {
+		waits := recordSleeps(t)
+		server, count := newSequenceServer(t, tt.responses)
+		options := tt.options
+		if options == nil {
+			options = &ClientOptions{}
+		}
+		options.BaseURL = ptr.String(server.URL)
+
+		_, err := NewClient("token", options).Do(context.Background(), tt.method, "/repos/owner/repo/issues", nil, nil)
+		if tt.err == "" && err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+		}
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) != tt.rateLimit {
+			t.Fatalf("expected value is %v, but result was %T.\ntest case: %v", tt.rateLimit, err, tt.name)
+		}
+		var apiErr *APIError
+		if err != nil && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.responses[len(tt.responses)-1].status) {
+			t.Fatalf("expected value is %v, but result was %#v.\ntest case: %v", tt.responses[len(tt.responses)-1].status, err, tt.name)
+		}
+		if *count != len(tt.responses) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", len(tt.responses), *count, tt.name)
+		}
+		if !reflect.DeepEqual(*waits, tt.waits) && (len(*waits) != 0 || len(tt.waits) != 0) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.waits, *waits, tt.name)
+		}
+	}
+}
+
+func Test_Paginate(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			next := fmt.Sprintf("%s/repos/owner/repo/issues?per_page=2&page=%d", server.URL, page+1)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s/repos/owner/repo/issues?per_page=2&page=3>; rel="last"`, next, server.URL))
+		}
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-page))
+		fmt.Fprintf(w, `[{"number": %d}, {"number": %d}]`, page*2-1, page*2)
+	}))
+	defer server.Close()
+
+	c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+	pages := c.Paginate("/repos/owner/repo/issues?per_page=2")
+	var numbers []int
+	for {
+		var issues []IssueDetails
+		if !pages.Next(context.Background(), &issues) {
+			break
+		}
+		for _, issue := range issues {
+			numbers = append(numbers, issue.Number)
+		}
+	}
+	if err := pages.Err(); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(numbers, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, numbers)
+	}
+	if pages.Response().Rate.Remaining != 4997 || pages.Response().NextURL != "" {
+		t.Fatalf("expected value is %v, but result was %+v.", 4997, pages.Response())
+	}
+
+	// The iteration stops on errors
+	recordSleeps(t)
+	pages = c.Paginate("http://127.0.0.1:0/issues")
+	var issues []IssueDetails
+	if pages.Next(context.Background(), &issues) || pages.Err() == nil {
+		t.Fatalf("expected an error, but result was %v.", pages.Err())
+	}
+}
+
+func Test_ParseLinks(t *testing.T) {
+	table := []struct {
+		header   string
+		expected map[string]string
+	}{
+		{header: "", expected: map[string]string{}},
+		{
+			header:   `<https://api.github.com/repositories/1/issues?page=2>; rel="next", <https://api.github.com/repositories/1/issues?page=5>; rel="last"`,
+			expected: map[string]string{"next": "https://api.github.com/repositories/1/issues?page=2", "last": "https://api.github.com/repositories/1/issues?page=5"},
+		},
+		{
+			header:   `<https://api.github.com/issues?page=1>;rel="prev first"`,
+			expected: map[string]string{"prev": "https://api.github.com/issues?page=1", "first": "https://api.github.com/issues?page=1"},
+		},
+		{header: `https://api.github.com/issues?page=1; rel="next"`, expected: map[string]string{}},
+	}
+	for _, tt := range table {
+		if actual := parseLinks(tt.header); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.header)
+		}
+	}
+}
+
+func Test_APIError(t *testing.T) {
+	server, _ := newSequenceServer(t, []response{
+		{status: 422, body: `{"message": "Validation Failed", "errors": [{"resource": "Issue", "field": "title", "code": "missing_field"}, "Label does not exist"], "documentation_url": "https://docs.github.com/rest/issues"}`},
+	})
+	c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+	_, err := c.Do(context.Background(), http.MethodPost, "/repos/owner/repo/issues", map[string]string{}, nil)
+	expected := "Validation Failed: Issue title missing_field, Label does not exist - https://docs.github.com/rest/issues"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected value is %q, but result was %v.", expected, err)
+	}
+	apiErr := err.(*APIError)
+	if apiErr.StatusCode != 422 || len(apiErr.Errors) != 2 || apiErr.Errors[0].Field != "title" || apiErr.Response.StatusCode != 422 {
+		t.Fatalf("unexpected error: %#v", apiErr)
+	}
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// maxCommentLength is the maximum number of characters of the body of a comment.
+const maxCommentLength = 65536
+
+const minimizeCommentMutation = `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// UpsertCommentOptions are the options of UpsertComment.
+type UpsertCommentOptions struct {
+	/** Optional. Whether an existing comment is deleted and created again at the end of the conversation instead of being edited. Defaults to false */
+	Recreate *bool
+
+	/** Optional. Whether a recreated comment is minimized instead of being deleted. Defaults to false */
+	Minimize *bool
+
+	/** Optional. The reason of the minimization: ABUSE, DUPLICATE, OFF_TOPIC, OUTDATED, RESOLVED or SPAM. Defaults to OUTDATED */
+	MinimizeReason *string
+
+	/** Optional. The URL linked when the body is truncated. Defaults to the URL of the workflow run, which shows the job summaries */
+	SummaryURL *string
+
+	initOnce sync.Once
+}
+
+func initializeUpsertCommentOptions(options *UpsertCommentOptions) *UpsertCommentOptions {
+	if options == nil {
+		options = &UpsertCommentOptions{}
+	}
+	options.initOnce.Do(options.init)
+	return options
+}
+
+// DO NOT CALL THIS OUTSIDE initializeUpsertCommentOptions
+func (o *UpsertCommentOptions) init() {
+	if o.Recreate == nil {
+		o.Recreate = ptr.Bool(false)
+	}
+	if o.Minimize == nil {
+		o.Minimize = ptr.Bool(false)
+	}
+	if o.MinimizeReason == nil {
+		o.MinimizeReason = ptr.String("OUTDATED")
+	}
+	if o.SummaryURL == nil {
+		o.SummaryURL = ptr.String(runURL())
+	}
+}
+
+// runURL returns the URL of the workflow run, or an empty string outside of a workflow run.
+func runURL() string {
+	repository, runID := os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if repository == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", getEnvOrDefault("GITHUB_SERVER_URL", "https://github.com"), repository, runID)
+}
+
+// UpsertComment creates or updates the comment of an issue or a pull request identified by a
+// marker, so that re-runs update their comment instead of adding new ones. The marker is
+// written as a hidden HTML comment, <!-- marker -->, at the beginning of the body.
+//
+// The body is truncated with a link to the job summary when the comment would exceed the
+// 65536 characters GitHub accepts. options may be nil.
+func (c *Client) UpsertComment(ctx context.Context, owner, repo string, issueNumber int, marker, body string, options *UpsertCommentOptions) (*Comment, error) {
+	options = initializeUpsertCommentOptions(options)
+	hidden := fmt.Sprintf("<!-- %s -->", marker)
+	body = truncateComment(hidden+"\n"+body, *options.SummaryURL)
+
+	comments, err := c.ListIssueComments(ctx, owner, repo, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	// The minimized comments keep their marker, so the last comment with the marker is the
+	// current one
+	var existing *Comment
+	for i := range comments {
+		if strings.Contains(comments[i].Body, hidden) {
+			existing = &comments[i]
+		}
+	}
+
+	if existing == nil {
+		return c.CreateIssueComment(ctx, owner, repo, issueNumber, body)
+	}
+	if !*options.Recreate {
+		return c.UpdateIssueComment(ctx, owner, repo, existing.ID, body)
+	}
+
+	comment, err := c.CreateIssueComment(ctx, owner, repo, issueNumber, body)
+	if err != nil {
+		return nil, err
+	}
+	if *options.Minimize {
+		err = c.MinimizeComment(ctx, existing.NodeID, *options.MinimizeReason)
+	} else {
+		err = c.DeleteIssueComment(ctx, owner, repo, existing.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// MinimizeComment hides a comment, given its node ID, for a reason: ABUSE, DUPLICATE,
+// OFF_TOPIC, OUTDATED, RESOLVED or SPAM.
+func (c *Client) MinimizeComment(ctx context.Context, nodeID string, reason string) error {
+	_, err := c.GraphQL(ctx, minimizeCommentMutation, map[string]interface{}{"id": nodeID, "classifier": reason}, nil)
+	return err
+}
+
+// truncateComment truncates the body of a comment to the maximum length of the comments,
+// and links to the summary from the end of the truncated body.
+func truncateComment(body string, summaryURL string) string {
+	if utf8.RuneCountInString(body) <= maxCommentLength {
+		return body
+	}
+	notice := "\n\n---\n:warning: The comment is truncated because it exceeds the maximum length of the comments."
+	if summaryURL != "" {
+		notice += fmt.Sprintf(" See the [job summary](%s) for the whole content.", summaryURL)
+	}
+	keep := maxCommentLength - utf8.RuneCountInString(notice)
+	for i := range body {
+		if keep == 0 {
+			return body[:i] + notice
+		}
+		keep--
+	}
+	return body
+}
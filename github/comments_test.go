@@ -0,0 +1,130 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func Test_UpsertComment(t *testing.T) {
+	existing := `[{"id": 1, "node_id": "IC_1", "body": "first"}, {"id": 2, "node_id": "IC_2", "body": "<!-- report -->\nold"}, {"id": 3, "node_id": "IC_3", "body": "last"}]`
+	table := []struct {
+		name     string
+		comments string
+		options  *UpsertCommentOptions
+		expected []string
+	}{
+		{
+			name:     "create",
+			comments: `[{"id": 1, "node_id": "IC_1", "body": "<!-- other -->"}]`,
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "POST /repos/owner/repo/issues/7/comments <!-- report -->\nnew"},
+		},
+		{
+			name:     "update",
+			comments: existing,
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "PATCH /repos/owner/repo/issues/comments/2 <!-- report -->\nnew"},
+		},
+		{
+			name:     "update the last comment",
+			comments: `[{"id": 1, "node_id": "IC_1", "body": "<!-- report -->\nminimized"}, {"id": 2, "node_id": "IC_2", "body": "<!-- report -->\nold"}]`,
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "PATCH /repos/owner/repo/issues/comments/2 <!-- report -->\nnew"},
+		},
+		{
+			name:     "recreate",
+			comments: existing,
+			options:  &UpsertCommentOptions{Recreate: ptr.Bool(true)},
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "POST /repos/owner/repo/issues/7/comments <!-- report -->\nnew", "DELETE /repos/owner/repo/issues/comments/2 "},
+		},
+		{
+			name:     "recreate and minimize",
+			comments: existing,
+			options:  &UpsertCommentOptions{Recreate: ptr.Bool(true), Minimize: ptr.Bool(true)},
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "POST /repos/owner/repo/issues/7/comments <!-- report -->\nnew", "POST /graphql IC_2 OUTDATED"},
+		},
+		{
+			name:     "minimize for a reason",
+			comments: existing,
+			options:  &UpsertCommentOptions{Recreate: ptr.Bool(true), Minimize: ptr.Bool(true), MinimizeReason: ptr.String("RESOLVED")},
+			expected: []string{"GET /repos/owner/repo/issues/7/comments", "POST /repos/owner/repo/issues/7/comments <!-- report -->\nnew", "POST /graphql IC_2 RESOLVED"},
+		},
+	}
+	for _, tt := range table {
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := r.Method + " " + r.URL.Path
+			switch {
+			case r.URL.Path == "/graphql":
+				var body graphQLRequest
+				json.NewDecoder(r.Body).Decode(&body)
+				request += fmt.Sprintf(" %v %v", body.Variables["id"], body.Variables["classifier"])
+				fmt.Fprint(w, `{"data": {"minimizeComment": {"minimizedComment": {"isMinimized": true}}}}`)
+			case r.Method == http.MethodGet:
+				fmt.Fprint(w, tt.comments)
+			case r.Method == http.MethodDelete:
+				request += " "
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				var body struct{ Body string }
+				json.NewDecoder(r.Body).Decode(&body)
+				request += " " + body.Body
+				fmt.Fprintf(w, `{"id": 4, "body": %q}`, body.Body)
+			}
+			requests = append(requests, request)
+		}))
+
+		c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL), GraphQLURL: ptr.String(server.URL + "/graphql")})
+		comment, err := c.UpsertComment(context.Background(), "owner", "repo", 7, "report", "new", tt.options)
+		server.Close()
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if comment.Body != "<!-- report -->\nnew" {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", "<!-- report -->\nnew", comment.Body, tt.name)
+		}
+		if !reflect.DeepEqual(requests, tt.expected) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, requests, tt.name)
+		}
+	}
+}
+
+func Test_TruncateComment(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	summaryURL := *initializeUpsertCommentOptions(nil).SummaryURL
+	if expected := "https://github.com/owner/repo/actions/runs/42"; summaryURL != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, summaryURL)
+	}
+
+	notice := "\n\n---\n:warning: The comment is truncated because it exceeds the maximum length of the comments."
+	link := " See the [job summary](https://github.com/owner/repo/actions/runs/42) for the whole content."
+	table := []struct {
+		name       string
+		body       string
+		summaryURL string
+		suffix     string
+	}{
+		{name: "short", body: "body", summaryURL: summaryURL, suffix: "body"},
+		{name: "longest", body: strings.Repeat("a", maxCommentLength), summaryURL: summaryURL, suffix: "aaa"},
+		{name: "long", body: strings.Repeat("a", maxCommentLength+1), summaryURL: summaryURL, suffix: "aaa" + notice + link},
+		{name: "multibyte", body: strings.Repeat("é", maxCommentLength+1), summaryURL: summaryURL, suffix: "éé" + notice + link},
+		{name: "no summary", body: strings.Repeat("a", maxCommentLength+1), suffix: "aaa" + notice},
+	}
+	for _, tt := range table {
+		actual := truncateComment(tt.body, tt.summaryURL)
+		if n := utf8.RuneCountInString(actual); n > maxCommentLength || !utf8.ValidString(actual) {
+			t.Fatalf("expected value is at most %v valid characters, but result was %v.\ntest case: %v", maxCommentLength, n, tt.name)
+		}
+		if !strings.HasSuffix(actual, tt.suffix) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.suffix, actual, tt.name)
+		}
+	}
+}
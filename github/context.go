@@ -0,0 +1,165 @@
+// Package github provides the context of the workflow run, like @actions/github.
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The writer of the messages to the runner, overridden by tests.
+var stdout io.Writer = os.Stdout
+
+// PayloadRepository is the repository of a webhook payload.
+type PayloadRepository struct {
+	FullName string `json:"full_name,omitempty"`
+	Name     string `json:"name"`
+	Owner    struct {
+		Login string `json:"login"`
+		Name  string `json:"name,omitempty"`
+	} `json:"owner"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// PayloadIssue is the issue or the pull request of a webhook payload.
+type PayloadIssue struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+// WebhookPayload holds the fields common to the webhook payloads. The whole payload is in Raw.
+type WebhookPayload struct {
+	Repository  *PayloadRepository `json:"repository,omitempty"`
+	Issue       *PayloadIssue      `json:"issue,omitempty"`
+	PullRequest *PayloadIssue      `json:"pull_request,omitempty"`
+	Sender      *struct {
+		Type string `json:"type"`
+	} `json:"sender,omitempty"`
+	Action       string `json:"action,omitempty"`
+	Installation *struct {
+		ID int64 `json:"id"`
+	} `json:"installation,omitempty"`
+	Comment *struct {
+		ID int64 `json:"id"`
+	} `json:"comment,omitempty"`
+
+	// Number is the number of the issue or the pull request of some events, such as pull_request
+	Number int `json:"number,omitempty"`
+
+	// Raw is the payload as read from GITHUB_EVENT_PATH, or nil if there is none
+	Raw json.RawMessage `json:"-"`
+}
+
+// Repo identifies a repository.
+type Repo struct {
+	Owner string
+	Repo  string
+}
+
+// Issue identifies an issue or a pull request.
+type Issue struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// Context is the context of the workflow run, read from the GITHUB_* environment variables
+// and the webhook payload of the event which triggered the workflow.
+type Context struct {
+	// Webhook payload object that triggered the workflow
+	Payload WebhookPayload
+
+	EventName  string
+	Sha        string
+	Ref        string
+	Workflow   string
+	Action     string
+	Actor      string
+	Job        string
+	RunAttempt int
+	RunNumber  int
+	RunID      int64
+	APIURL     string
+	ServerURL  string
+	GraphQLURL string
+}
+
+// NewContext hydrates the context from the environment. A missing GITHUB_EVENT_PATH file
+// results in an empty payload; an invalid one in an error.
+func NewContext() (*Context, error) {
+	c := &Context{}
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		data, err := os.ReadFile(eventPath)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &c.Payload); err != nil {
+				return nil, fmt.Errorf("GITHUB_EVENT_PATH %s is not valid: %w", eventPath, err)
+			}
+			c.Payload.Raw = data
+		case errors.Is(err, os.ErrNotExist):
+			fmt.Fprintf(stdout, "GITHUB_EVENT_PATH %s does not exist\n", eventPath)
+		default:
+			return nil, err
+		}
+	}
+
+	c.EventName = os.Getenv("GITHUB_EVENT_NAME")
+	c.Sha = os.Getenv("GITHUB_SHA")
+	c.Ref = os.Getenv("GITHUB_REF")
+	c.Workflow = os.Getenv("GITHUB_WORKFLOW")
+	c.Action = os.Getenv("GITHUB_ACTION")
+	c.Actor = os.Getenv("GITHUB_ACTOR")
+	c.Job = os.Getenv("GITHUB_JOB")
+	c.RunAttempt, _ = strconv.Atoi(os.Getenv("GITHUB_RUN_ATTEMPT"))
+	c.RunNumber, _ = strconv.Atoi(os.Getenv("GITHUB_RUN_NUMBER"))
+	c.RunID, _ = strconv.ParseInt(os.Getenv("GITHUB_RUN_ID"), 10, 64)
+	c.APIURL = getEnvOrDefault("GITHUB_API_URL", "https://api.github.com")
+	c.ServerURL = getEnvOrDefault("GITHUB_SERVER_URL", "https://github.com")
+	c.GraphQLURL = getEnvOrDefault("GITHUB_GRAPHQL_URL", "https://api.github.com/graphql")
+	return c, nil
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Repo returns the repository of the workflow run, from GITHUB_REPOSITORY or else the payload.
+func (c *Context) Repo() (Repo, error) {
+	if repository := os.Getenv("GITHUB_REPOSITORY"); repository != "" {
+		parts := strings.SplitN(repository, "/", 2)
+		repo := Repo{Owner: parts[0]}
+		if len(parts) == 2 {
+			repo.Repo = parts[1]
+		}
+		return repo, nil
+	}
+	if c.Payload.Repository != nil {
+		return Repo{Owner: c.Payload.Repository.Owner.Login, Repo: c.Payload.Repository.Name}, nil
+	}
+	return Repo{}, errors.New("context.repo requires a GITHUB_REPOSITORY environment variable like 'owner/repo'")
+}
+
+// Issue returns the issue or the pull request of the event, e.g. for issues, issue_comment
+// and pull_request events. Its number is 0 for other events.
+func (c *Context) Issue() (Issue, error) {
+	repo, err := c.Repo()
+	if err != nil {
+		return Issue{}, err
+	}
+	number := c.Payload.Number
+	switch {
+	case c.Payload.Issue != nil:
+		number = c.Payload.Issue.Number
+	case c.Payload.PullRequest != nil:
+		number = c.Payload.PullRequest.Number
+	}
+	return Issue{Owner: repo.Owner, Repo: repo.Repo, Number: number}, nil
+}
@@ -0,0 +1,214 @@
+package github
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type env struct {
+	key string
+	val string
+}
+
+func setEnvVars(t *testing.T, vars []env) {
+	for _, kv := range vars {
+		t.Setenv(kv.key, kv.val)
+	}
+}
+
+// captureStdout replaces stdout with a buffer for the duration of the test.
+func captureStdout(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	original := stdout
+	stdout = &buf
+	t.Cleanup(func() { stdout = original })
+	return &buf
+}
+
+// writePayload writes the event payload to a temporary file and returns its path.
+func writePayload(t *testing.T, payload string) string {
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(path, []byte(payload), 0o644); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return path
+}
+
+func Test_NewContext(t *testing.T) {
+	setEnvVars(t, []env{
+		{key: "GITHUB_EVENT_PATH", val: filepath.Join("testdata", "context", "payload.json")},
+		{key: "GITHUB_EVENT_NAME", val: "pull_request"},
+		{key: "GITHUB_SHA", val: "ffac537e6cbbf934b08745a378932722df287a53"},
+		{key: "GITHUB_REF", val: "refs/pull/1/merge"},
+		{key: "GITHUB_WORKFLOW", val: "CI"},
+		{key: "GITHUB_ACTION", val: "__run"},
+		{key: "GITHUB_ACTOR", val: "monalisa"},
+		{key: "GITHUB_JOB", val: "test"},
+		{key: "GITHUB_RUN_ATTEMPT", val: "2"},
+		{key: "GITHUB_RUN_NUMBER", val: "7"},
+		{key: "GITHUB_RUN_ID", val: "5000000000"},
+		{key: "GITHUB_API_URL", val: "https://ghes.example.com/api/v3"},
+		{key: "GITHUB_SERVER_URL", val: "https://ghes.example.com"},
+		{key: "GITHUB_GRAPHQL_URL", val: "https://ghes.example.com/api/graphql"},
+		{key: "GITHUB_REPOSITORY", val: "owner/repo"},
+	})
+
+	c, err := NewContext()
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if c.Payload.Raw == nil {
+		t.Fatal("expected the raw payload to be set")
+	}
+	c.Payload.Raw = nil
+
+	expected := &Context{
+		EventName:  "pull_request",
+		Sha:        "ffac537e6cbbf934b08745a378932722df287a53",
+		Ref:        "refs/pull/1/merge",
+		Workflow:   "CI",
+		Action:     "__run",
+		Actor:      "monalisa",
+		Job:        "test",
+		RunAttempt: 2,
+		RunNumber:  7,
+		RunID:      5000000000,
+		APIURL:     "https://ghes.example.com/api/v3",
+		ServerURL:  "https://ghes.example.com",
+		GraphQLURL: "https://ghes.example.com/api/graphql",
+	}
+	expected.Payload.Action = "opened"
+	expected.Payload.Number = 1
+	expected.Payload.PullRequest = &PayloadIssue{Number: 1, HTMLURL: "https://github.com/payload-owner/payload-repo/pull/1", Body: "Description of the change"}
+	expected.Payload.Repository = &PayloadRepository{FullName: "payload-owner/payload-repo", Name: "payload-repo", HTMLURL: "https://github.com/payload-owner/payload-repo"}
+	expected.Payload.Repository.Owner.Login = "payload-owner"
+	expected.Payload.Sender = c.Payload.Sender
+	expected.Payload.Installation = c.Payload.Installation
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, c)
+	}
+	if c.Payload.Sender == nil || c.Payload.Sender.Type != "User" {
+		t.Fatalf("expected value is %v, but result was %+v.", "User", c.Payload.Sender)
+	}
+	if c.Payload.Installation == nil || c.Payload.Installation.ID != 42 {
+		t.Fatalf("expected value is %v, but result was %+v.", 42, c.Payload.Installation)
+	}
+}
+
+func Test_NewContextDefaults(t *testing.T) {
+	buf := captureStdout(t)
+	setEnvVars(t, []env{
+		{key: "GITHUB_API_URL", val: ""},
+		{key: "GITHUB_SERVER_URL", val: ""},
+		{key: "GITHUB_GRAPHQL_URL", val: ""},
+		{key: "GITHUB_EVENT_PATH", val: filepath.Join("testdata", "context", "missing.json")},
+		{key: "GITHUB_RUN_ID", val: ""},
+	})
+
+	c, err := NewContext()
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := "GITHUB_EVENT_PATH " + filepath.Join("testdata", "context", "missing.json") + " does not exist\n"; buf.String() != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, buf.String())
+	}
+	if !reflect.DeepEqual(c.Payload, WebhookPayload{}) {
+		t.Fatalf("expected value is %+v, but result was %+v.", WebhookPayload{}, c.Payload)
+	}
+	table := []struct {
+		name     string
+		actual   interface{}
+		expected interface{}
+	}{
+		{name: "api url", actual: c.APIURL, expected: "https://api.github.com"},
+		{name: "server url", actual: c.ServerURL, expected: "https://github.com"},
+		{name: "graphql url", actual: c.GraphQLURL, expected: "https://api.github.com/graphql"},
+		{name: "run id", actual: c.RunID, expected: int64(0)},
+	}
+	for _, tt := range table {
+		if tt.actual != tt.expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, tt.actual, tt.name)
+		}
+	}
+}
+
+func Test_NewContextInvalidPayload(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join("testdata", "context", "invalid.json"))
+	_, err := NewContext()
+	if err == nil || !strings.Contains(err.Error(), "is not valid") {
+		t.Fatalf("expected value is %q, but result was %v.", "is not valid", err)
+	}
+}
+
+func Test_ContextRepoAndIssue(t *testing.T) {
+	payload := func(json string) WebhookPayload {
+		t.Setenv("GITHUB_EVENT_PATH", writePayload(t, json))
+		c, err := NewContext()
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		return c.Payload
+	}
+
+	table := []struct {
+		name       string
+		repository string
+		payload    WebhookPayload
+		expected   Issue
+		err        string
+	}{
+		{
+			name:       "from GITHUB_REPOSITORY",
+			repository: "owner/repo",
+			payload:    payload(`{"repository": {"name": "payload-repo", "owner": {"login": "payload-owner"}}, "issue": {"number": 1}}`),
+			expected:   Issue{Owner: "owner", Repo: "repo", Number: 1},
+		},
+		{
+			name:     "from the payload",
+			payload:  payload(`{"repository": {"name": "payload-repo", "owner": {"login": "payload-owner"}}, "pull_request": {"number": 2}}`),
+			expected: Issue{Owner: "payload-owner", Repo: "payload-repo", Number: 2},
+		},
+		{
+			name:       "from the number of the payload",
+			repository: "owner/repo",
+			payload:    payload(`{"number": 3}`),
+			expected:   Issue{Owner: "owner", Repo: "repo", Number: 3},
+		},
+		{
+			name:       "without a number",
+			repository: "owner/repo",
+			payload:    payload(`{}`),
+			expected:   Issue{Owner: "owner", Repo: "repo"},
+		},
+		{
+			name:    "without a repository",
+			payload: payload(`{}`),
+			err:     "context.repo requires a GITHUB_REPOSITORY environment variable like 'owner/repo'",
+		},
+	}
+	for _, tt := range table {
+		t.Setenv("GITHUB_REPOSITORY", tt.repository)
+		c := &Context{Payload: tt.payload}
+		issue, err := c.Issue()
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if issue != tt.expected {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, issue, tt.name)
+		}
+		repo, _ := c.Repo()
+		if repo != (Repo{Owner: tt.expected.Owner, Repo: tt.expected.Repo}) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, repo, tt.name)
+		}
+	}
+}
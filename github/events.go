@@ -0,0 +1,441 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ci-tools/toolkit/core"
+)
+
+// User is a user or an organization of a webhook payload.
+type User struct {
+	Login   string `json:"login"`
+	ID      int64  `json:"id"`
+	NodeID  string `json:"node_id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// Repository is the repository of a webhook payload.
+type Repository struct {
+	ID            int64  `json:"id"`
+	NodeID        string `json:"node_id,omitempty"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Private       bool   `json:"private"`
+	Fork          bool   `json:"fork"`
+	Owner         User   `json:"owner"`
+	HTMLURL       string `json:"html_url,omitempty"`
+	CloneURL      string `json:"clone_url,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+// Label is a label of an issue or a pull request.
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// CommitAuthor is the author or the committer of a commit of a push.
+type CommitAuthor struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+// Commit is a commit of a push.
+type Commit struct {
+	ID        string       `json:"id"`
+	TreeID    string       `json:"tree_id"`
+	Distinct  bool         `json:"distinct"`
+	Message   string       `json:"message"`
+	Timestamp time.Time    `json:"timestamp"`
+	URL       string       `json:"url"`
+	Author    CommitAuthor `json:"author"`
+	Committer CommitAuthor `json:"committer"`
+	Added     []string     `json:"added"`
+	Removed   []string     `json:"removed"`
+	Modified  []string     `json:"modified"`
+}
+
+// PullRequestBranch is the head or the base branch of a pull request.
+type PullRequestBranch struct {
+	Label string      `json:"label"`
+	Ref   string      `json:"ref"`
+	SHA   string      `json:"sha"`
+	User  User        `json:"user"`
+	Repo  *Repository `json:"repo"`
+}
+
+// PullRequest is the pull request of a webhook payload.
+type PullRequest struct {
+	ID             int64             `json:"id"`
+	NodeID         string            `json:"node_id,omitempty"`
+	Number         int               `json:"number"`
+	State          string            `json:"state"`
+	Locked         bool              `json:"locked"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body"`
+	User           User              `json:"user"`
+	Labels         []Label           `json:"labels"`
+	Draft          bool              `json:"draft"`
+	Merged         bool              `json:"merged"`
+	MergeCommitSHA string            `json:"merge_commit_sha"`
+	Head           PullRequestBranch `json:"head"`
+	Base           PullRequestBranch `json:"base"`
+	HTMLURL        string            `json:"html_url"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	ClosedAt       *time.Time        `json:"closed_at"`
+	MergedAt       *time.Time        `json:"merged_at"`
+}
+
+// IssueDetails is the issue of a webhook payload. PullRequest is set when the issue is a pull request.
+type IssueDetails struct {
+	ID          int64      `json:"id"`
+	NodeID      string     `json:"node_id,omitempty"`
+	Number      int        `json:"number"`
+	State       string     `json:"state"`
+	Locked      bool       `json:"locked"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	User        User       `json:"user"`
+	Labels      []Label    `json:"labels"`
+	HTMLURL     string     `json:"html_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	PullRequest *struct {
+		URL     string `json:"url"`
+		HTMLURL string `json:"html_url"`
+	} `json:"pull_request,omitempty"`
+}
+
+// Comment is a comment on an issue or a pull request.
+type Comment struct {
+	ID                int64     `json:"id"`
+	NodeID            string    `json:"node_id,omitempty"`
+	Body              string    `json:"body"`
+	User              User      `json:"user"`
+	AuthorAssociation string    `json:"author_association"`
+	HTMLURL           string    `json:"html_url"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Release is the release of a webhook payload.
+type Release struct {
+	ID              int64      `json:"id"`
+	NodeID          string     `json:"node_id,omitempty"`
+	TagName         string     `json:"tag_name"`
+	TargetCommitish string     `json:"target_commitish"`
+	Name            string     `json:"name"`
+	Body            string     `json:"body"`
+	Draft           bool       `json:"draft"`
+	Prerelease      bool       `json:"prerelease"`
+	Author          User       `json:"author"`
+	HTMLURL         string     `json:"html_url"`
+	UploadURL       string     `json:"upload_url"`
+	CreatedAt       time.Time  `json:"created_at"`
+	PublishedAt     *time.Time `json:"published_at"`
+}
+
+// Workflow is the workflow of a workflow_run event.
+type Workflow struct {
+	ID      int64  `json:"id"`
+	NodeID  string `json:"node_id,omitempty"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// WorkflowRun is the workflow run of a workflow_run event.
+type WorkflowRun struct {
+	ID           int64  `json:"id"`
+	NodeID       string `json:"node_id,omitempty"`
+	Name         string `json:"name"`
+	WorkflowID   int64  `json:"workflow_id"`
+	HeadBranch   string `json:"head_branch"`
+	HeadSHA      string `json:"head_sha"`
+	Path         string `json:"path"`
+	RunNumber    int    `json:"run_number"`
+	RunAttempt   int    `json:"run_attempt"`
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Conclusion   string `json:"conclusion"`
+	Actor        User   `json:"actor"`
+	PullRequests []struct {
+		ID     int64 `json:"id"`
+		Number int   `json:"number"`
+		Head   struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_requests"`
+	HeadRepository *Repository `json:"head_repository"`
+	Repository     *Repository `json:"repository"`
+	HTMLURL        string      `json:"html_url"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// MergeGroup is the merge group of a merge_group event.
+type MergeGroup struct {
+	HeadSHA    string  `json:"head_sha"`
+	HeadRef    string  `json:"head_ref"`
+	BaseSHA    string  `json:"base_sha"`
+	BaseRef    string  `json:"base_ref"`
+	HeadCommit *Commit `json:"head_commit"`
+}
+
+// Deployment is the deployment of a deployment event.
+type Deployment struct {
+	ID                    int64           `json:"id"`
+	NodeID                string          `json:"node_id,omitempty"`
+	SHA                   string          `json:"sha"`
+	Ref                   string          `json:"ref"`
+	Task                  string          `json:"task"`
+	Environment           string          `json:"environment"`
+	OriginalEnvironment   string          `json:"original_environment"`
+	Description           string          `json:"description"`
+	TransientEnvironment  bool            `json:"transient_environment"`
+	ProductionEnvironment bool            `json:"production_environment"`
+	Payload               json.RawMessage `json:"payload"`
+	Creator               User            `json:"creator"`
+	CreatedAt             time.Time       `json:"created_at"`
+	UpdatedAt             time.Time       `json:"updated_at"`
+}
+
+// PushEvent is the payload of push events.
+type PushEvent struct {
+	Ref        string   `json:"ref"`
+	Before     string   `json:"before"`
+	After      string   `json:"after"`
+	Created    bool     `json:"created"`
+	Deleted    bool     `json:"deleted"`
+	Forced     bool     `json:"forced"`
+	BaseRef    *string  `json:"base_ref"`
+	Compare    string   `json:"compare"`
+	Commits    []Commit `json:"commits"`
+	HeadCommit *Commit  `json:"head_commit"`
+	Pusher     struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"pusher"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// PullRequestEvent is the payload of pull_request and pull_request_target events.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	// Label is the label added or removed for the labeled and unlabeled actions
+	Label      *Label     `json:"label,omitempty"`
+	Before     string     `json:"before,omitempty"`
+	After      string     `json:"after,omitempty"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// IssuesEvent is the payload of issues events.
+type IssuesEvent struct {
+	Action string       `json:"action"`
+	Issue  IssueDetails `json:"issue"`
+	// Label is the label added or removed for the labeled and unlabeled actions
+	Label      *Label     `json:"label,omitempty"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// IssueCommentEvent is the payload of issue_comment events, on issues and pull requests.
+type IssueCommentEvent struct {
+	Action     string       `json:"action"`
+	Issue      IssueDetails `json:"issue"`
+	Comment    Comment      `json:"comment"`
+	Repository Repository   `json:"repository"`
+	Sender     User         `json:"sender"`
+}
+
+// ReleaseEvent is the payload of release events.
+type ReleaseEvent struct {
+	Action     string     `json:"action"`
+	Release    Release    `json:"release"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// WorkflowDispatchEvent is the payload of workflow_dispatch events.
+type WorkflowDispatchEvent struct {
+	Inputs     map[string]WorkflowInput `json:"inputs"`
+	Ref        string                   `json:"ref"`
+	Workflow   string                   `json:"workflow"`
+	Repository Repository               `json:"repository"`
+	Sender     User                     `json:"sender"`
+}
+
+// WorkflowRunEvent is the payload of workflow_run events.
+type WorkflowRunEvent struct {
+	Action      string      `json:"action"`
+	Workflow    Workflow    `json:"workflow"`
+	WorkflowRun WorkflowRun `json:"workflow_run"`
+	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+}
+
+// ScheduleEvent is the payload of schedule events.
+type ScheduleEvent struct {
+	// Schedule is the cron expression which triggered the workflow
+	Schedule   string     `json:"schedule"`
+	Repository Repository `json:"repository"`
+	Sender     *User      `json:"sender,omitempty"`
+}
+
+// MergeGroupEvent is the payload of merge_group events.
+type MergeGroupEvent struct {
+	Action     string     `json:"action"`
+	MergeGroup MergeGroup `json:"merge_group"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// DeploymentEvent is the payload of deployment events.
+type DeploymentEvent struct {
+	Action      string       `json:"action"`
+	Deployment  Deployment   `json:"deployment"`
+	Workflow    *Workflow    `json:"workflow,omitempty"`
+	WorkflowRun *WorkflowRun `json:"workflow_run,omitempty"`
+	Repository  Repository   `json:"repository"`
+	Sender      User         `json:"sender"`
+}
+
+// WorkflowInput is an input of a workflow_dispatch event. Inputs of the boolean and number
+// types are JSON booleans and numbers in the payload, the others are strings.
+type WorkflowInput struct {
+	value interface{}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *WorkflowInput) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &i.value)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i WorkflowInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.value)
+}
+
+// String returns the value of the input as it is available to the workflow in `inputs.<name>`.
+func (i WorkflowInput) String() string {
+	switch v := i.value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// Bool returns the value of a boolean input. The string values of the YAML 1.2 "Core Schema"
+// specification are accepted too, like in core.GetBooleanInput.
+func (i WorkflowInput) Bool() (bool, error) {
+	if v, ok := i.value.(bool); ok {
+		return v, nil
+	}
+	return core.ParseBoolean(i.String())
+}
+
+// Number returns the value of a number input. Numeric strings are accepted too.
+func (i WorkflowInput) Number() (float64, error) {
+	switch v := i.value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("Input is not a number: %s", i.String())
+}
+
+// UnsupportedEventError is returned by Context.Event and ParseEvent for events without a
+// typed payload.
+type UnsupportedEventError struct {
+	EventName string
+}
+
+func (e *UnsupportedEventError) Error() string {
+	return fmt.Sprintf("the payload of %q events is not supported, use Context.Payload instead", e.EventName)
+}
+
+// Event decodes the webhook payload into the type of the event in GITHUB_EVENT_NAME, e.g. a
+// *PushEvent for push events or a *PullRequestEvent for pull_request and pull_request_target
+// events. It returns an *UnsupportedEventError for the other events.
+func (c *Context) Event() (interface{}, error) {
+	if newEvent(c.EventName) == nil {
+		return nil, &UnsupportedEventError{EventName: c.EventName}
+	}
+	if c.Payload.Raw == nil {
+		return nil, errors.New("the payload of the event is not available, GITHUB_EVENT_PATH is not set or does not exist")
+	}
+	return ParseEvent(c.EventName, c.Payload.Raw)
+}
+
+// ParseEvent decodes a webhook payload into the type of the event, like Context.Event. It
+// returns an *UnsupportedEventError for the events without a typed payload.
+func ParseEvent(eventName string, payload []byte) (interface{}, error) {
+	event := newEvent(eventName)
+	if event == nil {
+		return nil, &UnsupportedEventError{EventName: eventName}
+	}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode the payload of the %s event: %w", eventName, err)
+	}
+	return event, nil
+}
+
+// newEvent returns a pointer to the type of the payload of the event, or nil if the event has
+// no typed payload.
+func newEvent(eventName string) interface{} {
+	switch eventName {
+	case "push":
+		return &PushEvent{}
+	case "pull_request", "pull_request_target":
+		return &PullRequestEvent{}
+	case "issues":
+		return &IssuesEvent{}
+	case "issue_comment":
+		return &IssueCommentEvent{}
+	case "release":
+		return &ReleaseEvent{}
+	case "workflow_dispatch":
+		return &WorkflowDispatchEvent{}
+	case "workflow_run":
+		return &WorkflowRunEvent{}
+	case "schedule":
+		return &ScheduleEvent{}
+	case "merge_group":
+		return &MergeGroupEvent{}
+	case "deployment":
+		return &DeploymentEvent{}
+	default:
+		return nil
+	}
+}
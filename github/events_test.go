@@ -0,0 +1,220 @@
+package github
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ContextEvent(t *testing.T) {
+	table := []struct {
+		eventName string
+		fixture   string
+		get       func(event interface{}) interface{}
+		expected  interface{}
+	}{
+		{
+			eventName: "push",
+			fixture:   "push.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*PushEvent)
+				return []interface{}{e.Ref, e.Created, e.BaseRef, len(e.Commits), e.HeadCommit.Modified, e.HeadCommit.Timestamp.Unix(), e.Pusher.Name, e.Repository.FullName}
+			},
+			expected: []interface{}{"refs/heads/main", true, (*string)(nil), 1, []string{"README.md"}, int64(1705280400), "monalisa", "octocat/Hello-World"},
+		},
+		{
+			eventName: "pull_request",
+			fixture:   "pull_request.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*PullRequestEvent)
+				return []interface{}{e.Action, e.Number, e.Label.Name, e.PullRequest.Head.Ref, e.PullRequest.Head.Repo.Fork, e.PullRequest.Base.SHA, e.PullRequest.MergedAt == nil}
+			},
+			expected: []interface{}{"labeled", 2, "bug", "changes", true, "f95f852bd8fca8fcc58a9a2d6c842781e32a215e", true},
+		},
+		{
+			eventName: "pull_request_target",
+			fixture:   "pull_request.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*PullRequestEvent)
+				return []interface{}{e.PullRequest.Number, e.PullRequest.Labels[0].Color}
+			},
+			expected: []interface{}{2, "f29513"},
+		},
+		{
+			eventName: "issues",
+			fixture:   "issues.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*IssuesEvent)
+				return []interface{}{e.Action, e.Issue.Number, e.Issue.Title, e.Issue.PullRequest == nil, e.Label == nil, e.Sender.Login}
+			},
+			expected: []interface{}{"opened", 1, "Spelling error in the README file", true, true, "monalisa"},
+		},
+		{
+			eventName: "issue_comment",
+			fixture:   "issue_comment.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*IssueCommentEvent)
+				return []interface{}{e.Action, e.Issue.Number, e.Issue.PullRequest.HTMLURL, e.Comment.ID, e.Comment.Body, e.Comment.AuthorAssociation}
+			},
+			expected: []interface{}{"created", 2, "https://github.com/octocat/Hello-World/pull/2", int64(1146825), "/deploy staging", "MEMBER"},
+		},
+		{
+			eventName: "release",
+			fixture:   "release.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*ReleaseEvent)
+				return []interface{}{e.Action, e.Release.TagName, e.Release.Prerelease, e.Release.PublishedAt.Unix(), e.Release.Author.Login}
+			},
+			expected: []interface{}{"published", "v1.0.0", false, int64(1705281000), "monalisa"},
+		},
+		{
+			eventName: "workflow_dispatch",
+			fixture:   "workflow_dispatch.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*WorkflowDispatchEvent)
+				dryRun, _ := e.Inputs["dry-run"].Bool()
+				replicas, _ := e.Inputs["replicas"].Number()
+				return []interface{}{e.Ref, e.Workflow, len(e.Inputs), e.Inputs["environment"].String(), dryRun, replicas, e.Inputs["tag"].String()}
+			},
+			expected: []interface{}{"refs/heads/main", ".github/workflows/deploy.yml", 4, "staging", true, float64(3), "v1.2"},
+		},
+		{
+			eventName: "workflow_run",
+			fixture:   "workflow_run.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*WorkflowRunEvent)
+				return []interface{}{e.Action, e.Workflow.Path, e.WorkflowRun.ID, e.WorkflowRun.Conclusion, e.WorkflowRun.Event, e.WorkflowRun.HeadRepository.FullName}
+			},
+			expected: []interface{}{"completed", ".github/workflows/build.yml", int64(30433642), "failure", "push", "octocat/Hello-World"},
+		},
+		{
+			eventName: "schedule",
+			fixture:   "schedule.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*ScheduleEvent)
+				return []interface{}{e.Schedule, e.Repository.DefaultBranch, e.Sender.Type}
+			},
+			expected: []interface{}{"30 5 * * 1,3", "main", "Bot"},
+		},
+		{
+			eventName: "merge_group",
+			fixture:   "merge_group.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*MergeGroupEvent)
+				return []interface{}{e.Action, e.MergeGroup.BaseRef, e.MergeGroup.HeadSHA, e.MergeGroup.HeadCommit.Message}
+			},
+			expected: []interface{}{"checks_requested", "refs/heads/main", "ec26c3e57ca3a959ca5aad62de7213c562f8c821", "Merge pull request #2 from monalisa/changes"},
+		},
+		{
+			eventName: "deployment",
+			fixture:   "deployment.json",
+			get: func(event interface{}) interface{} {
+				e := event.(*DeploymentEvent)
+				var payload map[string]string
+				json.Unmarshal(e.Deployment.Payload, &payload)
+				return []interface{}{e.Deployment.Environment, e.Deployment.ProductionEnvironment, payload["region"], e.Workflow == nil}
+			},
+			expected: []interface{}{"production", true, "us-east-1", true},
+		},
+	}
+	for _, tt := range table {
+		setEnvVars(t, []env{
+			{key: "GITHUB_EVENT_NAME", val: tt.eventName},
+			{key: "GITHUB_EVENT_PATH", val: filepath.Join("testdata", "events", tt.fixture)},
+		})
+		c, err := NewContext()
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		event, err := c.Event()
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.eventName)
+		}
+		if actual := tt.get(event); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.eventName)
+		}
+	}
+}
+
+func Test_ContextEventErrors(t *testing.T) {
+	table := []struct {
+		name     string
+		context  *Context
+		expected string
+	}{
+		{
+			name:     "unsupported event",
+			context:  &Context{EventName: "watch", Payload: WebhookPayload{Raw: json.RawMessage(`{}`)}},
+			expected: `the payload of "watch" events is not supported, use Context.Payload instead`,
+		},
+		{
+			name:     "no payload",
+			context:  &Context{EventName: "push"},
+			expected: "the payload of the event is not available, GITHUB_EVENT_PATH is not set or does not exist",
+		},
+		{
+			name:     "invalid payload",
+			context:  &Context{EventName: "push", Payload: WebhookPayload{Raw: json.RawMessage(`{"ref": 1}`)}},
+			expected: "failed to decode the payload of the push event",
+		},
+	}
+	for _, tt := range table {
+		_, err := tt.context.Event()
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.expected, err, tt.name)
+		}
+	}
+
+	_, err := (&Context{EventName: "watch"}).Event()
+	if _, ok := err.(*UnsupportedEventError); !ok {
+		t.Fatalf("expected value is %T, but result was %T.", &UnsupportedEventError{}, err)
+	}
+}
+
+func Test_WorkflowInput(t *testing.T) {
+	var inputs map[string]WorkflowInput
+	data := `{"string": "value", "bool": true, "bool-string": "False", "number": 1.5, "number-string": "42", "empty": ""}`
+	if err := json.Unmarshal([]byte(data), &inputs); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	table := []struct {
+		name     string
+		actual   func() (interface{}, error)
+		expected interface{}
+		err      string
+	}{
+		{name: "string", actual: func() (interface{}, error) { return inputs["string"].String(), nil }, expected: "value"},
+		{name: "bool as string", actual: func() (interface{}, error) { return inputs["bool"].String(), nil }, expected: "true"},
+		{name: "number as string", actual: func() (interface{}, error) { return inputs["number"].String(), nil }, expected: "1.5"},
+		{name: "missing as string", actual: func() (interface{}, error) { return inputs["missing"].String(), nil }, expected: ""},
+		{name: "bool", actual: func() (interface{}, error) { return inputs["bool"].Bool() }, expected: true},
+		{name: "bool from string", actual: func() (interface{}, error) { return inputs["bool-string"].Bool() }, expected: false},
+		{name: "invalid bool", actual: func() (interface{}, error) { return inputs["string"].Bool() }, err: "Input does not meet YAML 1.2 \"Core Schema\" specification: value"},
+		{name: "number", actual: func() (interface{}, error) { return inputs["number"].Number() }, expected: 1.5},
+		{name: "number from string", actual: func() (interface{}, error) { return inputs["number-string"].Number() }, expected: float64(42)},
+		{name: "invalid number", actual: func() (interface{}, error) { return inputs["empty"].Number() }, err: "Input is not a number: "},
+	}
+	for _, tt := range table {
+		actual, err := tt.actual()
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if actual != tt.expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+
+	encoded, err := json.Marshal(inputs["bool"])
+	if err != nil || string(encoded) != "true" {
+		t.Fatalf("expected value is %v, but result was %s %v.", "true", encoded, err)
+	}
+}
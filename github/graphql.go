@@ -0,0 +1,238 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GraphQLError is an error of a GraphQL response.
+type GraphQLError struct {
+	Message string `json:"message"`
+
+	// Type is set by GitHub, e.g. NOT_FOUND or RATE_LIMITED
+	Type      string        `json:"type,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponseError is returned when a GraphQL response has errors. The data of the
+// response, which may be partial, is decoded anyway.
+type GraphQLResponseError struct {
+	Errors   []GraphQLError
+	Response *GraphQLResponse
+}
+
+func (e *GraphQLResponseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = " - " + err.Message
+	}
+	return "Request failed due to following response errors:\n" + strings.Join(messages, "\n")
+}
+
+// GraphQLCost is the cost of a query, reported when the query selects the rate limit,
+// e.g. `rateLimit { cost nodeCount limit remaining used resetAt }`.
+type GraphQLCost struct {
+	Cost      int       `json:"cost"`
+	NodeCount int       `json:"nodeCount"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// GraphQLResponse is the response of a GraphQL query.
+type GraphQLResponse struct {
+	*Response
+
+	// Cost is nil unless the query selects rateLimit at its root
+	Cost *GraphQLCost
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQL sends the query with the variables to the GraphQL API and decodes the data of the
+// response into out unless it is nil. Requests hitting a rate limit are retried like the
+// requests of the REST API.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) (*GraphQLResponse, error) {
+	request := &graphQLRequest{Query: query, Variables: variables}
+	for attempt := 0; ; attempt++ {
+		var result graphQLResult
+		resp, err := c.Do(ctx, http.MethodPost, c.graphqlURL, request, &result)
+		if err != nil {
+			return nil, err
+		}
+		response := &GraphQLResponse{Response: resp}
+
+		// The primary rate limit is reported as an error of a successful response
+		if isRateLimited(result.Errors) {
+			apiErr := &APIError{StatusCode: resp.StatusCode, Message: result.Errors[0].Message, Response: resp}
+			rateLimitErr := &RateLimitError{APIError: apiErr, RetryAfter: resp.Rate.Reset.Sub(now()) + time.Second}
+			if rateLimitErr.RetryAfter < 0 {
+				rateLimitErr.RetryAfter = 0
+			}
+			if attempt >= c.maxRetries || resp.Rate.Reset.IsZero() || rateLimitErr.RetryAfter > c.maxRateLimitWait {
+				return response, rateLimitErr
+			}
+			if err := sleep(ctx, rateLimitErr.RetryAfter); err != nil {
+				return response, err
+			}
+			continue
+		}
+
+		if len(result.Data) > 0 && !bytes.Equal(result.Data, []byte("null")) {
+			var root struct {
+				RateLimit *GraphQLCost `json:"rateLimit"`
+			}
+			if json.Unmarshal(result.Data, &root) == nil {
+				response.Cost = root.RateLimit
+			}
+			if out != nil {
+				if err := json.Unmarshal(result.Data, out); err != nil {
+					return response, fmt.Errorf("failed to decode the response: %w", err)
+				}
+			}
+		}
+		if len(result.Errors) > 0 {
+			return response, &GraphQLResponseError{Errors: result.Errors, Response: response}
+		}
+		return response, nil
+	}
+}
+
+func isRateLimited(errors []GraphQLError) bool {
+	for _, err := range errors {
+		if err.Type == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphQLPages iterates over the pages of a connection of a GraphQL query, following the
+// pageInfo of the connection. The query takes the cursor of the page in a $cursor variable
+// and selects `pageInfo { hasNextPage endCursor }` of the connection:
+//
+//	query := `query($owner: String!, $repo: String!, $cursor: String) {
+//		repository(owner: $owner, name: $repo) {
+//			issues(first: 100, after: $cursor) {
+//				nodes { number title }
+//				pageInfo { hasNextPage endCursor }
+//			}
+//		}
+//	}`
+//	pages := client.PaginateGraphQL(query, map[string]interface{}{"owner": "octocat", "repo": "Hello-World"}, "repository", "issues")
+//	for {
+//		var page struct { ... }
+//		if !pages.Next(ctx, &page) {
+//			break
+//		}
+//		...
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type GraphQLPages struct {
+	client    *Client
+	query     string
+	variables map[string]interface{}
+	path      []string
+	done      bool
+	response  *GraphQLResponse
+	err       error
+}
+
+// PaginateGraphQL returns an iterator over the pages of the connection at the path of the
+// data of the query, e.g. "repository", "issues". The variables are not modified.
+func (c *Client) PaginateGraphQL(query string, variables map[string]interface{}, path ...string) *GraphQLPages {
+	copied := map[string]interface{}{}
+	for key, value := range variables {
+		copied[key] = value
+	}
+	return &GraphQLPages{client: c, query: query, variables: copied, path: path}
+}
+
+// Next decodes the data of the next page into out. It returns false after the last page or
+// when the query fails.
+func (p *GraphQLPages) Next(ctx context.Context, out interface{}) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	var data json.RawMessage
+	p.response, p.err = p.client.GraphQL(ctx, p.query, p.variables, &data)
+	if p.err != nil {
+		return false
+	}
+	if out != nil {
+		if p.err = json.Unmarshal(data, out); p.err != nil {
+			return false
+		}
+	}
+
+	pageInfo, err := getPageInfo(data, p.path)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	if !pageInfo.HasNextPage {
+		p.done = true
+		return true
+	}
+	if cursor, ok := p.variables["cursor"].(string); ok && cursor == pageInfo.EndCursor {
+		p.err = fmt.Errorf("The cursor at %q did not change its value %q after a page transition. Please make sure your that your query is set up correctly.", strings.Join(p.path, "."), cursor)
+		return false
+	}
+	p.variables["cursor"] = pageInfo.EndCursor
+	return true
+}
+
+// Response returns the response of the last page.
+func (p *GraphQLPages) Response() *GraphQLResponse {
+	return p.response
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (p *GraphQLPages) Err() error {
+	return p.err
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// getPageInfo returns the pageInfo of the connection at the path of the data.
+func getPageInfo(data json.RawMessage, path []string) (*pageInfo, error) {
+	node := data
+	for _, key := range path {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(node, &object); err != nil || object[key] == nil {
+			return nil, fmt.Errorf("No connection found at %q in the response. Please make sure the path of the connection matches the query.", strings.Join(path, "."))
+		}
+		node = object[key]
+	}
+	var connection struct {
+		PageInfo *pageInfo `json:"pageInfo"`
+	}
+	if err := json.Unmarshal(node, &connection); err != nil || connection.PageInfo == nil {
+		return nil, fmt.Errorf("No pageInfo property found in response. Please make sure to specify the pageInfo in your query. Response-Data: %s", data)
+	}
+	return connection.PageInfo, nil
+}
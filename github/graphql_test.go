@@ -0,0 +1,239 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func Test_GraphQL(t *testing.T) {
+	var request graphQLRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.Method != http.MethodPost || r.URL.Path != "/api/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		fmt.Fprint(w, `{"data": {"repository": {"name": "Hello-World", "stargazerCount": 80}, "rateLimit": {"cost": 1, "nodeCount": 1, "limit": 5000, "remaining": 4999, "used": 1, "resetAt": "2024-01-15T01:00:00Z"}}}`)
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_GRAPHQL_URL", server.URL+"/api/graphql")
+
+	c := NewClient("token", nil)
+	query := `query($owner: String!, $repo: String!) { repository(owner: $owner, name: $repo) { name stargazerCount } rateLimit { cost nodeCount limit remaining used resetAt } }`
+	variables := map[string]interface{}{"owner": "octocat", "repo": "Hello-World"}
+	var out struct {
+		Repository struct {
+			Name           string
+			StargazerCount int
+		}
+	}
+	resp, err := c.GraphQL(context.Background(), query, variables, &out)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if request.Query != query || !reflect.DeepEqual(request.Variables, variables) || authorization != "Bearer token" {
+		t.Fatalf("unexpected request: %+v %v", request, authorization)
+	}
+	if out.Repository.Name != "Hello-World" || out.Repository.StargazerCount != 80 {
+		t.Fatalf("expected value is %v, but result was %+v.", "Hello-World 80", out)
+	}
+	expected := &GraphQLCost{Cost: 1, NodeCount: 1, Limit: 5000, Remaining: 4999, Used: 1, ResetAt: time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(resp.Cost, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, resp.Cost)
+	}
+}
+
+func Test_GraphQLErrors(t *testing.T) {
+	reset := strconv.FormatInt(time.Unix(1700000000, 0).Add(30*time.Second).Unix(), 10)
+	rateLimited := response{
+		status: 200,
+		header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+		body:   `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded for user ID 1."}]}`,
+	}
+	maxRateLimitWait := 10 * time.Second
+
+	table := []struct {
+		name      string
+		options   *ClientOptions
+		responses []response
+		waits     []time.Duration
+		expected  string
+		err       string
+	}{
+		{
+			name: "partial data",
+			responses: []response{
+				{status: 200, body: `{"data": {"a": {"name": "a"}, "b": null}, "errors": [{"type": "NOT_FOUND", "path": ["b"], "locations": [{"line": 1, "column": 2}], "message": "Could not resolve to a Repository with the name 'owner/b'."}, {"message": "Something went wrong"}]}`},
+			},
+			expected: "a",
+			err:      "Request failed due to following response errors:\n - Could not resolve to a Repository with the name 'owner/b'.\n - Something went wrong",
+		},
+		{
+			name:      "rate limited",
+			responses: []response{rateLimited, {status: 200, body: `{"data": {"a": {"name": "a"}}}`}},
+			waits:     []time.Duration{31 * time.Second},
+			expected:  "a",
+		},
+		{
+			name:      "rate limit resetting too late",
+			options:   &ClientOptions{MaxRateLimitWait: &maxRateLimitWait},
+			responses: []response{rateLimited},
+			err:       "API rate limit exceeded for user ID 1. (retry after 31s)",
+		},
+		{
+			name:      "secondary rate limit",
+			responses: []response{{status: 403, header: map[string]string{"Retry-After": "60"}, body: `{"message": "You have exceeded a secondary rate limit."}`}, {status: 200, body: `{"data": {"a": {"name": "a"}}}`}},
+			waits:     []time.Duration{time.Minute},
+			expected:  "a",
+		},
+		{
+			name:      "unauthorized",
+			responses: []response{{status: 401, body: `{"message": "Bad credentials"}`}},
+			err:       "Bad credentials",
+		},
+	}
+	for _, tt := range table {
+		waits := recordSleeps(t)
+		server, count := newSequenceServer(t, tt.responses)
+		options := tt.options
+		if options == nil {
+			options = &ClientOptions{}
+		}
+		options.GraphQLURL = ptr.String(server.URL)
+
+		var out struct {
+			A *struct{ Name string }
+		}
+		_, err := NewClient("token", options).GraphQL(context.Background(), "query { a { name } b { name } }", nil, &out)
+		if tt.err == "" && err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+		}
+		var actual string
+		if out.A != nil {
+			actual = out.A.Name
+		}
+		if actual != tt.expected || *count != len(tt.responses) {
+			t.Fatalf("expected value is %v %v, but result was %v %v.\ntest case: %v", tt.expected, len(tt.responses), actual, *count, tt.name)
+		}
+		if !reflect.DeepEqual(*waits, tt.waits) && (len(*waits) != 0 || len(tt.waits) != 0) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.waits, *waits, tt.name)
+		}
+	}
+
+	// The errors are structured
+	server, _ := newSequenceServer(t, []response{{status: 200, body: `{"data": null, "errors": [{"type": "NOT_FOUND", "path": ["repository", 0], "locations": [{"line": 1, "column": 2}], "message": "Not found"}]}`}})
+	_, err := NewClient("token", &ClientOptions{GraphQLURL: ptr.String(server.URL)}).GraphQL(context.Background(), "query { repository { name } }", nil, nil)
+	var responseErr *GraphQLResponseError
+	if !errors.As(err, &responseErr) || len(responseErr.Errors) != 1 {
+		t.Fatalf("expected value is %T, but result was %#v.", responseErr, err)
+	}
+	if e := responseErr.Errors[0]; e.Type != "NOT_FOUND" || !reflect.DeepEqual(e.Path, []interface{}{"repository", float64(0)}) || e.Locations[0].Column != 2 {
+		t.Fatalf("unexpected error: %+v", e)
+	}
+}
+
+func Test_PaginateGraphQL(t *testing.T) {
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request graphQLRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		cursor := request.Variables["cursor"]
+		cursors = append(cursors, cursor)
+		switch cursor {
+		case nil:
+			fmt.Fprint(w, `{"data": {"repository": {"issues": {"nodes": [{"number": 1}, {"number": 2}], "pageInfo": {"hasNextPage": true, "endCursor": "Y3Vyc29yOjI="}}}}}`)
+		case "Y3Vyc29yOjI=":
+			fmt.Fprint(w, `{"data": {"repository": {"issues": {"nodes": [{"number": 3}], "pageInfo": {"hasNextPage": true, "endCursor": "Y3Vyc29yOjM="}}}}}`)
+		default:
+			fmt.Fprint(w, `{"data": {"repository": {"issues": {"nodes": [], "pageInfo": {"hasNextPage": false, "endCursor": null}}}}}`)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient("token", &ClientOptions{GraphQLURL: ptr.String(server.URL)})
+	variables := map[string]interface{}{"owner": "octocat", "repo": "Hello-World"}
+	pages := c.PaginateGraphQL("query", variables, "repository", "issues")
+	var numbers []int
+	for {
+		var page struct {
+			Repository struct {
+				Issues struct {
+					Nodes []struct{ Number int }
+				}
+			}
+		}
+		if !pages.Next(context.Background(), &page) {
+			break
+		}
+		for _, node := range page.Repository.Issues.Nodes {
+			numbers = append(numbers, node.Number)
+		}
+	}
+	if err := pages.Err(); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := []int{1, 2, 3}; !reflect.DeepEqual(numbers, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, numbers)
+	}
+	if expected := []interface{}{nil, "Y3Vyc29yOjI=", "Y3Vyc29yOjM="}; !reflect.DeepEqual(cursors, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, cursors)
+	}
+	if _, ok := variables["cursor"]; ok || pages.Response() == nil {
+		t.Fatalf("expected the variables to be left untouched, but result was %v.", variables)
+	}
+}
+
+func Test_PaginateGraphQLErrors(t *testing.T) {
+	table := []struct {
+		name string
+		path []string
+		body string
+		err  string
+	}{
+		{
+			name: "missing pageInfo",
+			path: []string{"repository", "issues"},
+			body: `{"data": {"repository": {"issues": {"nodes": []}}}}`,
+			err:  "No pageInfo property found in response. Please make sure to specify the pageInfo in your query.",
+		},
+		{
+			name: "wrong path",
+			path: []string{"repository", "pullRequests"},
+			body: `{"data": {"repository": {"issues": {"nodes": []}}}}`,
+			err:  `No connection found at "repository.pullRequests" in the response.`,
+		},
+		{
+			name: "cursor not changing",
+			path: []string{"viewer", "repositories"},
+			body: `{"data": {"viewer": {"repositories": {"pageInfo": {"hasNextPage": true, "endCursor": "same"}}}}}`,
+			err:  `The cursor at "viewer.repositories" did not change its value "same" after a page transition.`,
+		},
+	}
+	for _, tt := range table {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tt.body)
+		}))
+		pages := NewClient("token", &ClientOptions{GraphQLURL: ptr.String(server.URL)}).PaginateGraphQL("query", nil, tt.path...)
+		for pages.Next(context.Background(), nil) {
+		}
+		server.Close()
+		if err := pages.Err(); err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The REST API endpoints actions use most. The responses share the types of the webhook
+// payloads where the API returns the same objects.
+
+// IssueRequest creates or updates an issue. Fields left nil are not changed.
+type IssueRequest struct {
+	Title     *string   `json:"title,omitempty"`
+	Body      *string   `json:"body,omitempty"`
+	State     *string   `json:"state,omitempty"`
+	Labels    *[]string `json:"labels,omitempty"`
+	Assignees *[]string `json:"assignees,omitempty"`
+	Milestone *int      `json:"milestone,omitempty"`
+}
+
+// CheckRunOutput is the output of a check run.
+type CheckRunOutput struct {
+	Title       string               `json:"title"`
+	Summary     string               `json:"summary"`
+	Text        *string              `json:"text,omitempty"`
+	Annotations []CheckRunAnnotation `json:"annotations,omitempty"`
+	Images      []CheckRunImage      `json:"images,omitempty"`
+}
+
+// CheckRunAnnotation is an annotation of a line range of a file of a check run.
+type CheckRunAnnotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+
+	// StartColumn and EndColumn are only allowed when StartLine and EndLine are the same
+	StartColumn *int `json:"start_column,omitempty"`
+	EndColumn   *int `json:"end_column,omitempty"`
+
+	// AnnotationLevel is notice, warning or failure
+	AnnotationLevel string  `json:"annotation_level"`
+	Message         string  `json:"message"`
+	Title           *string `json:"title,omitempty"`
+	RawDetails      *string `json:"raw_details,omitempty"`
+}
+
+// CheckRunImage is an image shown in the output of a check run.
+type CheckRunImage struct {
+	Alt      string  `json:"alt"`
+	ImageURL string  `json:"image_url"`
+	Caption  *string `json:"caption,omitempty"`
+}
+
+// CheckRunRequest creates or updates a check run. Fields left nil are not changed.
+type CheckRunRequest struct {
+	Name        *string         `json:"name,omitempty"`
+	HeadSHA     *string         `json:"head_sha,omitempty"`
+	DetailsURL  *string         `json:"details_url,omitempty"`
+	ExternalID  *string         `json:"external_id,omitempty"`
+	Status      *string         `json:"status,omitempty"`
+	Conclusion  *string         `json:"conclusion,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Output      *CheckRunOutput `json:"output,omitempty"`
+}
+
+// CheckRun is a check run of a commit.
+type CheckRun struct {
+	ID          int64      `json:"id"`
+	NodeID      string     `json:"node_id,omitempty"`
+	Name        string     `json:"name"`
+	HeadSHA     string     `json:"head_sha"`
+	ExternalID  string     `json:"external_id"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	DetailsURL  string     `json:"details_url"`
+	HTMLURL     string     `json:"html_url"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Output      struct {
+		Title            string `json:"title"`
+		Summary          string `json:"summary"`
+		Text             string `json:"text"`
+		AnnotationsCount int    `json:"annotations_count"`
+	} `json:"output"`
+}
+
+// StatusRequest creates a commit status.
+type StatusRequest struct {
+	// State is error, failure, pending or success
+	State       string  `json:"state"`
+	TargetURL   *string `json:"target_url,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Context     *string `json:"context,omitempty"`
+}
+
+// CommitStatus is a status of a commit.
+type CommitStatus struct {
+	ID          int64     `json:"id"`
+	State       string    `json:"state"`
+	TargetURL   string    `json:"target_url"`
+	Description string    `json:"description"`
+	Context     string    `json:"context"`
+	Creator     User      `json:"creator"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ReleaseRequest creates or updates a release. Fields left nil are not changed.
+type ReleaseRequest struct {
+	TagName              *string `json:"tag_name,omitempty"`
+	TargetCommitish      *string `json:"target_commitish,omitempty"`
+	Name                 *string `json:"name,omitempty"`
+	Body                 *string `json:"body,omitempty"`
+	Draft                *bool   `json:"draft,omitempty"`
+	Prerelease           *bool   `json:"prerelease,omitempty"`
+	GenerateReleaseNotes *bool   `json:"generate_release_notes,omitempty"`
+}
+
+// Reference is a git reference, e.g. refs/heads/main.
+type Reference struct {
+	Ref    string `json:"ref"`
+	NodeID string `json:"node_id,omitempty"`
+	URL    string `json:"url"`
+	Object struct {
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+		URL  string `json:"url"`
+	} `json:"object"`
+}
+
+// PullRequestFile is a file changed by a pull request.
+type PullRequestFile struct {
+	SHA       string `json:"sha"`
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	Patch     string `json:"patch,omitempty"`
+
+	// PreviousFilename is set for renamed files
+	PreviousFilename string `json:"previous_filename,omitempty"`
+}
+
+// repoPath returns the path of the resource of the repository, escaping the segments.
+func repoPath(owner, repo string, segments ...interface{}) string {
+	path := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	for _, segment := range segments {
+		path += "/" + url.PathEscape(fmt.Sprint(segment))
+	}
+	return path
+}
+
+// refPath returns the escaped path of a fully qualified or short ref, e.g. heads/main.
+func refPath(ref string) string {
+	segments := strings.Split(strings.TrimPrefix(ref, "refs/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// GetIssue returns an issue or a pull request as an issue.
+func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (*IssueDetails, error) {
+	issue := &IssueDetails{}
+	if _, err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "issues", number), nil, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// CreateIssue creates an issue.
+func (c *Client) CreateIssue(ctx context.Context, owner, repo string, request *IssueRequest) (*IssueDetails, error) {
+	issue := &IssueDetails{}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "issues"), request, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// UpdateIssue updates an issue or a pull request.
+func (c *Client) UpdateIssue(ctx context.Context, owner, repo string, number int, request *IssueRequest) (*IssueDetails, error) {
+	issue := &IssueDetails{}
+	if _, err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "issues", number), request, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// ListIssueComments returns all the comments of an issue or a pull request.
+func (c *Client) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	var comments []Comment
+	pages := c.Paginate(repoPath(owner, repo, "issues", number, "comments") + "?per_page=100")
+	for {
+		var page []Comment
+		if !pages.Next(ctx, &page) {
+			break
+		}
+		comments = append(comments, page...)
+	}
+	return comments, pages.Err()
+}
+
+// CreateIssueComment comments on an issue or a pull request.
+func (c *Client) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) (*Comment, error) {
+	comment := &Comment{}
+	request := map[string]string{"body": body}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "issues", number, "comments"), request, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateIssueComment replaces the body of a comment.
+func (c *Client) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) (*Comment, error) {
+	comment := &Comment{}
+	request := map[string]string{"body": body}
+	if _, err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "issues", "comments", commentID), request, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteIssueComment deletes a comment.
+func (c *Client) DeleteIssueComment(ctx context.Context, owner, repo string, commentID int64) error {
+	_, err := c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "issues", "comments", commentID), nil, nil)
+	return err
+}
+
+// ListLabels returns the labels of an issue or a pull request.
+func (c *Client) ListLabels(ctx context.Context, owner, repo string, number int) ([]Label, error) {
+	var labels []Label
+	pages := c.Paginate(repoPath(owner, repo, "issues", number, "labels") + "?per_page=100")
+	for {
+		var page []Label
+		if !pages.Next(ctx, &page) {
+			break
+		}
+		labels = append(labels, page...)
+	}
+	return labels, pages.Err()
+}
+
+// AddLabels adds labels to an issue or a pull request and returns all its labels.
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) ([]Label, error) {
+	var result []Label
+	request := map[string][]string{"labels": labels}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "issues", number, "labels"), request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RemoveLabel removes a label from an issue or a pull request.
+func (c *Client) RemoveLabel(ctx context.Context, owner, repo string, number int, label string) error {
+	_, err := c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "issues", number, "labels", label), nil, nil)
+	return err
+}
+
+// CreateCheckRun creates a check run. Name and HeadSHA are required.
+func (c *Client) CreateCheckRun(ctx context.Context, owner, repo string, request *CheckRunRequest) (*CheckRun, error) {
+	checkRun := &CheckRun{}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "check-runs"), request, checkRun); err != nil {
+		return nil, err
+	}
+	return checkRun, nil
+}
+
+// UpdateCheckRun updates a check run. The annotations of the output are added to the
+// annotations of the check run.
+func (c *Client) UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, request *CheckRunRequest) (*CheckRun, error) {
+	checkRun := &CheckRun{}
+	if _, err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "check-runs", checkRunID), request, checkRun); err != nil {
+		return nil, err
+	}
+	return checkRun, nil
+}
+
+// ListCheckRunsForRef returns all the check runs of a commit SHA, branch or tag.
+func (c *Client) ListCheckRunsForRef(ctx context.Context, owner, repo string, ref string) ([]CheckRun, error) {
+	var checkRuns []CheckRun
+	pages := c.Paginate(repoPath(owner, repo, "commits", ref, "check-runs") + "?per_page=100")
+	for {
+		var page struct {
+			CheckRuns []CheckRun `json:"check_runs"`
+		}
+		if !pages.Next(ctx, &page) {
+			break
+		}
+		checkRuns = append(checkRuns, page.CheckRuns...)
+	}
+	return checkRuns, pages.Err()
+}
+
+// CreateStatus creates a status of a commit.
+func (c *Client) CreateStatus(ctx context.Context, owner, repo string, sha string, request *StatusRequest) (*CommitStatus, error) {
+	status := &CommitStatus{}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "statuses", sha), request, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ListReleases returns all the releases of a repository, including drafts when the token can push.
+func (c *Client) ListReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	var releases []Release
+	pages := c.Paginate(repoPath(owner, repo, "releases") + "?per_page=100")
+	for {
+		var page []Release
+		if !pages.Next(ctx, &page) {
+			break
+		}
+		releases = append(releases, page...)
+	}
+	return releases, pages.Err()
+}
+
+// GetReleaseByTag returns the published release of a tag.
+func (c *Client) GetReleaseByTag(ctx context.Context, owner, repo string, tag string) (*Release, error) {
+	release := &Release{}
+	if _, err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "releases", "tags", tag), nil, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// CreateRelease creates a release. TagName is required.
+func (c *Client) CreateRelease(ctx context.Context, owner, repo string, request *ReleaseRequest) (*Release, error) {
+	release := &Release{}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "releases"), request, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// UpdateRelease updates a release.
+func (c *Client) UpdateRelease(ctx context.Context, owner, repo string, releaseID int64, request *ReleaseRequest) (*Release, error) {
+	release := &Release{}
+	if _, err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "releases", releaseID), request, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// GetRef returns a reference, e.g. heads/main or refs/tags/v1.
+func (c *Client) GetRef(ctx context.Context, owner, repo string, ref string) (*Reference, error) {
+	reference := &Reference{}
+	if _, err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "git", "ref")+"/"+refPath(ref), nil, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
+// CreateRef creates a reference to the SHA. The ref is fully qualified, e.g. refs/heads/main.
+func (c *Client) CreateRef(ctx context.Context, owner, repo string, ref string, sha string) (*Reference, error) {
+	reference := &Reference{}
+	request := map[string]string{"ref": ref, "sha": sha}
+	if _, err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "git", "refs"), request, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
+// UpdateRef points a reference to the SHA, e.g. heads/main. The update must be a fast-forward unless force is true.
+func (c *Client) UpdateRef(ctx context.Context, owner, repo string, ref string, sha string, force bool) (*Reference, error) {
+	reference := &Reference{}
+	request := map[string]interface{}{"sha": sha, "force": force}
+	if _, err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "git", "refs")+"/"+refPath(ref), request, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
+// DeleteRef deletes a reference, e.g. heads/feature.
+func (c *Client) DeleteRef(ctx context.Context, owner, repo string, ref string) error {
+	_, err := c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "git", "refs")+"/"+refPath(ref), nil, nil)
+	return err
+}
+
+// ListPullRequestFiles returns the files changed by a pull request, at most 3000.
+func (c *Client) ListPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]PullRequestFile, error) {
+	var files []PullRequestFile
+	pages := c.Paginate(repoPath(owner, repo, "pulls", number, "files") + "?per_page=100")
+	for {
+		var page []PullRequestFile
+		if !pages.Next(ctx, &page) {
+			break
+		}
+		files = append(files, page...)
+	}
+	return files, pages.Err()
+}
@@ -0,0 +1,290 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func Test_RESTEndpoints(t *testing.T) {
+	type request struct {
+		method string
+		uri    string
+		body   string
+	}
+	var requests []request
+	var responses map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{method: r.Method, uri: r.URL.RequestURI(), body: string(body)})
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		response, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	c := NewClient("token", &ClientOptions{BaseURL: ptr.String(server.URL)})
+	ctx := context.Background()
+
+	table := []struct {
+		name      string
+		call      func() (interface{}, error)
+		responses map[string]string
+		requests  []request
+		expected  interface{}
+	}{
+		{
+			name: "get issue",
+			call: func() (interface{}, error) {
+				issue, err := c.GetIssue(ctx, "owner", "repo", 1)
+				return issue.Title, err
+			},
+			responses: map[string]string{"/repos/owner/repo/issues/1": `{"number": 1, "title": "Found a bug"}`},
+			requests:  []request{{method: "GET", uri: "/repos/owner/repo/issues/1"}},
+			expected:  "Found a bug",
+		},
+		{
+			name: "create issue",
+			call: func() (interface{}, error) {
+				issue, err := c.CreateIssue(ctx, "owner", "repo", &IssueRequest{Title: ptr.String("Found a bug"), Labels: &[]string{"bug"}})
+				return issue.Number, err
+			},
+			responses: map[string]string{"/repos/owner/repo/issues": `{"number": 2}`},
+			requests:  []request{{method: "POST", uri: "/repos/owner/repo/issues", body: `{"title":"Found a bug","labels":["bug"]}`}},
+			expected:  2,
+		},
+		{
+			name: "update issue",
+			call: func() (interface{}, error) {
+				issue, err := c.UpdateIssue(ctx, "owner", "repo", 2, &IssueRequest{State: ptr.String("closed"), Labels: &[]string{}})
+				return issue.State, err
+			},
+			responses: map[string]string{"/repos/owner/repo/issues/2": `{"number": 2, "state": "closed"}`},
+			requests:  []request{{method: "PATCH", uri: "/repos/owner/repo/issues/2", body: `{"state":"closed","labels":[]}`}},
+			expected:  "closed",
+		},
+		{
+			name: "list issue comments",
+			call: func() (interface{}, error) {
+				comments, err := c.ListIssueComments(ctx, "owner", "repo", 1)
+				var bodies []string
+				for _, comment := range comments {
+					bodies = append(bodies, comment.Body)
+				}
+				return bodies, err
+			},
+			responses: map[string]string{"/repos/owner/repo/issues/1/comments?per_page=100": `[{"id": 1, "body": "first"}, {"id": 2, "body": "second"}]`},
+			requests:  []request{{method: "GET", uri: "/repos/owner/repo/issues/1/comments?per_page=100"}},
+			expected:  []string{"first", "second"},
+		},
+		{
+			name: "create, update and delete issue comments",
+			call: func() (interface{}, error) {
+				comment, err := c.CreateIssueComment(ctx, "owner", "repo", 1, "hello")
+				if err != nil {
+					return nil, err
+				}
+				if comment, err = c.UpdateIssueComment(ctx, "owner", "repo", comment.ID, "hello again"); err != nil {
+					return nil, err
+				}
+				return comment.Body, c.DeleteIssueComment(ctx, "owner", "repo", comment.ID)
+			},
+			responses: map[string]string{
+				"/repos/owner/repo/issues/1/comments": `{"id": 3, "body": "hello"}`,
+				"/repos/owner/repo/issues/comments/3": `{"id": 3, "body": "hello again"}`,
+			},
+			requests: []request{
+				{method: "POST", uri: "/repos/owner/repo/issues/1/comments", body: `{"body":"hello"}`},
+				{method: "PATCH", uri: "/repos/owner/repo/issues/comments/3", body: `{"body":"hello again"}`},
+				{method: "DELETE", uri: "/repos/owner/repo/issues/comments/3"},
+			},
+			expected: "hello again",
+		},
+		{
+			name: "labels",
+			call: func() (interface{}, error) {
+				labels, err := c.AddLabels(ctx, "owner", "repo", 1, []string{"bug", "good first issue"})
+				if err != nil {
+					return nil, err
+				}
+				if err := c.RemoveLabel(ctx, "owner", "repo", 1, "good first issue"); err != nil {
+					return nil, err
+				}
+				listed, err := c.ListLabels(ctx, "owner", "repo", 1)
+				return []int{len(labels), len(listed)}, err
+			},
+			responses: map[string]string{
+				"/repos/owner/repo/issues/1/labels":              `[{"name": "bug"}, {"name": "good first issue"}]`,
+				"/repos/owner/repo/issues/1/labels?per_page=100": `[{"name": "bug"}]`,
+			},
+			requests: []request{
+				{method: "POST", uri: "/repos/owner/repo/issues/1/labels", body: `{"labels":["bug","good first issue"]}`},
+				{method: "DELETE", uri: "/repos/owner/repo/issues/1/labels/good%20first%20issue"},
+				{method: "GET", uri: "/repos/owner/repo/issues/1/labels?per_page=100"},
+			},
+			expected: []int{2, 1},
+		},
+		{
+			name: "check runs",
+			call: func() (interface{}, error) {
+				checkRun, err := c.CreateCheckRun(ctx, "owner", "repo", &CheckRunRequest{Name: ptr.String("lint"), HeadSHA: ptr.String("abc"), Status: ptr.String("in_progress")})
+				if err != nil {
+					return nil, err
+				}
+				output := &CheckRunOutput{Title: "1 error", Summary: "Found 1 error", Annotations: []CheckRunAnnotation{{Path: "main.go", StartLine: 1, EndLine: 1, AnnotationLevel: "failure", Message: "unused variable"}}}
+				if _, err := c.UpdateCheckRun(ctx, "owner", "repo", checkRun.ID, &CheckRunRequest{Conclusion: ptr.String("failure"), Output: output}); err != nil {
+					return nil, err
+				}
+				checkRuns, err := c.ListCheckRunsForRef(ctx, "owner", "repo", "feature/x")
+				return len(checkRuns), err
+			},
+			responses: map[string]string{
+				"/repos/owner/repo/check-runs":                                  `{"id": 4, "name": "lint"}`,
+				"/repos/owner/repo/check-runs/4":                                `{"id": 4, "name": "lint", "conclusion": "failure"}`,
+				"/repos/owner/repo/commits/feature%2Fx/check-runs?per_page=100": `{"total_count": 2, "check_runs": [{"id": 4}, {"id": 5}]}`,
+			},
+			requests: []request{
+				{method: "POST", uri: "/repos/owner/repo/check-runs", body: `{"name":"lint","head_sha":"abc","status":"in_progress"}`},
+				{method: "PATCH", uri: "/repos/owner/repo/check-runs/4", body: `{"conclusion":"failure","output":{"title":"1 error","summary":"Found 1 error","annotations":[{"path":"main.go","start_line":1,"end_line":1,"annotation_level":"failure","message":"unused variable"}]}}`},
+				{method: "GET", uri: "/repos/owner/repo/commits/feature%2Fx/check-runs?per_page=100"},
+			},
+			expected: 2,
+		},
+		{
+			name: "create status",
+			call: func() (interface{}, error) {
+				status, err := c.CreateStatus(ctx, "owner", "repo", "abc", &StatusRequest{State: "success", Context: ptr.String("ci/build")})
+				return status.Context, err
+			},
+			responses: map[string]string{"/repos/owner/repo/statuses/abc": `{"id": 5, "state": "success", "context": "ci/build"}`},
+			requests:  []request{{method: "POST", uri: "/repos/owner/repo/statuses/abc", body: `{"state":"success","context":"ci/build"}`}},
+			expected:  "ci/build",
+		},
+		{
+			name: "releases",
+			call: func() (interface{}, error) {
+				release, err := c.GetReleaseByTag(ctx, "owner", "repo", "v1.0.0")
+				if err != nil {
+					return nil, err
+				}
+				if _, err := c.UpdateRelease(ctx, "owner", "repo", release.ID, &ReleaseRequest{Draft: ptr.Bool(false)}); err != nil {
+					return nil, err
+				}
+				if _, err := c.CreateRelease(ctx, "owner", "repo", &ReleaseRequest{TagName: ptr.String("v1.1.0"), GenerateReleaseNotes: ptr.Bool(true)}); err != nil {
+					return nil, err
+				}
+				releases, err := c.ListReleases(ctx, "owner", "repo")
+				return len(releases), err
+			},
+			responses: map[string]string{
+				"/repos/owner/repo/releases/tags/v1.0.0":  `{"id": 6, "tag_name": "v1.0.0", "draft": true}`,
+				"/repos/owner/repo/releases/6":            `{"id": 6, "tag_name": "v1.0.0"}`,
+				"/repos/owner/repo/releases":              `{"id": 7, "tag_name": "v1.1.0"}`,
+				"/repos/owner/repo/releases?per_page=100": `[{"id": 7}, {"id": 6}]`,
+			},
+			requests: []request{
+				{method: "GET", uri: "/repos/owner/repo/releases/tags/v1.0.0"},
+				{method: "PATCH", uri: "/repos/owner/repo/releases/6", body: `{"draft":false}`},
+				{method: "POST", uri: "/repos/owner/repo/releases", body: `{"tag_name":"v1.1.0","generate_release_notes":true}`},
+				{method: "GET", uri: "/repos/owner/repo/releases?per_page=100"},
+			},
+			expected: 2,
+		},
+		{
+			name: "refs",
+			call: func() (interface{}, error) {
+				ref, err := c.GetRef(ctx, "owner", "repo", "refs/heads/feature/x")
+				if err != nil {
+					return nil, err
+				}
+				if _, err := c.CreateRef(ctx, "owner", "repo", "refs/tags/v1", ref.Object.SHA); err != nil {
+					return nil, err
+				}
+				if _, err := c.UpdateRef(ctx, "owner", "repo", "tags/v1", "def", true); err != nil {
+					return nil, err
+				}
+				return ref.Object.SHA, c.DeleteRef(ctx, "owner", "repo", "heads/feature/x")
+			},
+			responses: map[string]string{
+				"/repos/owner/repo/git/ref/heads/feature/x": `{"ref": "refs/heads/feature/x", "object": {"type": "commit", "sha": "abc"}}`,
+				"/repos/owner/repo/git/refs":                `{"ref": "refs/tags/v1", "object": {"type": "commit", "sha": "abc"}}`,
+				"/repos/owner/repo/git/refs/tags/v1":        `{"ref": "refs/tags/v1", "object": {"type": "commit", "sha": "def"}}`,
+			},
+			requests: []request{
+				{method: "GET", uri: "/repos/owner/repo/git/ref/heads/feature/x"},
+				{method: "POST", uri: "/repos/owner/repo/git/refs", body: `{"ref":"refs/tags/v1","sha":"abc"}`},
+				{method: "PATCH", uri: "/repos/owner/repo/git/refs/tags/v1", body: `{"force":true,"sha":"def"}`},
+				{method: "DELETE", uri: "/repos/owner/repo/git/refs/heads/feature/x"},
+			},
+			expected: "abc",
+		},
+		{
+			name: "list pull request files",
+			call: func() (interface{}, error) {
+				files, err := c.ListPullRequestFiles(ctx, "owner", "repo", 1)
+				var names []string
+				for _, file := range files {
+					names = append(names, file.Status+" "+file.Filename)
+				}
+				return names, err
+			},
+			responses: map[string]string{"/repos/owner/repo/pulls/1/files?per_page=100": `[{"filename": "a.go", "status": "added"}, {"filename": "b.go", "status": "renamed", "previous_filename": "c.go"}]`},
+			requests:  []request{{method: "GET", uri: "/repos/owner/repo/pulls/1/files?per_page=100"}},
+			expected:  []string{"added a.go", "renamed b.go"},
+		},
+	}
+	for _, tt := range table {
+		requests = nil
+		responses = tt.responses
+		actual, err := tt.call()
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+		if !reflect.DeepEqual(requests, tt.requests) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.requests, requests, tt.name)
+		}
+	}
+
+	// Not found is an error
+	_, err := c.GetIssue(ctx, "owner", "repo", 404)
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusNotFound || apiErr.Error() != "Not Found" {
+		t.Fatalf("expected value is %v, but result was %v.", "Not Found", err)
+	}
+}
+
+func Test_CheckRunRequestJSON(t *testing.T) {
+	request := &CheckRunRequest{
+		Output: &CheckRunOutput{
+			Title:   "title",
+			Summary: "summary",
+			Annotations: []CheckRunAnnotation{
+				{Path: "a.go", StartLine: 2, EndLine: 2, StartColumn: ptr.Int(1), EndColumn: ptr.Int(5), AnnotationLevel: "warning", Message: "message", Title: ptr.String("title")},
+			},
+		},
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	expected := `{"output":{"title":"title","summary":"summary","annotations":[{"path":"a.go","start_line":2,"end_line":2,"start_column":1,"end_column":5,"annotation_level":"warning","message":"message","title":"title"}]}}`
+	if string(data) != expected {
+		t.Fatalf("expected value is %v, but result was %v.", expected, string(data))
+	}
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxWebhookPayloadSize is the maximum size of the webhook payloads GitHub delivers.
+const maxWebhookPayloadSize = 25 << 20
+
+// ErrSignatureMismatch is returned when the signature of a webhook delivery is not the HMAC of
+// its payload with the secret of the webhook.
+var ErrSignatureMismatch = errors.New("signature does not match event payload and secret")
+
+// ErrSecretRequired is returned when the secret of a webhook is empty, as anyone could sign
+// the payloads with it.
+var ErrSecretRequired = errors.New("secret required")
+
+// VerifySignature verifies the X-Hub-Signature-256 header of a webhook delivery, e.g.
+// "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", against the HMAC
+// of its payload with the secret of the webhook. The HMACs are compared in constant time. An
+// empty secret results in ErrSecretRequired.
+func VerifySignature(secret string, payload []byte, signature string) error {
+	if secret == "" {
+		return ErrSecretRequired
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrSignatureMismatch
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return ErrSignatureMismatch
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// Sign returns the X-Hub-Signature-256 header of a payload, e.g. to test webhook handlers.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookEvent is a webhook delivery.
+type WebhookEvent struct {
+	// ID is the X-GitHub-Delivery header identifying the delivery
+	ID string
+
+	// Name is the name of the event, e.g. pull_request
+	Name string
+
+	// Payload holds the fields common to the payloads, and the whole payload in Raw
+	Payload WebhookPayload
+
+	// Event is the payload decoded like Context.Event, e.g. a *PullRequestEvent, or nil if
+	// the event has no typed payload
+	Event interface{}
+}
+
+// WebhookHandlerFunc handles a webhook event. An error makes the delivery fail with a 500
+// status code.
+type WebhookHandlerFunc func(ctx context.Context, event *WebhookEvent) error
+
+// WebhookRouter is an http.Handler receiving webhook deliveries. It verifies their
+// signature, decodes their payload and dispatches them to the handlers of the event and
+// its action.
+type WebhookRouter struct {
+	secret string
+
+	mu       sync.RWMutex
+	handlers map[string][]WebhookHandlerFunc
+	any      []WebhookHandlerFunc
+}
+
+// NewWebhookRouter creates a router for the deliveries of a webhook. secret is the secret of
+// the webhook; the deliveries without a valid signature are rejected. An empty secret results
+// in ErrSecretRequired.
+func NewWebhookRouter(secret string) (*WebhookRouter, error) {
+	if secret == "" {
+		return nil, ErrSecretRequired
+	}
+	return &WebhookRouter{secret: secret, handlers: map[string][]WebhookHandlerFunc{}}, nil
+}
+
+// On registers a handler for an event, e.g. "pull_request", or for an action of an event,
+// e.g. "pull_request.opened". The handlers of an event run before the handlers of its
+// action, in the order of their registration.
+func (r *WebhookRouter) On(event string, handler WebhookHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[event] = append(r.handlers[event], handler)
+}
+
+// OnAny registers a handler for all the events. The handlers run after the handlers of the
+// event.
+func (r *WebhookRouter) OnAny(handler WebhookHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.any = append(r.any, handler)
+}
+
+// ServeHTTP receives a webhook delivery.
+func (r *WebhookRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, fmt.Sprintf("Unknown route: %s %s", req.Method, req.URL.Path), http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, `Unsupported "Content-Type" header value. Must be "application/json"`, http.StatusUnsupportedMediaType)
+		return
+	}
+	var missing []string
+	for _, header := range []string{"X-GitHub-Event", "X-Hub-Signature-256", "X-GitHub-Delivery"} {
+		if req.Header.Get(header) == "" {
+			missing = append(missing, strings.ToLower(header))
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, "Required headers missing: "+strings.Join(missing, ", "), http.StatusBadRequest)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := VerifySignature(r.secret, payload, req.Header.Get("X-Hub-Signature-256")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	event := &WebhookEvent{ID: req.Header.Get("X-GitHub-Delivery"), Name: req.Header.Get("X-GitHub-Event")}
+	if err := json.Unmarshal(payload, &event.Payload); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode the payload of the %s event: %v", event.Name, err), http.StatusBadRequest)
+		return
+	}
+	event.Payload.Raw = payload
+	event.Event, err = ParseEvent(event.Name, payload)
+	var unsupportedErr *UnsupportedEventError
+	if err != nil && !errors.As(err, &unsupportedErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.dispatch(req.Context(), event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// dispatch runs the handlers of the event and returns the first error. All the handlers run
+// even if one fails.
+func (r *WebhookRouter) dispatch(ctx context.Context, event *WebhookEvent) error {
+	r.mu.RLock()
+	handlers := append([]WebhookHandlerFunc{}, r.handlers[event.Name]...)
+	if event.Payload.Action != "" {
+		handlers = append(handlers, r.handlers[event.Name+"."+event.Payload.Action]...)
+	}
+	handlers = append(handlers, r.any...)
+	r.mu.RUnlock()
+
+	var firstErr error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
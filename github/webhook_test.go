@@ -0,0 +1,160 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_VerifySignature(t *testing.T) {
+	// The example of the documentation of the webhooks
+	secret := "It's a Secret to Everybody"
+	payload := []byte("Hello, World!")
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	table := []struct {
+		name      string
+		secret    string
+		payload   []byte
+		signature string
+		err       error
+	}{
+		{name: "valid", secret: secret, payload: payload, signature: signature},
+		{name: "wrong secret", secret: "secret", payload: payload, signature: signature, err: ErrSignatureMismatch},
+		{name: "wrong payload", secret: secret, payload: []byte("Hello, World"), signature: signature, err: ErrSignatureMismatch},
+		{name: "SHA-1", secret: secret, payload: payload, signature: "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", err: ErrSignatureMismatch},
+		{name: "not hexadecimal", secret: secret, payload: payload, signature: "sha256=xyz", err: ErrSignatureMismatch},
+		{name: "empty", secret: secret, payload: payload, err: ErrSignatureMismatch},
+		// Anyone can sign a payload without a secret
+		{name: "empty secret", payload: payload, signature: Sign("", payload), err: ErrSecretRequired},
+	}
+	for _, tt := range table {
+		if err := VerifySignature(tt.secret, tt.payload, tt.signature); err != tt.err {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+		}
+	}
+	if actual := Sign(secret, payload); actual != signature {
+		t.Fatalf("expected value is %v, but result was %v.", signature, actual)
+	}
+}
+
+func Test_WebhookRouter(t *testing.T) {
+	pullRequest, err := os.ReadFile("testdata/events/pull_request.json")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	ping := []byte(`{"zen": "Keep it logically awesome.", "hook_id": 1}`)
+
+	var calls []string
+	if _, err := NewWebhookRouter(""); err != ErrSecretRequired {
+		t.Fatalf("expected value is %v, but result was %v.", ErrSecretRequired, err)
+	}
+	router, err := NewWebhookRouter("secret")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	router.On("pull_request", func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "pull_request "+event.ID+" "+event.Event.(*PullRequestEvent).Label.Name)
+		return nil
+	})
+	router.On("pull_request.labeled", func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "pull_request.labeled "+event.Payload.Repository.FullName)
+		return errors.New("failed to handle the label")
+	})
+	router.On("pull_request.opened", func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "pull_request.opened")
+		return nil
+	})
+	router.OnAny(func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "any "+event.Name+" "+string(event.Payload.Raw[:8]))
+		return nil
+	})
+
+	table := []struct {
+		name     string
+		method   string
+		header   map[string]string
+		payload  []byte
+		status   int
+		body     string
+		expected []string
+	}{
+		{
+			name:     "pull_request",
+			header:   map[string]string{"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": Sign("secret", pullRequest)},
+			payload:  pullRequest,
+			status:   500,
+			body:     "failed to handle the label\n",
+			expected: []string{"pull_request 72d4f7c0 bug", "pull_request.labeled octocat/Hello-World", "any pull_request {\n  \"act"},
+		},
+		{
+			name:     "event without a typed payload",
+			header:   map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": Sign("secret", ping)},
+			payload:  ping,
+			status:   200,
+			body:     "ok\n",
+			expected: []string{"any ping {\"zen\": "},
+		},
+		{
+			name:    "invalid signature",
+			header:  map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": Sign("other", ping)},
+			payload: ping,
+			status:  401,
+			body:    "signature does not match event payload and secret\n",
+		},
+		{
+			name:    "missing headers",
+			header:  map[string]string{"X-GitHub-Delivery": ""},
+			payload: ping,
+			status:  400,
+			body:    "Required headers missing: x-github-event, x-hub-signature-256, x-github-delivery\n",
+		},
+		{
+			name:    "content type",
+			header:  map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			payload: ping,
+			status:  415,
+			body:    "Unsupported \"Content-Type\" header value. Must be \"application/json\"\n",
+		},
+		{
+			name:    "method",
+			method:  http.MethodGet,
+			payload: ping,
+			status:  405,
+			body:    "Unknown route: GET /webhook\n",
+		},
+		{
+			name:    "invalid payload",
+			header:  map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": Sign("secret", []byte(`{"ref": 1}`))},
+			payload: []byte(`{"ref": 1}`),
+			status:  400,
+			body:    "failed to decode the payload of the push event: json: cannot unmarshal number into Go struct field PushEvent.ref of type string\n",
+		},
+	}
+	for _, tt := range table {
+		calls = nil
+		method := tt.method
+		if method == "" {
+			method = http.MethodPost
+		}
+		req := httptest.NewRequest(method, "/webhook", bytes.NewReader(tt.payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Delivery", "72d4f7c0")
+		for key, value := range tt.header {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Fatalf("expected value is %v %q, but result was %v %q.\ntest case: %v", tt.status, tt.body, rec.Code, rec.Body.String(), tt.name)
+		}
+		if !reflect.DeepEqual(calls, tt.expected) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expected, calls, tt.name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package glob matches files and directories using the multiline pattern syntax of @actions/glob.
+//
+// Each line of the pattern text is a pattern. Empty lines and lines starting with `#` are ignored,
+// leading `!` characters negate the pattern, `**` matches any number of directories and a leading
+// `~` is expanded to the home directory. Relative patterns are rooted at the current working directory.
+package glob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Globber matches files and directories against the patterns it was created with.
+type Globber interface {
+	// GetSearchPaths returns the search paths preceding the first glob segment, from each pattern.
+	// Duplicates and descendants of other paths are filtered out.
+	GetSearchPaths() []string
+
+	// Glob returns the matching paths.
+	Glob(ctx context.Context) ([]string, error)
+
+	// GlobGenerator streams the matching paths. The path channel is closed when the search completes,
+	// and then the error channel receives the error which stopped the search, if any.
+	GlobGenerator(ctx context.Context) (<-chan string, <-chan error)
+}
+
+type defaultGlobber struct {
+	options     Options
+	patterns    []*pattern
+	searchPaths []string
+}
+
+// Create constructs a globber from the multiline pattern text.
+// Pattern text would normally come from core.GetInput or core.GetMultilineInput.
+func Create(patterns string, options *Options) (Globber, error) {
+	g := &defaultGlobber{options: getOptions(options)}
+
+	if isWindows {
+		patterns = strings.ReplaceAll(patterns, "\r\n", "\n")
+		patterns = strings.ReplaceAll(patterns, "\r", "\n")
+	}
+
+	for _, line := range strings.Split(patterns, "\n") {
+		line = strings.TrimSpace(line)
+		// Empty or comment
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := newPattern(line)
+		if err != nil {
+			return nil, err
+		}
+		g.patterns = append(g.patterns, p)
+	}
+
+	g.searchPaths = getSearchPaths(g.patterns)
+	return g, nil
+}
+
+func (g *defaultGlobber) GetSearchPaths() []string {
+	return append([]string(nil), g.searchPaths...)
+}
+
+func (g *defaultGlobber) Glob(ctx context.Context) ([]string, error) {
+	var result []string
+	err := g.walk(ctx, func(itemPath string) error {
+		result = append(result, itemPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (g *defaultGlobber) GlobGenerator(ctx context.Context) (<-chan string, <-chan error) {
+	paths := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		err := g.walk(ctx, func(itemPath string) error {
+			select {
+			case paths <- itemPath:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(paths)
+		errc <- err
+		close(errc)
+	}()
+	return paths, errc
+}
+
+type searchState struct {
+	path  string
+	level int
+}
+
+// walk traverses the search paths depth-first in directory order and calls fn for each match.
+func (g *defaultGlobber) walk(ctx context.Context, fn func(itemPath string) error) error {
+	patterns := make([]*pattern, 0, len(g.patterns))
+	for _, p := range g.patterns {
+		patterns = append(patterns, p)
+		if *g.options.ImplicitDescendants && (p.trailingSeparator || p.segments[len(p.segments)-1] != "**") {
+			implicit, err := newImplicitPattern(p.negate, append(append([]string(nil), p.segments...), "**"))
+			if err != nil {
+				return err
+			}
+			patterns = append(patterns, implicit)
+		}
+	}
+
+	var stack []searchState
+	for _, searchPath := range getSearchPaths(patterns) {
+		if _, err := os.Lstat(searchPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		stack = append([]searchState{{path: searchPath, level: 1}}, stack...)
+	}
+
+	var traversalChain []string
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// Match?
+		match := matchPatterns(patterns, item.path)
+		partialMatch := match != matchKindNone || partialMatchPatterns(patterns, item.path)
+		if match == matchKindNone && !partialMatch {
+			continue
+		}
+
+		// Stat
+		info, chain, err := g.stat(item, traversalChain)
+		if err != nil {
+			return err
+		}
+		traversalChain = chain
+		if info == nil {
+			continue
+		}
+
+		if info.IsDir() {
+			// Matched
+			if match&matchKindDirectory != 0 && *g.options.MatchDirectories {
+				if err := fn(item.path); err != nil {
+					return err
+				}
+			} else if !partialMatch {
+				continue
+			}
+
+			// Push the child items in reverse
+			entries, err := os.ReadDir(item.path)
+			if err != nil {
+				return err
+			}
+			for i := len(entries) - 1; i >= 0; i-- {
+				stack = append(stack, searchState{path: filepath.Join(item.path, entries[i].Name()), level: item.level + 1})
+			}
+		} else if match&matchKindFile != 0 {
+			// File
+			if err := fn(item.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stat returns nil info when the item should be skipped, because it is a broken symlink or a symlink cycle.
+// The traversal chain is the list of real paths of the ancestor directories, used to detect cycles.
+func (g *defaultGlobber) stat(item searchState, traversalChain []string) (os.FileInfo, []string, error) {
+	var (
+		info os.FileInfo
+		err  error
+	)
+	if *g.options.FollowSymbolicLinks {
+		info, err = os.Stat(item.path)
+		if os.IsNotExist(err) {
+			if *g.options.OmitBrokenSymbolicLinks {
+				return nil, traversalChain, nil
+			}
+			return nil, traversalChain, fmt.Errorf("No information found for the path '%s'. This may indicate a broken symbolic link.", item.path)
+		}
+	} else {
+		info, err = os.Lstat(item.path)
+	}
+	if err != nil {
+		return nil, traversalChain, err
+	}
+
+	// Note, IsDir() returns false for the lstat of a symlink
+	if info.IsDir() && *g.options.FollowSymbolicLinks {
+		// Get the realpath
+		realPath, err := filepath.EvalSymlinks(item.path)
+		if err != nil {
+			return nil, traversalChain, err
+		}
+
+		// Fixup the traversal chain to match the item level
+		if len(traversalChain) >= item.level {
+			traversalChain = traversalChain[:item.level-1]
+		}
+
+		// Test for a cycle
+		for _, p := range traversalChain {
+			if p == realPath {
+				return nil, traversalChain, nil
+			}
+		}
+
+		// Update the traversal chain
+		traversalChain = append(traversalChain, realPath)
+	}
+	return info, traversalChain, nil
+}
@@ -0,0 +1,412 @@
+package glob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// createTree creates the files (and directories ending with `/`) under a temporary root.
+func createTree(t *testing.T, items []string) string {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		p := filepath.Join(root, filepath.FromSlash(item))
+		if item[len(item)-1] == '/' {
+			if err := os.MkdirAll(p, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("content of "+item), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func rooted(root string, items ...string) []string {
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		if item == "" {
+			res = append(res, root)
+			continue
+		}
+		res = append(res, filepath.Join(root, filepath.FromSlash(item)))
+	}
+	return res
+}
+
+func Test_Glob(t *testing.T) {
+	root := createTree(t, []string{
+		"a/1.txt",
+		"a/2.go",
+		"a/b/3.txt",
+		"a/b/c/4.txt",
+		"a/.hidden/5.txt",
+		"d/6.go",
+		"d/[x]/7.go",
+		"empty/",
+	})
+
+	table := []struct {
+		name     string
+		patterns string
+		options  *Options
+		expected []string
+	}{
+		{
+			name:     "star",
+			patterns: root + "/a/*.txt",
+			expected: rooted(root, "a/1.txt"),
+		},
+		{
+			name:     "globstar",
+			patterns: root + "/**/*.go",
+			expected: rooted(root, "a/2.go", "d/6.go", "d/[x]/7.go"),
+		},
+		{
+			name:     "globstar matches hidden directories",
+			patterns: root + "/a/**/5.txt",
+			expected: rooted(root, "a/.hidden/5.txt"),
+		},
+		{
+			name:     "implicit descendants",
+			patterns: root + "/a/b",
+			expected: rooted(root, "a/b", "a/b/3.txt", "a/b/c", "a/b/c/4.txt"),
+		},
+		{
+			name:     "no implicit descendants",
+			patterns: root + "/a/b",
+			options:  &Options{ImplicitDescendants: ptr.Bool(false)},
+			expected: rooted(root, "a/b"),
+		},
+		{
+			name:     "no match directories",
+			patterns: root + "/a/b",
+			options:  &Options{MatchDirectories: ptr.Bool(false)},
+			expected: rooted(root, "a/b/3.txt", "a/b/c/4.txt"),
+		},
+		{
+			name:     "trailing separator matches directories only",
+			patterns: root + "/a/*/",
+			options:  &Options{ImplicitDescendants: ptr.Bool(false)},
+			expected: rooted(root, "a/.hidden", "a/b"),
+		},
+		{
+			name:     "negation",
+			patterns: root + "/a/**\n!" + root + "/a/b/**\n!!" + root + "/a/b/c/4.txt",
+			options:  &Options{MatchDirectories: ptr.Bool(false)},
+			expected: rooted(root, "a/.hidden/5.txt", "a/1.txt", "a/2.go", "a/b/c/4.txt"),
+		},
+		{
+			name:     "comments and empty lines",
+			patterns: "# comment\n\n  " + root + "/d/*.go  \n",
+			expected: rooted(root, "d/6.go"),
+		},
+		{
+			name:     "escaped character class",
+			patterns: root + "/d/[[]x]/*",
+			expected: rooted(root, "d/[x]/7.go"),
+		},
+		{
+			name:     "character class",
+			patterns: root + "/a/[12].*",
+			expected: rooted(root, "a/1.txt", "a/2.go"),
+		},
+		{
+			name:     "negated character class",
+			patterns: root + "/a/[!1].*",
+			expected: rooted(root, "a/2.go"),
+		},
+		{
+			name:     "question mark",
+			patterns: root + "/?/6.go",
+			expected: rooted(root, "d/6.go"),
+		},
+		{
+			name:     "empty directory",
+			patterns: root + "/empty",
+			expected: rooted(root, "empty"),
+		},
+		{
+			name:     "missing search path",
+			patterns: root + "/missing/**",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range table {
+		globber, err := Create(tt.patterns, tt.options)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		val, err := globber.Glob(context.Background())
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if !reflect.DeepEqual(val, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %s", tt.expected, val, tt.name)
+		}
+	}
+}
+
+func Test_GlobRelativeAndHomePatterns(t *testing.T) {
+	root := createTree(t, []string{"a/1.txt", "a/2.txt"})
+	t.Setenv("HOME", root)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, "a")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	table := []struct {
+		patterns string
+		expected []string
+	}{
+		{patterns: "~/a/1.txt", expected: rooted(root, "a/1.txt")},
+		{patterns: "./2.txt", expected: rooted(root, "a/2.txt")},
+		{patterns: "*.txt", expected: rooted(root, "a/1.txt", "a/2.txt")},
+	}
+
+	for _, tt := range table {
+		globber, err := Create(tt.patterns, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		val, err := globber.Glob(context.Background())
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if !reflect.DeepEqual(val, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %s", tt.expected, val, tt.patterns)
+		}
+	}
+}
+
+func Test_GlobSymbolicLinks(t *testing.T) {
+	root := createTree(t, []string{"real/1.txt"})
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "link")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "broken")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "real", "cycle")); err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name     string
+		options  *Options
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "follow",
+			options:  &Options{MatchDirectories: ptr.Bool(false)},
+			expected: rooted(root, "link/1.txt", "real/1.txt"),
+		},
+		{
+			name:     "no follow",
+			options:  &Options{MatchDirectories: ptr.Bool(false), FollowSymbolicLinks: ptr.Bool(false)},
+			expected: rooted(root, "broken", "link", "real/1.txt", "real/cycle"),
+		},
+		{
+			name:    "broken symlinks are errors",
+			options: &Options{MatchDirectories: ptr.Bool(false), OmitBrokenSymbolicLinks: ptr.Bool(false)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range table {
+		globber, err := Create(root, tt.options)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		val, err := globber.Glob(context.Background())
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("errors are expected but not found.\ntest case: %s", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if !reflect.DeepEqual(val, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %s", tt.expected, val, tt.name)
+		}
+	}
+}
+
+func Test_GlobGenerator(t *testing.T) {
+	root := createTree(t, []string{"1.txt", "2.txt", "3.txt"})
+	globber, err := Create(root+"/*.txt", nil)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	var val []string
+	paths, errc := globber.GlobGenerator(context.Background())
+	for p := range paths {
+		val = append(val, p)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if expected := rooted(root, "1.txt", "2.txt", "3.txt"); !reflect.DeepEqual(val, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, val)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	paths, errc = globber.GlobGenerator(ctx)
+	<-paths
+	cancel()
+	for range paths {
+	}
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("expected error is %v, but result was %v.", context.Canceled, err)
+	}
+}
+
+func Test_GetSearchPaths(t *testing.T) {
+	table := []struct {
+		patterns string
+		expected []string
+	}{
+		{patterns: "/foo/*.txt\n/foo/bar/**\n/baz/b[a]r/q*x", expected: []string{"/foo", "/baz/bar"}},
+		{patterns: "/foo/bar\n!/foo/bar/baz\n/foo", expected: []string{"/foo"}},
+		{patterns: "/foo/b\\*r/*", expected: []string{"/foo/b*r"}},
+		{patterns: "/**", expected: []string{"/"}},
+	}
+
+	for _, tt := range table {
+		globber, err := Create(tt.patterns, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if val := globber.GetSearchPaths(); !reflect.DeepEqual(val, tt.expected) {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %q", tt.expected, val, tt.patterns)
+		}
+	}
+}
+
+func Test_CreateInvalidPatterns(t *testing.T) {
+	for _, patterns := range []string{"/foo/../bar", "/foo/./bar", "!"} {
+		if _, err := Create(patterns, nil); err == nil {
+			t.Fatalf("errors are expected but not found.\ntest case: %q", patterns)
+		}
+	}
+}
+
+func Test_HashFiles(t *testing.T) {
+	root := createTree(t, []string{"a/1.txt", "a/2.txt", "b/3.txt"})
+	outside := createTree(t, []string{"4.txt"})
+
+	expected := sha256.New()
+	for _, item := range []string{"a/1.txt", "a/2.txt"} {
+		sum := sha256.Sum256([]byte("content of " + item))
+		expected.Write(sum[:])
+	}
+
+	table := []struct {
+		patterns string
+		expected string
+	}{
+		{patterns: root + "/a/**", expected: hex.EncodeToString(expected.Sum(nil))},
+		{patterns: root + "/a\n" + outside + "/4.txt", expected: hex.EncodeToString(expected.Sum(nil))},
+		{patterns: root + "/missing", expected: ""},
+	}
+
+	for _, tt := range table {
+		val, err := HashFiles(context.Background(), tt.patterns, root, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if val != tt.expected {
+			t.Fatalf("expected value is %s, but result was %s.\ntest case: %q", tt.expected, val, tt.patterns)
+		}
+	}
+
+	t.Setenv("GITHUB_WORKSPACE", root)
+	val, err := HashFiles(context.Background(), root+"/a/*", "", nil)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if val != hex.EncodeToString(expected.Sum(nil)) {
+		t.Fatalf("expected value is %x, but result was %s.", expected.Sum(nil), val)
+	}
+}
+
+func Test_HashFilesConcurrency(t *testing.T) {
+	var items []string
+	for i := 0; i < 200; i++ {
+		items = append(items, filepath.ToSlash(filepath.Join(string(rune('a'+i%5)), hex.EncodeToString([]byte{byte(i)})+".txt")))
+	}
+	root := createTree(t, items)
+
+	// The files are hashed in the order of their paths, whatever the concurrency
+	const expected = "227925c08e103cc0b929ab106c351695501dff1eb53785e881005a303767c521"
+	for _, concurrency := range []int{1, 4, 64} {
+		val, err := HashFiles(context.Background(), root+"/**", root, &HashFileOptions{Concurrency: ptr.Int(concurrency)})
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if val != expected {
+			t.Fatalf("expected value is %s, but result was %s.\nconcurrency: %d", expected, val, concurrency)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := HashFiles(ctx, root+"/**", root, nil); err != context.Canceled {
+		t.Fatalf("expected error is %v, but result was %v.", context.Canceled, err)
+	}
+}
+
+// cancelingGlobber yields its paths, then cancels the search and completes without an error.
+type cancelingGlobber struct {
+	Globber
+	paths  []string
+	cancel context.CancelFunc
+}
+
+func (g *cancelingGlobber) GlobGenerator(ctx context.Context) (<-chan string, <-chan error) {
+	paths := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(paths)
+		for _, path := range g.paths {
+			paths <- path
+		}
+		g.cancel()
+	}()
+	return paths, errc
+}
+
+func Test_HashMatchesCanceled(t *testing.T) {
+	root := createTree(t, []string{"a.txt", "b.txt"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globber := &cancelingGlobber{paths: []string{filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")}, cancel: cancel}
+	if _, err := hashMatches(ctx, globber, root, 1); err != context.Canceled {
+		t.Fatalf("expected error is %v, but result was %v.", context.Canceled, err)
+	}
+}
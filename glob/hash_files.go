@@ -0,0 +1,190 @@
+package glob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// HashFileOptions controls the globbing behavior of HashFiles.
+type HashFileOptions struct {
+	/** Optional. Indicates whether to follow symbolic links. Defaults to true */
+	FollowSymbolicLinks *bool
+
+	/** Optional. The maximum number of files read and hashed concurrently. Defaults to the number of CPUs */
+	Concurrency *int
+}
+
+// HashFiles computes the same SHA-256 as the `hashFiles()` expression function.
+// Only regular files under currentWorkspace are hashed; when currentWorkspace is empty,
+// GITHUB_WORKSPACE is used, falling back to the current working directory.
+// Returns an empty string if no file matches.
+//
+// The files are read by a bounded pool of workers, but the per-file digests are fed into
+// the result in the order the globber yields the matches, so the result does not depend on concurrency.
+func HashFiles(ctx context.Context, patterns string, currentWorkspace string, options *HashFileOptions) (string, error) {
+	followSymbolicLinks := true
+	concurrency := runtime.NumCPU()
+	if options != nil && options.FollowSymbolicLinks != nil {
+		followSymbolicLinks = *options.FollowSymbolicLinks
+	}
+	if options != nil && options.Concurrency != nil && *options.Concurrency > 0 {
+		concurrency = *options.Concurrency
+	}
+
+	globber, err := Create(patterns, &Options{FollowSymbolicLinks: &followSymbolicLinks})
+	if err != nil {
+		return "", err
+	}
+
+	workspace, err := resolveWorkspace(currentWorkspace)
+	if err != nil {
+		return "", err
+	}
+
+	sums, err := hashMatches(ctx, globber, workspace, concurrency)
+	if err != nil {
+		return "", err
+	}
+
+	result := sha256.New()
+	hasMatch := false
+	for _, sum := range sums {
+		// Directories have no digest
+		if sum == nil {
+			continue
+		}
+		result.Write(sum)
+		hasMatch = true
+	}
+
+	if !hasMatch {
+		return "", nil
+	}
+	return hex.EncodeToString(result.Sum(nil)), nil
+}
+
+func resolveWorkspace(currentWorkspace string) (string, error) {
+	if currentWorkspace != "" {
+		return currentWorkspace, nil
+	}
+	if workspace, ok := os.LookupEnv("GITHUB_WORKSPACE"); ok {
+		return workspace, nil
+	}
+	return os.Getwd()
+}
+
+type hashJob struct {
+	index int
+	file  string
+}
+
+type hashResult struct {
+	index int
+	sum   []byte
+	err   error
+}
+
+// hashMatches returns the digests of the matches under the workspace, in match order.
+// The digest of a directory is nil.
+func hashMatches(parent context.Context, globber Globber, workspace string, concurrency int) ([][]byte, error) {
+	// Stop the search and the workers when returning early
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	jobs := make(chan hashJob)
+	results := make(chan hashResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				sum, err := hashFile(job.file)
+				results <- hashResult{index: job.index, sum: sum, err: err}
+			}
+		}()
+	}
+
+	// The producer enumerates the matches. Its error and the number of jobs are only
+	// read after the results channel is closed.
+	var (
+		count   int
+		globErr error
+	)
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		defer close(jobs)
+
+		paths, errc := globber.GlobGenerator(ctx)
+		for file := range paths {
+			if !strings.HasPrefix(file, workspace+sep) {
+				continue
+			}
+			select {
+			case jobs <- hashJob{index: count, file: file}:
+				count++
+			case <-ctx.Done():
+			}
+		}
+		globErr = <-errc
+	}()
+
+	sums := map[int][]byte{}
+	var hashErr error
+	for result := range results {
+		if result.err != nil {
+			if hashErr == nil {
+				hashErr = result.err
+				cancel()
+			}
+			continue
+		}
+		sums[result.index] = result.sum
+	}
+	if hashErr != nil {
+		return nil, hashErr
+	}
+	// The paths dropped on cancellation are not hashed
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	if globErr != nil {
+		return nil, globErr
+	}
+
+	ordered := make([][]byte, count)
+	for i := range ordered {
+		ordered[i] = sums[i]
+	}
+	return ordered, nil
+}
+
+// hashFile returns the SHA-256 of the file content, or nil if the path is a directory.
+func hashFile(file string) ([]byte, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
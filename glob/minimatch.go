@@ -0,0 +1,182 @@
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// minimatch implements the subset of the minimatch npm package used by @actions/glob,
+// i.e. the options `{dot: true, nobrace: true, nocase: IS_WINDOWS, nocomment: true, noext: true, nonegate: true}`.
+type minimatch struct {
+	set    []minimatchSegment
+	nocase bool
+}
+
+// minimatchSegment is either a globstar, a literal string or a compiled regular expression.
+type minimatchSegment struct {
+	globstar bool
+	literal  string
+	re       *regexp.Regexp
+}
+
+var slashSplitRegexp = regexp.MustCompile(`/+`)
+
+func newMinimatch(pattern string, nocase bool) *minimatch {
+	parts := slashSplitRegexp.Split(pattern, -1)
+	set := make([]minimatchSegment, 0, len(parts))
+	for _, part := range parts {
+		set = append(set, parseMinimatchSegment(part, nocase))
+	}
+	return &minimatch{set: set, nocase: nocase}
+}
+
+// parseMinimatchSegment converts a single path segment of the pattern.
+// Segments without magic characters are unescaped and compared literally.
+func parseMinimatchSegment(segment string, nocase bool) minimatchSegment {
+	if segment == "**" {
+		return minimatchSegment{globstar: true}
+	}
+
+	var (
+		re       strings.Builder
+		literal  strings.Builder
+		hasMagic bool
+	)
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		switch {
+		case c == '\\' && i+1 < len(segment):
+			i++
+			re.WriteString(regexp.QuoteMeta(string(segment[i])))
+			literal.WriteByte(segment[i])
+		case c == '*':
+			hasMagic = true
+			re.WriteString(`[^/]*?`)
+		case c == '?':
+			hasMagic = true
+			re.WriteString(`[^/]`)
+		case c == '[':
+			if class, end, ok := parseCharacterClass(segment, i); ok {
+				hasMagic = true
+				re.WriteString(class)
+				i = end
+				continue
+			}
+			re.WriteString(`\[`)
+			literal.WriteByte(c)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+			literal.WriteByte(c)
+		}
+	}
+
+	if !hasMagic {
+		return minimatchSegment{literal: literal.String()}
+	}
+	flags := ""
+	if nocase {
+		flags = "(?i)"
+	}
+	return minimatchSegment{re: regexp.MustCompile(flags + "^(?:" + re.String() + ")$")}
+}
+
+// parseCharacterClass parses the bracket expression starting at segment[start].
+// It returns false when the class is not closed or is invalid, in which case `[` is literal.
+func parseCharacterClass(segment string, start int) (string, int, bool) {
+	var class strings.Builder
+	class.WriteByte('[')
+	for i := start + 1; i < len(segment); i++ {
+		c := segment[i]
+		switch {
+		case c == '!' && i == start+1:
+			class.WriteByte('^')
+		case c == ']' && i > start+1:
+			class.WriteByte(']')
+			if _, err := regexp.Compile(class.String()); err != nil {
+				return "", 0, false
+			}
+			return class.String(), i, true
+		case c == '\\' && i+1 < len(segment):
+			i++
+			class.WriteString(`\` + string(segment[i]))
+		case c == '[' || c == ']' || c == '\\':
+			// A right bracket loses its special meaning when it occurs first in the list
+			class.WriteString(`\` + string(c))
+		default:
+			class.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+// match tests the whole path against the pattern.
+func (m *minimatch) match(itemPath string) bool {
+	if isWindows {
+		itemPath = strings.ReplaceAll(itemPath, `\`, "/")
+	}
+	return m.matchOne(slashSplitRegexp.Split(itemPath, -1), m.set, false)
+}
+
+// matchOne tests the path segments against the pattern segments. When partial is true,
+// running out of path segments before running out of pattern segments is considered a match.
+func (m *minimatch) matchOne(file []string, pattern []minimatchSegment, partial bool) bool {
+	fi, pi := 0, 0
+	for ; fi < len(file) && pi < len(pattern); fi, pi = fi+1, pi+1 {
+		p, f := pattern[pi], file[fi]
+
+		if p.globstar {
+			// A ** at the end will just swallow the rest
+			if pi+1 == len(pattern) {
+				for ; fi < len(file); fi++ {
+					if file[fi] == "." || file[fi] == ".." {
+						return false
+					}
+				}
+				return true
+			}
+
+			// Swallow as much as we can
+			for fr := fi; fr < len(file); fr++ {
+				if m.matchOne(file[fr:], pattern[pi+1:], partial) {
+					return true
+				}
+				if file[fr] == "." || file[fr] == ".." {
+					return false
+				}
+			}
+
+			// In partial mode, we can't reject it yet
+			return partial
+		}
+
+		if !m.matchSegment(p, f) {
+			return false
+		}
+	}
+
+	switch {
+	case fi == len(file) && pi == len(pattern):
+		return true
+	case fi == len(file):
+		// Ran out of file, but still had pattern left
+		return partial
+	default:
+		// Ran out of pattern, still have file left. This is only acceptable on the very last
+		// empty segment of a file with a trailing slash, e.g. `a/*` matches `a/b/`.
+		return fi == len(file)-1 && file[fi] == ""
+	}
+}
+
+func (m *minimatch) matchSegment(p minimatchSegment, f string) bool {
+	if p.re == nil {
+		if m.nocase {
+			return strings.EqualFold(f, p.literal)
+		}
+		return f == p.literal
+	}
+	// With `dot: true`, wildcards still never match the `.` and `..` segments
+	if f == "." || f == ".." {
+		return false
+	}
+	return p.re.MatchString(f)
+}
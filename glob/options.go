@@ -0,0 +1,44 @@
+package glob
+
+import "github.com/ci-tools/toolkit/ptr"
+
+// Options controls globbing behavior.
+type Options struct {
+	/** Optional. Indicates whether to follow symbolic links. Generally should set to false when deleting files. Defaults to true */
+	FollowSymbolicLinks *bool
+
+	/** Optional. Indicates whether directories that match a glob pattern, should implicitly cause all descendant paths to be matched. Defaults to true */
+	ImplicitDescendants *bool
+
+	/** Optional. Indicates whether matching directories should be included in the result set. Defaults to true */
+	MatchDirectories *bool
+
+	/** Optional. Indicates whether broken symbolic should be ignored and omitted from the result set. Otherwise an error will be returned. Defaults to true */
+	OmitBrokenSymbolicLinks *bool
+}
+
+// getOptions returns a copy of the options with defaults applied.
+func getOptions(options *Options) Options {
+	result := Options{
+		FollowSymbolicLinks:     ptr.Bool(true),
+		ImplicitDescendants:     ptr.Bool(true),
+		MatchDirectories:        ptr.Bool(true),
+		OmitBrokenSymbolicLinks: ptr.Bool(true),
+	}
+	if options == nil {
+		return result
+	}
+	if options.FollowSymbolicLinks != nil {
+		result.FollowSymbolicLinks = ptr.Bool(*options.FollowSymbolicLinks)
+	}
+	if options.ImplicitDescendants != nil {
+		result.ImplicitDescendants = ptr.Bool(*options.ImplicitDescendants)
+	}
+	if options.MatchDirectories != nil {
+		result.MatchDirectories = ptr.Bool(*options.MatchDirectories)
+	}
+	if options.OmitBrokenSymbolicLinks != nil {
+		result.OmitBrokenSymbolicLinks = ptr.Bool(*options.OmitBrokenSymbolicLinks)
+	}
+	return result
+}
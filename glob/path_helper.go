@@ -0,0 +1,193 @@
+package glob
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const isWindows = runtime.GOOS == "windows"
+
+const sep = string(filepath.Separator)
+
+var (
+	windowsDriveRootRegexp   = regexp.MustCompile(`(?i)^[A-Z]:\\$`)
+	windowsDriveRegexp       = regexp.MustCompile(`(?i)^[A-Z]:`)
+	windowsAbsoluteRegexp    = regexp.MustCompile(`(?i)^[A-Z]:\\`)
+	windowsUNCRootRegexp     = regexp.MustCompile(`^\\\\[^\\]+(\\[^\\]+)?$`)
+	windowsUNCShareRegexp    = regexp.MustCompile(`^\\\\[^\\]+\\[^\\]+\\$`)
+	windowsUNCPrefixRegexp   = regexp.MustCompile(`^\\\\+[^\\]`)
+	windowsRepeatedSepRegexp = regexp.MustCompile(`\\\\+`)
+	posixRepeatedSepRegexp   = regexp.MustCompile(`//+`)
+)
+
+// dirname is similar to path.dirname of Node.js, except it does not modify the path on Windows
+// when the path is a UNC root, and it does not resolve `.` or `..` segments.
+func dirname(p string) string {
+	p = safeTrimTrailingSeparator(p)
+	if isWindows && windowsUNCRootRegexp.MatchString(p) {
+		return p
+	}
+
+	vol := volumeName(p)
+	rest := p[len(vol):]
+	var result string
+	switch i := strings.LastIndex(rest, sep); {
+	case i < 0 && vol != "":
+		result = vol
+	case i < 0:
+		result = "."
+	case i == 0:
+		result = vol + sep
+	default:
+		result = vol + rest[:i]
+	}
+
+	if isWindows && windowsUNCShareRegexp.MatchString(result) {
+		result = safeTrimTrailingSeparator(result)
+	}
+	return result
+}
+
+// basename returns the last segment of the path.
+func basename(p string) string {
+	p = safeTrimTrailingSeparator(p)
+	rest := p[len(volumeName(p)):]
+	if i := strings.LastIndex(rest, sep); i >= 0 {
+		return rest[i+1:]
+	}
+	return rest
+}
+
+func volumeName(p string) string {
+	if !isWindows {
+		return ""
+	}
+	return filepath.VolumeName(p)
+}
+
+// ensureAbsoluteRoot roots the path if not already rooted.
+// On Windows, relative roots like `\` or `C:` are expanded based on the current working directory.
+func ensureAbsoluteRoot(root, itemPath string) string {
+	// Already rooted
+	if hasAbsoluteRoot(itemPath) {
+		return itemPath
+	}
+
+	if isWindows {
+		// Check for itemPath like C: or C:foo
+		if windowsDriveRegexp.MatchString(itemPath) {
+			cwd, _ := filepath.Abs(".")
+			if strings.EqualFold(cwd[:2], itemPath[:2]) {
+				// Current drive
+				if len(itemPath) == 2 {
+					return cwd
+				}
+				if !strings.HasSuffix(cwd, sep) {
+					cwd += sep
+				}
+				return cwd + itemPath[2:]
+			}
+			// Different drive
+			return itemPath[:2] + sep + itemPath[2:]
+		}
+		// Check for itemPath like \ or \foo
+		if normalizeSeparators(itemPath) == sep || strings.HasPrefix(normalizeSeparators(itemPath), sep) {
+			cwd, _ := filepath.Abs(".")
+			return cwd[:2] + sep + itemPath[1:]
+		}
+	}
+
+	// Otherwise ensure root ends with a separator
+	if !strings.HasSuffix(root, "/") && !(isWindows && strings.HasSuffix(root, `\`)) {
+		root += sep
+	}
+	return root + itemPath
+}
+
+// hasAbsoluteRoot reports whether the path has a root like `/`, `C:\`, or `\\hello\share`.
+func hasAbsoluteRoot(itemPath string) bool {
+	itemPath = normalizeSeparators(itemPath)
+	if isWindows {
+		return strings.HasPrefix(itemPath, `\\`) || windowsAbsoluteRegexp.MatchString(itemPath)
+	}
+	return strings.HasPrefix(itemPath, "/")
+}
+
+// hasRoot reports whether the path has a root, including relative roots like `\` or `C:` on Windows.
+func hasRoot(itemPath string) bool {
+	itemPath = normalizeSeparators(itemPath)
+	if isWindows {
+		return strings.HasPrefix(itemPath, `\`) || windowsDriveRegexp.MatchString(itemPath)
+	}
+	return strings.HasPrefix(itemPath, "/")
+}
+
+// normalizeSeparators removes redundant slashes and converts `/` to `\` on Windows.
+func normalizeSeparators(p string) string {
+	if isWindows {
+		p = strings.ReplaceAll(p, "/", `\`)
+		prefix := ""
+		if windowsUNCPrefixRegexp.MatchString(p) {
+			prefix = `\`
+		}
+		return prefix + windowsRepeatedSepRegexp.ReplaceAllString(p, `\`)
+	}
+	return posixRepeatedSepRegexp.ReplaceAllString(p, "/")
+}
+
+// safeTrimTrailingSeparator normalizes the separators and trims the trailing separator when safe.
+// For example, `/foo/ => /foo` but `/ => /`.
+func safeTrimTrailingSeparator(p string) string {
+	if p == "" {
+		return ""
+	}
+	p = normalizeSeparators(p)
+	if !strings.HasSuffix(p, sep) {
+		return p
+	}
+	if p == sep {
+		return p
+	}
+	if isWindows && windowsDriveRootRegexp.MatchString(p) {
+		return p
+	}
+	return p[:len(p)-1]
+}
+
+// splitPath splits the path into segments, where the first segment is the root, if any.
+// For example, `/foo/bar => [/ foo bar]`.
+func splitPath(itemPath string) []string {
+	itemPath = safeTrimTrailingSeparator(itemPath)
+
+	// Not rooted
+	if !hasRoot(itemPath) {
+		return strings.Split(itemPath, sep)
+	}
+
+	// Rooted
+	var segments []string
+	remaining := itemPath
+	for dir := dirname(remaining); dir != remaining; dir = dirname(remaining) {
+		segments = append([]string{basename(remaining)}, segments...)
+		remaining = dir
+	}
+	// Remainder is the root
+	return append([]string{remaining}, segments...)
+}
+
+// joinSegments is the inverse of splitPath.
+func joinSegments(segments []string) string {
+	result := segments[0]
+	skipSep := strings.HasSuffix(result, sep) || (isWindows && len(result) == 2 && windowsDriveRegexp.MatchString(result))
+	for _, segment := range segments[1:] {
+		if skipSep {
+			skipSep = false
+		} else {
+			result += sep
+		}
+		result += segment
+	}
+	return result
+}
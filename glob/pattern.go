@@ -0,0 +1,393 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// matchKind indicates whether matches should be restricted to directories or files.
+type matchKind int
+
+const (
+	/** Not matched */
+	matchKindNone matchKind = 0
+	/** Matched if the path is a directory */
+	matchKindDirectory matchKind = 1
+	/** Matched if the path is a regular file */
+	matchKindFile matchKind = 2
+	/** Matched */
+	matchKindAll = matchKindDirectory | matchKindFile
+)
+
+// pattern is a single line of the multiline pattern text.
+type pattern struct {
+	// negate indicates whether matches should be excluded from the result set
+	negate bool
+
+	// segments is the path segments parsed from the pattern
+	segments []string
+
+	// trailingSeparator indicates the pattern should only match directories
+	trailingSeparator bool
+
+	// searchPath is the literal path prior to the first glob segment
+	searchPath string
+
+	// isImplicitPattern indicates the pattern was added by the implicitDescendants option
+	isImplicitPattern bool
+
+	// rootRegexp is required when determining partial match
+	rootRegexp *regexp.Regexp
+
+	minimatch *minimatch
+}
+
+// newPattern parses a pattern line. Leading `!` characters toggle negation.
+func newPattern(text string) (*pattern, error) {
+	return parsePattern(strings.TrimSpace(text), false)
+}
+
+// newImplicitPattern creates a pattern from segments. The first segment must be a root path.
+func newImplicitPattern(negate bool, segments []string) (*pattern, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("Parameter 'segments' must not empty")
+	}
+	if root := getLiteral(segments[0]); root == "" || !hasAbsoluteRoot(root) {
+		return nil, errors.New("Parameter 'segments' first element must be a root path")
+	}
+	text := strings.TrimSpace(joinSegments(segments))
+	if negate {
+		text = "!" + text
+	}
+	return parsePattern(text, true)
+}
+
+func parsePattern(text string, isImplicitPattern bool) (*pattern, error) {
+	p := &pattern{isImplicitPattern: isImplicitPattern}
+
+	// Negate
+	for strings.HasPrefix(text, "!") {
+		p.negate = !p.negate
+		text = strings.TrimSpace(text[1:])
+	}
+
+	// Normalize slashes and ensures absolute root
+	text, err := fixupPattern(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Segments
+	p.segments = splitPath(text)
+
+	// Trailing slash indicates the pattern should only match directories, not regular files
+	p.trailingSeparator = strings.HasSuffix(normalizeSeparators(text), sep)
+	text = safeTrimTrailingSeparator(text)
+
+	// Search path (literal path prior to the first glob segment)
+	var searchSegments []string
+	for _, segment := range p.segments {
+		literal := getLiteral(segment)
+		if literal == "" {
+			break
+		}
+		searchSegments = append(searchSegments, literal)
+	}
+	p.searchPath = joinSegments(searchSegments)
+
+	// Root RegExp (required when determining partial match)
+	flags := ""
+	if isWindows {
+		flags = "(?i)"
+	}
+	p.rootRegexp = regexp.MustCompile(flags + "^" + regexp.QuoteMeta(searchSegments[0]))
+
+	if isWindows {
+		text = strings.ReplaceAll(text, `\`, "/")
+	}
+	p.minimatch = newMinimatch(text, isWindows)
+
+	return p, nil
+}
+
+// match tests the path against the pattern.
+func (p *pattern) match(itemPath string) matchKind {
+	if p.segments[len(p.segments)-1] == "**" {
+		// Normalize slashes
+		itemPath = normalizeSeparators(itemPath)
+
+		// Append a trailing slash. Otherwise minimatch will not match the directory immediately
+		// preceding the globstar. For example, given the pattern `/foo/**`, minimatch returns
+		// false for `/foo` but returns true for `/foo/`.
+		if !strings.HasSuffix(itemPath, sep) && !p.isImplicitPattern {
+			// Note, this is safe because the constructor ensures the pattern has an absolute root.
+			itemPath += sep
+		}
+	} else {
+		// Normalize slashes and trim unnecessary trailing slash
+		itemPath = safeTrimTrailingSeparator(itemPath)
+	}
+
+	if p.minimatch.match(itemPath) {
+		if p.trailingSeparator {
+			return matchKindDirectory
+		}
+		return matchKindAll
+	}
+	return matchKindNone
+}
+
+// partialMatch reports whether the path might match the pattern after descending further.
+func (p *pattern) partialMatch(itemPath string) bool {
+	// Normalize slashes and trim unnecessary trailing slash
+	itemPath = safeTrimTrailingSeparator(itemPath)
+
+	// matchOne does not handle root path correctly
+	if dirname(itemPath) == itemPath {
+		return p.rootRegexp.MatchString(itemPath)
+	}
+
+	var file []string
+	if isWindows {
+		file = windowsRepeatedOrSingleSepRegexp.Split(itemPath, -1)
+	} else {
+		file = slashSplitRegexp.Split(itemPath, -1)
+	}
+	return p.minimatch.matchOne(file, p.minimatch.set, true)
+}
+
+var windowsRepeatedOrSingleSepRegexp = regexp.MustCompile(`\\+`)
+
+// fixupPattern normalizes slashes and ensures an absolute root.
+func fixupPattern(text string) (string, error) {
+	// Empty
+	if text == "" {
+		return "", errors.New("pattern cannot be empty")
+	}
+
+	// Must not contain `.` segment, unless first segment
+	// Must not contain `..` segment
+	segments := splitPath(text)
+	literalSegments := make([]string, len(segments))
+	for i, segment := range segments {
+		literalSegments[i] = getLiteral(segment)
+		if (literalSegments[i] == "." && i != 0) || literalSegments[i] == ".." {
+			return "", fmt.Errorf("Invalid pattern '%s'. Relative pathing '.' and '..' is not allowed.", text)
+		}
+	}
+
+	// Must not contain globs in root, e.g. Windows UNC path \\foo\b*r
+	if hasRoot(text) && literalSegments[0] == "" {
+		return "", fmt.Errorf("Invalid pattern '%s'. Root segment must not contain globs.", text)
+	}
+
+	// Normalize slashes
+	text = normalizeSeparators(text)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	// Replace leading `.` segment
+	case text == "." || strings.HasPrefix(text, "."+sep):
+		text = globEscape(cwd) + text[1:]
+
+	// Replace leading `~` segment
+	case text == "~" || strings.HasPrefix(text, "~"+sep):
+		homedir, _ := os.UserHomeDir()
+		if homedir == "" {
+			return "", errors.New("Unable to determine HOME directory")
+		}
+		if !hasAbsoluteRoot(homedir) {
+			return "", fmt.Errorf("Expected HOME directory to be a rooted path. Actual '%s'", homedir)
+		}
+		text = globEscape(homedir) + text[1:]
+
+	// Replace relative drive root, e.g. pattern is C: or C:foo
+	case isWindows && windowsDriveRegexp.MatchString(text) && !windowsAbsoluteRegexp.MatchString(text):
+		root := ensureAbsoluteRoot(`C:\dummy-root`, text[:2])
+		if len(text) > 2 && !strings.HasSuffix(root, `\`) {
+			root += `\`
+		}
+		text = globEscape(root) + text[2:]
+
+	// Replace relative root, e.g. pattern is \ or \foo
+	case isWindows && (text == `\` || (strings.HasPrefix(text, `\`) && !strings.HasPrefix(text, `\\`))):
+		root := ensureAbsoluteRoot(`C:\dummy-root`, `\`)
+		if !strings.HasSuffix(root, `\`) {
+			root += `\`
+		}
+		text = globEscape(root) + text[1:]
+
+	// Otherwise ensure absolute root
+	default:
+		text = ensureAbsoluteRoot(cwd, text)
+	}
+
+	return normalizeSeparators(text), nil
+}
+
+// getLiteral attempts to unescape a pattern segment to create a literal path segment.
+// Otherwise returns empty string.
+func getLiteral(segment string) string {
+	var literal strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+
+		// Escape
+		if c == '\\' && !isWindows && i+1 < len(segment) {
+			i++
+			literal.WriteByte(segment[i])
+			continue
+		}
+
+		// Wildcard
+		if c == '*' || c == '?' {
+			return ""
+		}
+
+		// Character set
+		if c == '[' && i+1 < len(segment) {
+			var set strings.Builder
+			closed := -1
+			for i2 := i + 1; i2 < len(segment); i2++ {
+				c2 := segment[i2]
+
+				// Escape
+				if c2 == '\\' && !isWindows && i2+1 < len(segment) {
+					i2++
+					set.WriteByte(segment[i2])
+					continue
+				}
+
+				// Closed
+				if c2 == ']' {
+					closed = i2
+					break
+				}
+
+				set.WriteByte(c2)
+			}
+
+			if closed >= 0 {
+				// Cannot convert
+				if set.Len() > 1 {
+					return ""
+				}
+
+				// Convert to literal
+				if set.Len() == 1 {
+					literal.WriteString(set.String())
+					i = closed
+					continue
+				}
+			}
+			// Otherwise fall thru
+		}
+
+		literal.WriteByte(c)
+	}
+	return literal.String()
+}
+
+var globEscapeBracketRegexp = regexp.MustCompile(`^\[[^/]+\]`)
+
+// globEscape escapes glob patterns within a path.
+func globEscape(s string) string {
+	if !isWindows {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	s = escapeOpeningBrackets(s)
+	s = strings.ReplaceAll(s, "?", "[?]")
+	return strings.ReplaceAll(s, "*", "[*]")
+}
+
+// escapeOpeningBrackets replaces each `[` followed by a closing `]` in the same segment with `[[]`.
+func escapeOpeningBrackets(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '[' && globEscapeBracketRegexp.MatchString(s[i:]) {
+			b.WriteString("[[]")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// getSearchPaths returns the search paths of the non-negated patterns, excluding
+// the paths which have an ancestor in the results.
+func getSearchPaths(patterns []*pattern) []string {
+	key := func(p string) string {
+		if isWindows {
+			return strings.ToUpper(p)
+		}
+		return p
+	}
+
+	// Create a map of all search paths
+	const candidate, included = 1, 2
+	searchPathMap := map[string]int{}
+	for _, p := range patterns {
+		if !p.negate {
+			searchPathMap[key(p.searchPath)] = candidate
+		}
+	}
+
+	var result []string
+	for _, p := range patterns {
+		if p.negate {
+			continue
+		}
+
+		// Check if already included
+		k := key(p.searchPath)
+		if searchPathMap[k] == included {
+			continue
+		}
+
+		// Check for an ancestor search path
+		foundAncestor := false
+		for temp, parent := k, dirname(k); parent != temp; temp, parent = parent, dirname(parent) {
+			if searchPathMap[parent] != 0 {
+				foundAncestor = true
+				break
+			}
+		}
+
+		// Include the search pattern in the result
+		if !foundAncestor {
+			result = append(result, p.searchPath)
+			searchPathMap[k] = included
+		}
+	}
+	return result
+}
+
+// matchPatterns matches the patterns against the path. Negated patterns exclude prior matches.
+func matchPatterns(patterns []*pattern, itemPath string) matchKind {
+	result := matchKindNone
+	for _, p := range patterns {
+		if p.negate {
+			result &^= p.match(itemPath)
+		} else {
+			result |= p.match(itemPath)
+		}
+	}
+	return result
+}
+
+// partialMatchPatterns reports whether any non-negated pattern partially matches the path.
+func partialMatchPatterns(patterns []*pattern, itemPath string) bool {
+	for _, p := range patterns {
+		if !p.negate && p.partialMatch(itemPath) {
+			return true
+		}
+	}
+	return false
+}
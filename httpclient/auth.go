@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+// RequestHandler can modify the requests sent by the client and respond to authentication challenges.
+type RequestHandler interface {
+	// PrepareRequest is called before each request is sent, e.g. to add an Authorization header.
+	PrepareRequest(req *http.Request)
+
+	// CanHandleAuthentication reports whether the handler can respond to the 401 response.
+	CanHandleAuthentication(resp *http.Response) bool
+
+	// HandleAuthentication responds to the authentication challenge and returns the final response.
+	HandleAuthentication(client *Client, req *http.Request) (*http.Response, error)
+}
+
+var errNotImplemented = errors.New("not implemented")
+
+// BasicCredentialHandler authenticates requests with the Basic scheme.
+type BasicCredentialHandler struct {
+	Username string
+	Password string
+}
+
+func (h *BasicCredentialHandler) PrepareRequest(req *http.Request) {
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(h.Username+":"+h.Password)))
+}
+
+// CanHandleAuthentication always returns false. This handler only prepares requests.
+func (h *BasicCredentialHandler) CanHandleAuthentication(*http.Response) bool {
+	return false
+}
+
+func (h *BasicCredentialHandler) HandleAuthentication(*Client, *http.Request) (*http.Response, error) {
+	return nil, errNotImplemented
+}
+
+// BearerCredentialHandler authenticates requests with the Bearer scheme.
+type BearerCredentialHandler struct {
+	Token string
+}
+
+func (h *BearerCredentialHandler) PrepareRequest(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+h.Token)
+}
+
+// CanHandleAuthentication always returns false. This handler only prepares requests.
+func (h *BearerCredentialHandler) CanHandleAuthentication(*http.Response) bool {
+	return false
+}
+
+func (h *BearerCredentialHandler) HandleAuthentication(*Client, *http.Request) (*http.Response, error) {
+	return nil, errNotImplemented
+}
+
+// PersonalAccessTokenCredentialHandler authenticates requests with a personal access token,
+// sent as the password of the Basic scheme.
+type PersonalAccessTokenCredentialHandler struct {
+	Token string
+}
+
+func (h *PersonalAccessTokenCredentialHandler) PrepareRequest(req *http.Request) {
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("PAT:"+h.Token)))
+}
+
+// CanHandleAuthentication always returns false. This handler only prepares requests.
+func (h *PersonalAccessTokenCredentialHandler) CanHandleAuthentication(*http.Response) bool {
+	return false
+}
+
+func (h *PersonalAccessTokenCredentialHandler) HandleAuthentication(*Client, *http.Request) (*http.Response, error) {
+	return nil, errNotImplemented
+}
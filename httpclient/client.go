@@ -0,0 +1,451 @@
+// Package httpclient is an HTTP client for actions, modelled after @actions/http-client.
+//
+// The client sets a user agent, lets RequestHandlers authenticate requests, retries
+// idempotent requests with exponential backoff and honors the proxy environment variables
+// with the same no_proxy rules as the runner.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exponentialBackoffCeiling   = 10
+	exponentialBackoffTimeSlice = 5 * time.Millisecond
+)
+
+// RequestOptions controls the behavior of the client.
+type RequestOptions struct {
+	/** Optional. Headers sent with every request, taking precedence over the default Accept and Content-Type of the JSON helpers */
+	Headers http.Header
+
+	/** Optional. Time limit for requests including reading the response body. Defaults to no timeout */
+	Timeout *time.Duration
+
+	/** Optional. Whether TLS certificate errors are ignored. Defaults to false */
+	IgnoreSSLError *bool
+
+	/** Optional. Whether redirects are followed. Defaults to true */
+	AllowRedirects *bool
+
+	/** Optional. Whether redirects from HTTPS to HTTP are followed. Defaults to false */
+	AllowRedirectDowngrade *bool
+
+	/** Optional. The maximum number of redirects followed. Defaults to 50 */
+	MaxRedirects *int
+
+	/** Optional. The maximum number of connections per host. Defaults to no limit */
+	MaxSockets *int
+
+	/** Optional. Whether connections are reused. Defaults to true */
+	KeepAlive *bool
+
+	/** Optional. Whether idempotent requests are retried on 5xx and 429 responses. Defaults to false */
+	AllowRetries *bool
+
+	/** Optional. The maximum number of retries. Defaults to 1 */
+	MaxRetries *int
+
+	/** Optional. The transport used to send requests, e.g. to stub the network in tests. Overrides IgnoreSSLError, MaxSockets and KeepAlive. Defaults to a transport honoring the proxy environment variables */
+	Transport http.RoundTripper
+}
+
+// TypedResponse is the response of the JSON helpers. The body is decoded into the value
+// passed to the helper.
+type TypedResponse struct {
+	StatusCode int
+	Headers    http.Header
+}
+
+// HTTPError is returned by the JSON helpers when the status code is greater than 299.
+type HTTPError struct {
+	Message    string
+	StatusCode int
+
+	// Result is the response body when it is valid JSON
+	Result json.RawMessage
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// Client sends HTTP requests.
+type Client struct {
+	UserAgent string
+	Handlers  []RequestHandler
+
+	headers      http.Header
+	allowRetries bool
+	maxRetries   int
+	client       *http.Client
+}
+
+// NewClient creates a client. options may be nil.
+func NewClient(userAgent string, handlers []RequestHandler, options *RequestOptions) *Client {
+	if options == nil {
+		options = &RequestOptions{}
+	}
+
+	c := &Client{
+		UserAgent:  userAgent,
+		Handlers:   handlers,
+		headers:    options.Headers,
+		maxRetries: 1,
+	}
+	if options.AllowRetries != nil {
+		c.allowRetries = *options.AllowRetries
+	}
+	if options.MaxRetries != nil {
+		c.maxRetries = *options.MaxRetries
+		if c.maxRetries < 0 {
+			c.maxRetries = 0
+		}
+	}
+
+	allowRedirects := true
+	if options.AllowRedirects != nil {
+		allowRedirects = *options.AllowRedirects
+	}
+	allowRedirectDowngrade := false
+	if options.AllowRedirectDowngrade != nil {
+		allowRedirectDowngrade = *options.AllowRedirectDowngrade
+	}
+	maxRedirects := 50
+	if options.MaxRedirects != nil {
+		maxRedirects = *options.MaxRedirects
+	}
+
+	transport := &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return GetProxyURL(req.URL)
+		},
+		TLSClientConfig:     &tls.Config{},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if options.IgnoreSSLError != nil && *options.IgnoreSSLError {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	if options.MaxSockets != nil {
+		transport.MaxConnsPerHost = *options.MaxSockets
+	}
+	if options.KeepAlive != nil && !*options.KeepAlive {
+		transport.DisableKeepAlives = true
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if options.Transport != nil {
+		roundTripper = options.Transport
+	}
+
+	c.client = &http.Client{
+		Transport: roundTripper,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !allowRedirects || len(via) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" && !allowRedirectDowngrade {
+				return errors.New("Redirect from HTTPS to HTTP protocol. This downgrade is not allowed for security reasons. If you want to allow this behavior, set the AllowRedirectDowngrade option to true.")
+			}
+			// Strip authorization header if redirected to a different hostname
+			if req.URL.Hostname() != via[0].URL.Hostname() {
+				req.Header.Del("Authorization")
+			}
+			return nil
+		},
+	}
+	if options.Timeout != nil {
+		c.client.Timeout = *options.Timeout
+	}
+	return c
+}
+
+// Options sends an OPTIONS request.
+func (c *Client) Options(ctx context.Context, requestURL string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodOptions, requestURL, nil, headers)
+}
+
+// Get sends a GET request.
+func (c *Client) Get(ctx context.Context, requestURL string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodGet, requestURL, nil, headers)
+}
+
+// Del sends a DELETE request.
+func (c *Client) Del(ctx context.Context, requestURL string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodDelete, requestURL, nil, headers)
+}
+
+// Post sends a POST request with the data as the body.
+func (c *Client) Post(ctx context.Context, requestURL string, data string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodPost, requestURL, strings.NewReader(data), headers)
+}
+
+// Patch sends a PATCH request with the data as the body.
+func (c *Client) Patch(ctx context.Context, requestURL string, data string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodPatch, requestURL, strings.NewReader(data), headers)
+}
+
+// Put sends a PUT request with the data as the body.
+func (c *Client) Put(ctx context.Context, requestURL string, data string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodPut, requestURL, strings.NewReader(data), headers)
+}
+
+// Head sends a HEAD request.
+func (c *Client) Head(ctx context.Context, requestURL string, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, http.MethodHead, requestURL, nil, headers)
+}
+
+// SendStream sends a request with the stream as the body. The request is not retried
+// unless the stream is a *bytes.Buffer, *bytes.Reader or *strings.Reader.
+func (c *Client) SendStream(ctx context.Context, verb string, requestURL string, stream io.Reader, headers http.Header) (*http.Response, error) {
+	return c.Request(ctx, verb, requestURL, stream, headers)
+}
+
+// GetJSON sends a GET request and decodes the JSON response into out.
+// A 404 response is not an error and leaves out untouched.
+func (c *Client) GetJSON(ctx context.Context, requestURL string, out interface{}, headers http.Header) (*TypedResponse, error) {
+	headers = c.withDefaultHeader(headers, "Accept", "application/json")
+	resp, err := c.Get(ctx, requestURL, headers)
+	if err != nil {
+		return nil, err
+	}
+	return processResponse(resp, out)
+}
+
+// PostJSON sends obj as a JSON POST request and decodes the JSON response into out.
+func (c *Client) PostJSON(ctx context.Context, requestURL string, obj interface{}, out interface{}, headers http.Header) (*TypedResponse, error) {
+	return c.sendJSON(ctx, http.MethodPost, requestURL, obj, out, headers)
+}
+
+// PutJSON sends obj as a JSON PUT request and decodes the JSON response into out.
+func (c *Client) PutJSON(ctx context.Context, requestURL string, obj interface{}, out interface{}, headers http.Header) (*TypedResponse, error) {
+	return c.sendJSON(ctx, http.MethodPut, requestURL, obj, out, headers)
+}
+
+// PatchJSON sends obj as a JSON PATCH request and decodes the JSON response into out.
+func (c *Client) PatchJSON(ctx context.Context, requestURL string, obj interface{}, out interface{}, headers http.Header) (*TypedResponse, error) {
+	return c.sendJSON(ctx, http.MethodPatch, requestURL, obj, out, headers)
+}
+
+func (c *Client) sendJSON(ctx context.Context, verb string, requestURL string, obj interface{}, out interface{}, headers http.Header) (*TypedResponse, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	headers = c.withDefaultHeader(headers, "Accept", "application/json")
+	headers = c.withDefaultHeader(headers, "Content-Type", "application/json; charset=utf-8")
+	resp, err := c.Request(ctx, verb, requestURL, bytes.NewReader(data), headers)
+	if err != nil {
+		return nil, err
+	}
+	return processResponse(resp, out)
+}
+
+// Request sends the request, following redirects, responding to authentication challenges
+// and retrying as configured. The caller must close the response body.
+func (c *Client) Request(ctx context.Context, verb string, requestURL string, body io.Reader, headers http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, verb, requestURL, body)
+	if err != nil {
+		return nil, err
+	}
+	c.prepareRequest(req, headers)
+
+	// Only perform retries on reads since writes may not be idempotent.
+	maxTries := 1
+	if c.allowRetries && isRetryableVerb(verb) {
+		maxTries = c.maxRetries + 1
+	}
+
+	for numTries := 1; ; numTries++ {
+		resp, err := c.RequestRaw(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check if it's an authentication challenge
+		if resp.StatusCode == http.StatusUnauthorized {
+			for _, handler := range c.Handlers {
+				if handler.CanHandleAuthentication(resp) {
+					discardBody(resp)
+					return handler.HandleAuthentication(c, req)
+				}
+			}
+			return resp, nil
+		}
+
+		if !isRetryableStatusCode(resp.StatusCode) || numTries >= maxTries {
+			return resp, nil
+		}
+
+		// A body which cannot be rewound cannot be sent again
+		next, err := rewindRequest(req)
+		if err != nil || next == nil {
+			return resp, err
+		}
+
+		delay := backoff(numTries, resp.Header.Get("Retry-After"))
+		discardBody(resp)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// RequestRaw sends the prepared request once, without retries or authentication handling.
+func (c *Client) RequestRaw(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
+
+func (c *Client) prepareRequest(req *http.Request, headers http.Header) {
+	for key, values := range c.headers {
+		req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	for key, values := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for _, handler := range c.Handlers {
+		handler.PrepareRequest(req)
+	}
+}
+
+// rewindRequest returns a copy of the request with a fresh body, or nil if the body cannot be rewound.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+func isRetryableVerb(verb string) bool {
+	switch verb {
+	case http.MethodOptions, http.MethodGet, http.MethodDelete, http.MethodHead:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// backoff returns the delay before the next try. The Retry-After header, in seconds or
+// as an HTTP date, takes precedence when it asks for a longer delay.
+func backoff(retryNumber int, retryAfter string) time.Duration {
+	if retryNumber > exponentialBackoffCeiling {
+		retryNumber = exponentialBackoffCeiling
+	}
+	delay := exponentialBackoffTimeSlice * time.Duration(math.Pow(2, float64(retryNumber)))
+
+	if retryAfter == "" {
+		return delay
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		wait = time.Until(date)
+	}
+	if wait > delay {
+		return wait
+	}
+	return delay
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func discardBody(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// withDefaultHeader sets the header unless it is set by the request or the client options.
+func (c *Client) withDefaultHeader(headers http.Header, key, value string) http.Header {
+	if headers.Get(key) != "" || c.headers.Get(key) != "" {
+		return headers
+	}
+	merged := headers.Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	merged.Set(key, value)
+	return merged
+}
+
+// processResponse decodes the JSON body of the response into out and closes the body.
+func processResponse(resp *http.Response, out interface{}) (*TypedResponse, error) {
+	defer resp.Body.Close()
+	response := &TypedResponse{StatusCode: resp.StatusCode, Headers: resp.Header}
+
+	// Not found leads to an untouched result
+	if resp.StatusCode == http.StatusNotFound {
+		return response, nil
+	}
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result json.RawMessage
+	if len(contents) > 0 && json.Valid(contents) {
+		result = contents
+	}
+
+	if resp.StatusCode > 299 {
+		msg := fmt.Sprintf("Failed request: (%d)", resp.StatusCode)
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if result != nil && json.Unmarshal(result, &obj) == nil && obj.Message != "" {
+			msg = obj.Message
+		} else if len(contents) > 0 {
+			msg = string(contents)
+		}
+		return nil, &HTTPError{Message: msg, StatusCode: resp.StatusCode, Result: result}
+	}
+
+	// Invalid resource (contents not json) leaves the result untouched
+	if result != nil && out != nil {
+		if err := json.Unmarshal(result, out); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,358 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func Test_Handlers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("User-Agent"), r.Header.Get("Authorization"), r.Header.Get("X-Custom"))
+	}))
+	defer server.Close()
+
+	table := []struct {
+		handler  RequestHandler
+		expected string
+	}{
+		{handler: &BearerCredentialHandler{Token: "token"}, expected: "test-agent|Bearer token|custom"},
+		{handler: &BasicCredentialHandler{Username: "user", Password: "pass"}, expected: "test-agent|Basic dXNlcjpwYXNz|custom"},
+		{handler: &PersonalAccessTokenCredentialHandler{Token: "token"}, expected: "test-agent|Basic UEFUOnRva2Vu|custom"},
+	}
+
+	for _, tt := range table {
+		client := NewClient("test-agent", []RequestHandler{tt.handler}, &RequestOptions{Headers: http.Header{"X-Custom": {"custom"}}})
+		resp, err := client.Get(context.Background(), server.URL, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tt.expected {
+			t.Fatalf("expected value is %s, but result was %s.", tt.expected, body)
+		}
+	}
+}
+
+type challengeHandler struct {
+	BearerCredentialHandler
+}
+
+func (h *challengeHandler) CanHandleAuthentication(resp *http.Response) bool {
+	return resp.Header.Get("WWW-Authenticate") != ""
+}
+
+func (h *challengeHandler) HandleAuthentication(client *Client, req *http.Request) (*http.Response, error) {
+	h.Token = "refreshed"
+	retry := req.Clone(req.Context())
+	h.PrepareRequest(retry)
+	return client.RequestRaw(retry)
+}
+
+func Test_HandleAuthentication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer refreshed" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("", []RequestHandler{&challengeHandler{BearerCredentialHandler{Token: "expired"}}}, nil)
+	resp, err := client.Get(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected value is %d, but result was %d.", http.StatusNoContent, resp.StatusCode)
+	}
+}
+
+func Test_Retries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	table := []struct {
+		name          string
+		verb          string
+		options       *RequestOptions
+		expectedCalls int32
+		expectedCode  int
+	}{
+		{name: "retries are disabled by default", verb: http.MethodGet, options: nil, expectedCalls: 1, expectedCode: http.StatusServiceUnavailable},
+		{name: "retry until success", verb: http.MethodGet, options: &RequestOptions{AllowRetries: ptr.Bool(true), MaxRetries: ptr.Int(3)}, expectedCalls: 3, expectedCode: http.StatusOK},
+		{name: "retry until max retries", verb: http.MethodDelete, options: &RequestOptions{AllowRetries: ptr.Bool(true), MaxRetries: ptr.Int(1)}, expectedCalls: 2, expectedCode: http.StatusTooManyRequests},
+		{name: "writes are not retried", verb: http.MethodPost, options: &RequestOptions{AllowRetries: ptr.Bool(true), MaxRetries: ptr.Int(3)}, expectedCalls: 1, expectedCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range table {
+		atomic.StoreInt32(&calls, 0)
+		client := NewClient("", nil, tt.options)
+		resp, err := client.Request(context.Background(), tt.verb, server.URL, nil, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.expectedCode || atomic.LoadInt32(&calls) != tt.expectedCalls {
+			t.Fatalf("expected value is %d after %d calls, but result was %d after %d calls.\ntest case: %s", tt.expectedCode, tt.expectedCalls, resp.StatusCode, calls, tt.name)
+		}
+	}
+}
+
+func Test_Backoff(t *testing.T) {
+	table := []struct {
+		retryNumber int
+		retryAfter  string
+		expected    string
+	}{
+		{retryNumber: 1, expected: "10ms"},
+		{retryNumber: 3, expected: "40ms"},
+		{retryNumber: 20, expected: "5.12s"},
+		{retryNumber: 1, retryAfter: "2", expected: "2s"},
+		{retryNumber: 1, retryAfter: "0", expected: "10ms"},
+		{retryNumber: 1, retryAfter: "invalid", expected: "10ms"},
+	}
+
+	for _, tt := range table {
+		if val := backoff(tt.retryNumber, tt.retryAfter).String(); val != tt.expected {
+			t.Fatalf("expected value is %s, but result was %s.\ntest case: %v", tt.expected, val, tt)
+		}
+	}
+}
+
+func Test_Redirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer target.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same-host":
+			http.Redirect(w, r, server.URL+"/target", http.StatusFound)
+		case "/other-host":
+			// localhost and 127.0.0.1 are different hostnames
+			http.Redirect(w, r, "http://localhost:"+target.URL[len("http://127.0.0.1:"):], http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, server.URL+"/loop", http.StatusFound)
+		default:
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+	}))
+	defer server.Close()
+
+	table := []struct {
+		path         string
+		options      *RequestOptions
+		expectedCode int
+		expectedBody string
+	}{
+		{path: "/same-host", expectedCode: http.StatusOK, expectedBody: "Bearer token"},
+		{path: "/other-host", expectedCode: http.StatusOK, expectedBody: ""},
+		{path: "/same-host", options: &RequestOptions{AllowRedirects: ptr.Bool(false)}, expectedCode: http.StatusFound},
+		{path: "/loop", options: &RequestOptions{MaxRedirects: ptr.Int(3)}, expectedCode: http.StatusFound},
+	}
+
+	for _, tt := range table {
+		client := NewClient("", []RequestHandler{&BearerCredentialHandler{Token: "token"}}, tt.options)
+		resp, err := client.Get(context.Background(), server.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.expectedCode {
+			t.Fatalf("expected value is %d, but result was %d.\ntest case: %v", tt.expectedCode, resp.StatusCode, tt.path)
+		}
+		if tt.expectedCode == http.StatusOK && string(body) != tt.expectedBody {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", tt.expectedBody, body, tt.path)
+		}
+	}
+}
+
+func Test_RedirectDowngrade(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL, http.StatusFound)
+	}))
+	defer secure.Close()
+
+	client := NewClient("", nil, &RequestOptions{IgnoreSSLError: ptr.Bool(true)})
+	if _, err := client.Get(context.Background(), secure.URL, nil); err == nil {
+		t.Fatal("errors are expected but not found.")
+	}
+
+	client = NewClient("", nil, &RequestOptions{IgnoreSSLError: ptr.Bool(true), AllowRedirectDowngrade: ptr.Bool(true)})
+	resp, err := client.Get(context.Background(), secure.URL, nil)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	resp.Body.Close()
+}
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func Test_JSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			if r.Header.Get("Content-Type") != "application/json; charset=utf-8" || r.Header.Get("Accept") != "application/json" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("X-Method", r.Method)
+			io.Copy(w, r.Body)
+		case "/item":
+			fmt.Fprint(w, `{"name":"foo"}`)
+		case "/not-json":
+			fmt.Fprint(w, `not json`)
+		case "/message":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"bad request"}`)
+		case "/text":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `internal error`)
+		case "/empty":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("", nil, nil)
+	ctx := context.Background()
+
+	var out item
+	resp, err := client.GetJSON(ctx, server.URL+"/item", &out, nil)
+	if err != nil || resp.StatusCode != http.StatusOK || out.Name != "foo" {
+		t.Fatalf("unexpected result: %v %v %v", resp, out, err)
+	}
+
+	out = item{}
+	resp, err = client.GetJSON(ctx, server.URL+"/missing", &out, nil)
+	if err != nil || resp.StatusCode != http.StatusNotFound || out.Name != "" {
+		t.Fatalf("unexpected result: %v %v %v", resp, out, err)
+	}
+
+	out = item{}
+	resp, err = client.GetJSON(ctx, server.URL+"/not-json", &out, nil)
+	if err != nil || resp.StatusCode != http.StatusOK || out.Name != "" {
+		t.Fatalf("unexpected result: %v %v %v", resp, out, err)
+	}
+
+	helpers := map[string]func(context.Context, string, interface{}, interface{}, http.Header) (*TypedResponse, error){
+		http.MethodPost:  client.PostJSON,
+		http.MethodPut:   client.PutJSON,
+		http.MethodPatch: client.PatchJSON,
+	}
+	for method, helper := range helpers {
+		out = item{}
+		resp, err := helper(ctx, server.URL+"/echo", item{Name: "bar"}, &out, nil)
+		if err != nil || resp.Headers.Get("X-Method") != method || !reflect.DeepEqual(out, item{Name: "bar"}) {
+			t.Fatalf("unexpected result: %v %v %v", resp, out, err)
+		}
+	}
+
+	table := []struct {
+		path    string
+		message string
+		code    int
+	}{
+		{path: "/message", message: "bad request", code: http.StatusBadRequest},
+		{path: "/text", message: "internal error", code: http.StatusInternalServerError},
+		{path: "/empty", message: "Failed request: (403)", code: http.StatusForbidden},
+	}
+	for _, tt := range table {
+		_, err := client.GetJSON(ctx, server.URL+tt.path, &out, nil)
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.Message != tt.message || httpErr.StatusCode != tt.code {
+			t.Fatalf("expected error is %q (%d), but result was %v.", tt.message, tt.code, err)
+		}
+	}
+}
+
+func Test_JSONHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"name":%q}`, r.Header.Get("Accept")+"|"+r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	const accept = "application/json;api-version=6.0-preview.1"
+	table := []struct {
+		name     string
+		client   http.Header
+		request  http.Header
+		expected string
+	}{
+		{name: "defaults", expected: "application/json|application/json; charset=utf-8"},
+		// The headers of the client options take precedence over the defaults
+		{name: "client", client: http.Header{"Accept": {accept}, "Content-Type": {"application/vnd+json"}}, expected: accept + "|application/vnd+json"},
+		// The headers of the request take precedence over the ones of the client options
+		{name: "request", client: http.Header{"Accept": {accept}}, request: http.Header{"Accept": {"text/json"}}, expected: "text/json|application/json; charset=utf-8"},
+	}
+	for _, tt := range table {
+		client := NewClient("", nil, &RequestOptions{Headers: tt.client})
+		var out item
+		if _, err := client.PostJSON(ctx, server.URL, item{}, &out, tt.request); err != nil || out.Name != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q %v.\ntest case: %v", tt.expected, out.Name, err, tt.name)
+		}
+	}
+}
+
+func Test_Proxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "proxied ", r.URL.String())
+	}))
+	defer proxy.Close()
+
+	t.Setenv("http_proxy", "")
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("no_proxy", "")
+	t.Setenv("NO_PROXY", "bypassed.invalid")
+
+	client := NewClient("", nil, nil)
+	resp, err := client.Get(context.Background(), "http://example.invalid/path", nil)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if expected := "proxied http://example.invalid/path"; string(body) != expected {
+		t.Fatalf("expected value is %s, but result was %s.", expected, body)
+	}
+
+	if _, err := client.Get(context.Background(), "http://bypassed.invalid/path", nil); err == nil {
+		t.Fatal("errors are expected but not found.")
+	}
+}
@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GetProxyURL returns the proxy for the request URL from the https_proxy/HTTPS_PROXY or
+// http_proxy/HTTP_PROXY environment variables, or nil when the request bypasses the proxy.
+// The lowercase variables take precedence, and a proxy without a scheme is assumed to be http.
+func GetProxyURL(reqURL *url.URL) (*url.URL, error) {
+	if CheckBypass(reqURL) {
+		return nil, nil
+	}
+
+	var proxyVar string
+	if reqURL.Scheme == "https" {
+		proxyVar = firstEnv("https_proxy", "HTTPS_PROXY")
+	} else {
+		proxyVar = firstEnv("http_proxy", "HTTP_PROXY")
+	}
+	if proxyVar == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxyVar)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		if !strings.HasPrefix(proxyVar, "http://") && !strings.HasPrefix(proxyVar, "https://") {
+			return url.Parse("http://" + proxyVar)
+		}
+	}
+	return proxyURL, err
+}
+
+// CheckBypass reports whether the request URL matches the no_proxy/NO_PROXY environment variable
+// or is a loopback address, following the rules of the runner and @actions/http-client:
+//
+//   - entries are separated by commas, trimmed and compared case-insensitively
+//   - `*` bypasses the proxy for every host
+//   - `host` matches the host and its subdomains, `.host` only matches the subdomains
+//   - `host:port` only matches that port; the default port of the scheme is assumed when the URL has none
+//   - `localhost`, `127.*` and `[::1]` always bypass the proxy
+func CheckBypass(reqURL *url.URL) bool {
+	reqHost := reqURL.Hostname()
+	if reqHost == "" {
+		return false
+	}
+	if strings.Contains(reqHost, ":") {
+		// IPv6 literals keep their brackets, as in the WHATWG URL hostname
+		reqHost = "[" + reqHost + "]"
+	}
+
+	if isLoopbackAddress(reqHost) {
+		return true
+	}
+
+	noProxy := firstEnv("no_proxy", "NO_PROXY")
+	if noProxy == "" {
+		return false
+	}
+
+	// Determine the request port
+	reqPort := -1
+	if port := reqURL.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err == nil {
+			reqPort = n
+		}
+	} else if reqURL.Scheme == "http" {
+		reqPort = 80
+	} else if reqURL.Scheme == "https" {
+		reqPort = 443
+	}
+
+	// Format the request hostname and hostname with port
+	upperReqHosts := []string{strings.ToUpper(reqHost)}
+	if reqPort >= 0 {
+		upperReqHosts = append(upperReqHosts, upperReqHosts[0]+":"+strconv.Itoa(reqPort))
+	}
+
+	// Compare request host against noproxy
+	for _, item := range strings.Split(noProxy, ",") {
+		upperNoProxyItem := strings.ToUpper(strings.TrimSpace(item))
+		if upperNoProxyItem == "" {
+			continue
+		}
+		if upperNoProxyItem == "*" {
+			return true
+		}
+		for _, x := range upperReqHosts {
+			if x == upperNoProxyItem ||
+				strings.HasSuffix(x, "."+upperNoProxyItem) ||
+				(strings.HasPrefix(upperNoProxyItem, ".") && strings.HasSuffix(x, upperNoProxyItem)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isLoopbackAddress(host string) bool {
+	hostLower := strings.ToLower(host)
+	return hostLower == "localhost" ||
+		strings.HasPrefix(hostLower, "127.") ||
+		strings.HasPrefix(hostLower, "[::1]") ||
+		strings.HasPrefix(hostLower, "[0:0:0:0:0:0:0:1]")
+}
+
+// firstEnv returns the first non-empty value of the environment variables.
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+// Package platform describes the platform of the runner, like `core.platform` of the JS
+// toolkit: its operating system and architecture in the naming of the runner, whether it is
+// hosted by GitHub, whether the job runs in a container, and the Linux distribution.
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The operating systems in the naming of RUNNER_OS.
+const (
+	Linux   = "Linux"
+	Windows = "Windows"
+	MacOS   = "macOS"
+)
+
+// The architectures in the naming of RUNNER_ARCH.
+const (
+	X86   = "X86"
+	X64   = "X64"
+	ARM   = "ARM"
+	ARM64 = "ARM64"
+)
+
+// The platform of the process, overridden by tests.
+var (
+	goos   = runtime.GOOS
+	goarch = runtime.GOARCH
+)
+
+// OS returns the operating system of the process in the naming of the runner: Linux, Windows
+// or macOS. Other systems are returned as named by Go, e.g. freebsd.
+func OS() string {
+	switch goos {
+	case "linux":
+		return Linux
+	case "windows":
+		return Windows
+	case "darwin":
+		return MacOS
+	default:
+		return goos
+	}
+}
+
+// Arch returns the architecture of the process in the naming of the runner: X86, X64, ARM or
+// ARM64. Other architectures are returned as named by Go in upper case, e.g. S390X.
+func Arch() string {
+	switch goarch {
+	case "386":
+		return X86
+	case "amd64":
+		return X64
+	case "arm":
+		return ARM
+	case "arm64":
+		return ARM64
+	default:
+		return strings.ToUpper(goarch)
+	}
+}
+
+// IsLinux reports whether the process runs on Linux.
+func IsLinux() bool {
+	return goos == "linux"
+}
+
+// IsWindows reports whether the process runs on Windows.
+func IsWindows() bool {
+	return goos == "windows"
+}
+
+// IsMacOS reports whether the process runs on macOS.
+func IsMacOS() bool {
+	return goos == "darwin"
+}
+
+// RunnerOS returns RUNNER_OS, the operating system of the runner, falling back to the one of
+// the process outside of a runner.
+func RunnerOS() string {
+	if runnerOS := os.Getenv("RUNNER_OS"); runnerOS != "" {
+		return runnerOS
+	}
+	return OS()
+}
+
+// RunnerArch returns RUNNER_ARCH, the architecture of the runner, falling back to the one of
+// the process outside of a runner.
+func RunnerArch() string {
+	if runnerArch := os.Getenv("RUNNER_ARCH"); runnerArch != "" {
+		return runnerArch
+	}
+	return Arch()
+}
+
+// IsGitHubHosted reports whether the job runs on a runner hosted by GitHub, from
+// RUNNER_ENVIRONMENT, or from the ImageOS variable of the images of the hosted runners with
+// the runners not setting it.
+func IsGitHubHosted() bool {
+	switch os.Getenv("RUNNER_ENVIRONMENT") {
+	case "github-hosted":
+		return true
+	case "self-hosted":
+		return false
+	}
+	return os.Getenv("ImageOS") != ""
+}
+
+// IsSelfHosted reports whether the job runs on a self-hosted runner. It is false outside of
+// GitHub Actions.
+func IsSelfHosted() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true" && !IsGitHubHosted()
+}
+
+// IsContainer reports whether the process runs in a container: Docker, Podman, or a
+// container runtime registering the processes in its cgroups, e.g. Kubernetes.
+func IsContainer() bool {
+	return IsContainerAt("/")
+}
+
+// IsContainerAt is like IsContainer, reading the files of the system under a root directory,
+// e.g. a fixture of a test.
+func IsContainerAt(root string) bool {
+	for _, name := range []string{".dockerenv", "run/.containerenv"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			return true
+		}
+	}
+	cgroup, err := os.ReadFile(filepath.Join(root, "proc", "1", "cgroup"))
+	if err != nil {
+		return false
+	}
+	for _, name := range []string{"docker", "kubepods", "containerd", "libpod", "lxc"} {
+		if bytes.Contains(cgroup, []byte(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Distro is a Linux distribution, as described by os-release.
+type Distro struct {
+	// ID is the lower case identifier of the distribution, e.g. ubuntu
+	ID string
+
+	// Name is the name of the distribution, e.g. Ubuntu
+	Name string
+
+	// Version is the version of the distribution, e.g. 22.04, or empty for rolling releases
+	Version string
+
+	// Codename is the codename of the release, e.g. jammy, or empty
+	Codename string
+
+	// PrettyName is the name and the version of the distribution for display, e.g. Ubuntu 22.04.4 LTS
+	PrettyName string
+}
+
+// GetDistro returns the Linux distribution, from /etc/os-release or /usr/lib/os-release.
+func GetDistro() (*Distro, error) {
+	return GetDistroAt("/")
+}
+
+// GetDistroAt is like GetDistro, reading os-release under a root directory, e.g. a fixture
+// of a test.
+func GetDistroAt(root string) (*Distro, error) {
+	var contents []byte
+	var err error
+	for _, name := range []string{"etc/os-release", "usr/lib/os-release"} {
+		if contents, err = os.ReadFile(filepath.Join(root, name)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read os-release: %w", err)
+	}
+
+	fields := ParseOSRelease(contents)
+	distro := &Distro{
+		ID:         fields["ID"],
+		Name:       fields["NAME"],
+		Version:    fields["VERSION_ID"],
+		Codename:   fields["VERSION_CODENAME"],
+		PrettyName: fields["PRETTY_NAME"],
+	}
+	// The defaults of the specification
+	if distro.ID == "" {
+		distro.ID = "linux"
+	}
+	if distro.Name == "" {
+		distro.Name = "Linux"
+	}
+	if distro.PrettyName == "" {
+		distro.PrettyName = strings.TrimSpace(distro.Name + " " + distro.Version)
+	}
+	return distro, nil
+}
+
+// ParseOSRelease parses the variable assignments of os-release, or of files of the same
+// syntax like lsb-release, e.g.
+//
+//	NAME="Ubuntu"
+//	VERSION_ID="22.04"
+//	ID=ubuntu
+//
+// The values may be quoted with double or single quotes, and escape characters with
+// backslashes in double quotes.
+func ParseOSRelease(contents []byte) map[string]string {
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			continue
+		}
+		fields[line[:i]] = unquote(line[i+1:])
+	}
+	return fields
+}
+
+func unquote(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch quote := value[0]; {
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	case quote == '"' && value[len(value)-1] == '"':
+		var b strings.Builder
+		value = value[1 : len(value)-1]
+		for i := 0; i < len(value); i++ {
+			if value[i] == '\\' && i+1 < len(value) {
+				i++
+			}
+			b.WriteByte(value[i])
+		}
+		return b.String()
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newRoot writes files under a temporary filesystem root.
+func newRoot(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+	}
+	return dir
+}
+
+// setPlatform overrides the platform of the process.
+func setPlatform(t *testing.T, system, arch string) {
+	prevOS, prevArch := goos, goarch
+	goos, goarch = system, arch
+	t.Cleanup(func() { goos, goarch = prevOS, prevArch })
+}
+
+func Test_OSArch(t *testing.T) {
+	table := []struct {
+		goos, goarch    string
+		os, arch        string
+		linux, win, mac bool
+	}{
+		{goos: "linux", goarch: "amd64", os: "Linux", arch: "X64", linux: true},
+		{goos: "windows", goarch: "386", os: "Windows", arch: "X86", win: true},
+		{goos: "darwin", goarch: "arm64", os: "macOS", arch: "ARM64", mac: true},
+		{goos: "linux", goarch: "arm", os: "Linux", arch: "ARM", linux: true},
+		{goos: "freebsd", goarch: "s390x", os: "freebsd", arch: "S390X"},
+	}
+	for _, tt := range table {
+		setPlatform(t, tt.goos, tt.goarch)
+		if OS() != tt.os || Arch() != tt.arch {
+			t.Fatalf("expected value is %v/%v, but result was %v/%v.\ntest case: %+v", tt.os, tt.arch, OS(), Arch(), tt)
+		}
+		if IsLinux() != tt.linux || IsWindows() != tt.win || IsMacOS() != tt.mac {
+			t.Fatalf("unexpected platform: %v %v %v\ntest case: %+v", IsLinux(), IsWindows(), IsMacOS(), tt)
+		}
+	}
+}
+
+func Test_RunnerOSArch(t *testing.T) {
+	setPlatform(t, "linux", "amd64")
+	t.Setenv("RUNNER_OS", "")
+	t.Setenv("RUNNER_ARCH", "")
+	if RunnerOS() != "Linux" || RunnerArch() != "X64" {
+		t.Fatalf("expected value is %v/%v, but result was %v/%v.", "Linux", "X64", RunnerOS(), RunnerArch())
+	}
+
+	t.Setenv("RUNNER_OS", "Windows")
+	t.Setenv("RUNNER_ARCH", "ARM64")
+	if RunnerOS() != "Windows" || RunnerArch() != "ARM64" {
+		t.Fatalf("expected value is %v/%v, but result was %v/%v.", "Windows", "ARM64", RunnerOS(), RunnerArch())
+	}
+}
+
+func Test_IsGitHubHosted(t *testing.T) {
+	table := []struct {
+		actions     string
+		environment string
+		imageOS     string
+		hosted      bool
+		selfHosted  bool
+	}{
+		{actions: "true", environment: "github-hosted", hosted: true},
+		{actions: "true", environment: "self-hosted", imageOS: "ubuntu22", selfHosted: true},
+		{actions: "true", imageOS: "ubuntu22", hosted: true},
+		{actions: "true", selfHosted: true},
+		{},
+	}
+	for _, tt := range table {
+		t.Setenv("GITHUB_ACTIONS", tt.actions)
+		t.Setenv("RUNNER_ENVIRONMENT", tt.environment)
+		t.Setenv("ImageOS", tt.imageOS)
+		if IsGitHubHosted() != tt.hosted || IsSelfHosted() != tt.selfHosted {
+			t.Fatalf("expected value is %v/%v, but result was %v/%v.\ntest case: %+v", tt.hosted, tt.selfHosted, IsGitHubHosted(), IsSelfHosted(), tt)
+		}
+	}
+}
+
+func Test_IsContainer(t *testing.T) {
+	table := []struct {
+		name     string
+		files    map[string]string
+		expected bool
+	}{
+		{name: "docker", files: map[string]string{".dockerenv": ""}, expected: true},
+		{name: "podman", files: map[string]string{"run/.containerenv": ""}, expected: true},
+		{name: "kubernetes", files: map[string]string{"proc/1/cgroup": "12:memory:/kubepods/besteffort/pod1\n"}, expected: true},
+		{name: "host", files: map[string]string{"proc/1/cgroup": "0::/init.scope\n"}},
+		{name: "nothing"},
+	}
+	for _, tt := range table {
+		if actual := IsContainerAt(newRoot(t, tt.files)); actual != tt.expected {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+}
+
+func Test_GetDistro(t *testing.T) {
+	table := []struct {
+		name     string
+		files    map[string]string
+		expected *Distro
+	}{
+		{
+			name: "ubuntu",
+			files: map[string]string{"etc/os-release": `PRETTY_NAME="Ubuntu 22.04.4 LTS"
+NAME="Ubuntu"
+VERSION_ID="22.04"
+VERSION_CODENAME=jammy
+ID=ubuntu
+ID_LIKE=debian
+`},
+			expected: &Distro{ID: "ubuntu", Name: "Ubuntu", Version: "22.04", Codename: "jammy", PrettyName: "Ubuntu 22.04.4 LTS"},
+		},
+		{
+			name:     "usr lib",
+			files:    map[string]string{"usr/lib/os-release": "# comment\nNAME='Alpine Linux'\nID=alpine\nVERSION_ID=3.19.1\n"},
+			expected: &Distro{ID: "alpine", Name: "Alpine Linux", Version: "3.19.1", PrettyName: "Alpine Linux 3.19.1"},
+		},
+		{
+			name:     "escapes and defaults",
+			files:    map[string]string{"etc/os-release": `PRETTY_NAME="The \"Best\" \$Linux"` + "\n"},
+			expected: &Distro{ID: "linux", Name: "Linux", PrettyName: `The "Best" $Linux`},
+		},
+	}
+	for _, tt := range table {
+		actual, err := GetDistroAt(newRoot(t, tt.files))
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, actual, tt.name)
+		}
+	}
+
+	if _, err := GetDistroAt(newRoot(t, nil)); err == nil {
+		t.Fatal("expected an error without os-release")
+	}
+}
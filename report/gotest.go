@@ -0,0 +1,177 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goTestEvent is an event of the output of go test -json, see go doc test2json.
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// goTestLocation matches the location of a failure logged by t.Error or t.Fatal.
+var goTestLocation = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): (.*)$`)
+
+// ParseGoTest parses the output of go test -json into a report with a suite per package.
+// The location and the message of a failed test are taken from the first line it logged
+// with t.Error or t.Fatal; the file is relative to the directory of the package. A package
+// failing outside of its tests, e.g. because it does not build, gets a failed test named
+// after the package, with the output of the build which failed. The tests which do not finish, e.g. because the package times out or
+// panics, fail with the output of the package.
+func ParseGoTest(r io.Reader) (*Report, error) {
+	var packages []string
+	suites := map[string]*Suite{}
+	tests := map[string]map[string]int{}
+	outputs := map[string]map[string]*strings.Builder{}
+	// The build events are keyed by the import path of the build, and precede the package events
+	builds := map[string]*strings.Builder{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse the line %d of the go test output: %w", lineNumber, err)
+		}
+		if event.Action == "build-output" {
+			if builds[event.ImportPath] == nil {
+				builds[event.ImportPath] = &strings.Builder{}
+			}
+			builds[event.ImportPath].WriteString(event.Output)
+			continue
+		}
+		if event.Package == "" {
+			continue
+		}
+
+		suite, ok := suites[event.Package]
+		if !ok {
+			packages = append(packages, event.Package)
+			suite = &Suite{Name: event.Package}
+			suites[event.Package] = suite
+			tests[event.Package] = map[string]int{}
+			outputs[event.Package] = map[string]*strings.Builder{}
+		}
+		index, ok := tests[event.Package][event.Test]
+		if !ok && event.Test != "" {
+			index = len(suite.Tests)
+			tests[event.Package][event.Test] = index
+			suite.Tests = append(suite.Tests, Test{Name: event.Test})
+		}
+		output := outputs[event.Package][event.Test]
+		if output == nil {
+			output = &strings.Builder{}
+			outputs[event.Package][event.Test] = output
+		}
+
+		elapsed := time.Duration(math.Round(event.Elapsed * float64(time.Second)))
+		switch event.Action {
+		case "output":
+			output.WriteString(event.Output)
+		case "pass", "fail", "skip":
+			status := map[string]Status{"pass": StatusPassed, "fail": StatusFailed, "skip": StatusSkipped}[event.Action]
+			if event.Test == "" {
+				suite.Duration = elapsed
+				failUnfinishedGoTests(suite, outputs[event.Package])
+				if status == StatusFailed && suite.Counts().Failed == 0 {
+					test := Test{
+						Name:    event.Package,
+						Status:  StatusFailed,
+						Message: "the package failed outside of its tests",
+						Details: strings.TrimSpace(output.String()),
+					}
+					if event.FailedBuild != "" {
+						test.Message = "the package failed to build"
+						if build := builds[event.FailedBuild]; build != nil {
+							test.Details = strings.TrimSpace(build.String() + output.String())
+						}
+					}
+					suite.Tests = append(suite.Tests, test)
+				}
+				continue
+			}
+			test := &suite.Tests[index]
+			test.Status = status
+			test.Duration = elapsed
+			applyGoTestOutput(test, output.String())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for _, name := range packages {
+		// The output is truncated, e.g. because go test was killed
+		failUnfinishedGoTests(suites[name], outputs[name])
+		report.Suites = append(report.Suites, *suites[name])
+	}
+	return report, nil
+}
+
+// failUnfinishedGoTests fails the tests of a package without a status, with their output and
+// the output of the package, e.g. the panic of a timeout.
+func failUnfinishedGoTests(suite *Suite, outputs map[string]*strings.Builder) {
+	for i := range suite.Tests {
+		test := &suite.Tests[i]
+		if test.Status != "" {
+			continue
+		}
+		test.Status = StatusFailed
+		var output string
+		for _, name := range []string{test.Name, ""} {
+			if outputs[name] != nil {
+				output += outputs[name].String()
+			}
+		}
+		applyGoTestOutput(test, output)
+		if test.Message == "" {
+			test.Message = "the test did not finish"
+			if i := strings.Index(test.Details, "\n"); i > 0 {
+				test.Message = test.Details[:i]
+			}
+		}
+	}
+}
+
+// applyGoTestOutput sets the message, the location and the details of a failed or skipped
+// test from its output.
+func applyGoTestOutput(test *Test, output string) {
+	if test.Status == StatusPassed {
+		return
+	}
+	var details []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") || trimmed == "" {
+			continue
+		}
+		if m := goTestLocation.FindStringSubmatch(line); m != nil && test.File == "" {
+			test.File = m[1]
+			test.Line, _ = strconv.Atoi(m[2])
+			test.Message = m[3]
+		}
+		details = append(details, line)
+	}
+	if test.Status == StatusFailed {
+		test.Details = strings.Join(trimIndent(details), "\n")
+	}
+}
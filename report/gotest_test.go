@@ -0,0 +1,94 @@
+package report
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseGoTest(t *testing.T) {
+	file, err := os.Open("testdata/gotest.json")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	defer file.Close()
+	report, err := ParseGoTest(file)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	expected := &Report{Suites: []Suite{
+		{
+			Name:     "example.com/calc",
+			Duration: 250 * time.Millisecond,
+			Tests: []Test{
+				{Name: "TestAdd", Status: StatusPassed, Duration: time.Millisecond},
+				{
+					Name:     "TestDivide",
+					Status:   StatusFailed,
+					Duration: 120 * time.Millisecond,
+					Message:  "expected 2, got 3",
+					Details:  "calc_test.go:12: expected 2, got 3\n    with a second line\ncalc_test.go:13: another error",
+					File:     "calc_test.go",
+					Line:     12,
+				},
+				{Name: "TestMultiply", Status: StatusSkipped, Message: "not implemented", File: "calc_test.go", Line: 20},
+				{Name: "TestTable", Status: StatusPassed, Duration: 2 * time.Millisecond},
+				{Name: "TestTable/one", Status: StatusPassed},
+			},
+		},
+		{
+			Name: "example.com/broken",
+			Tests: []Test{{
+				Name:    "example.com/broken",
+				Status:  StatusFailed,
+				Message: "the package failed to build",
+				Details: "# example.com/broken [example.com/broken.test]\nbroken/broken.go:3:1: syntax error: non-declaration statement outside function body\nFAIL\texample.com/broken [build failed]",
+			}},
+		},
+		{Name: "example.com/empty"},
+		{
+			Name:     "example.com/hang",
+			Duration: 1006 * time.Millisecond,
+			Tests: []Test{
+				{Name: "TestQuick", Status: StatusPassed},
+				// The test did not finish before the timeout
+				{
+					Name:    "TestHang",
+					Status:  StatusFailed,
+					Message: "panic: test timed out after 1s",
+					Details: "panic: test timed out after 1s\n\trunning tests:\n\t\tTestHang (1s)\n" +
+						"goroutine 7 [sleep]:\ntime.Sleep(0xdf8475800)\n\t/usr/local/go/src/runtime/time.go:368 +0x165\n" +
+						"example.com/hang.TestHang(0x37d2beb22488?)\n\t/src/hang/hang_test.go:11 +0x1d\n" +
+						"testing.tRunner(0x37d2beb22488, 0x6d47e8)\n\t/usr/local/go/src/testing/testing.go:2193 +0xea\n" +
+						"created by testing.(*T).Run in goroutine 1\n\t/usr/local/go/src/testing/testing.go:2258 +0x4d4\n" +
+						"FAIL\texample.com/hang\t1.005s",
+				},
+			},
+		},
+	}}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, report)
+	}
+}
+
+func Test_ParseGoTestErrors(t *testing.T) {
+	_, err := ParseGoTest(strings.NewReader("{\"Action\":\"start\",\"Package\":\"a\"}\nok  \ta\t0.1s\n"))
+	if expected := "failed to parse the line 2 of the go test output: invalid character 'o' looking for beginning of value"; err == nil || err.Error() != expected {
+		t.Fatalf("expected value is %q, but result was %v.", expected, err)
+	}
+}
+
+func Test_ParseGoTestTruncated(t *testing.T) {
+	// go test was killed before the end of the test
+	report, err := ParseGoTest(strings.NewReader(`{"Action":"run","Package":"a","Test":"TestA"}` + "\n"))
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	expected := []Test{{Name: "TestA", Status: StatusFailed, Message: "the test did not finish"}}
+	if !reflect.DeepEqual(report.Suites[0].Tests, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, report.Suites[0].Tests)
+	}
+}
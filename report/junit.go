@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	Suites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name   string           `xml:"name,attr"`
+	Time   string           `xml:"time,attr"`
+	File   string           `xml:"file,attr"`
+	Cases  []junitTestCase  `xml:"testcase"`
+	Suites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	File      string        `xml:"file,attr"`
+	Line      string        `xml:"line,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnit parses a JUnit XML report, whose root is either a testsuites or a testsuite
+// element. Nested test suites are flattened. Test cases with an error are failed.
+func ParseJUnit(r io.Reader) (*Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse the JUnit report: %w", err)
+	}
+
+	var suites []junitTestSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		var testSuites junitTestSuites
+		if err := xml.Unmarshal(data, &testSuites); err != nil {
+			return nil, fmt.Errorf("failed to parse the JUnit report: %w", err)
+		}
+		suites = testSuites.Suites
+	case "testsuite":
+		var testSuite junitTestSuite
+		if err := xml.Unmarshal(data, &testSuite); err != nil {
+			return nil, fmt.Errorf("failed to parse the JUnit report: %w", err)
+		}
+		suites = []junitTestSuite{testSuite}
+	default:
+		return nil, fmt.Errorf("failed to parse the JUnit report: unexpected root element <%s>", root.XMLName.Local)
+	}
+
+	report := &Report{}
+	for _, suite := range suites {
+		report.addJUnitSuite(suite)
+	}
+	return report, nil
+}
+
+func (r *Report) addJUnitSuite(suite junitTestSuite) {
+	if len(suite.Cases) > 0 || len(suite.Suites) == 0 {
+		s := Suite{Name: suite.Name, Duration: parseSeconds(suite.Time)}
+		var testsDuration time.Duration
+		for _, testCase := range suite.Cases {
+			test := Test{
+				Name:      testCase.Name,
+				Classname: testCase.Classname,
+				Status:    StatusPassed,
+				Duration:  parseSeconds(testCase.Time),
+				File:      testCase.File,
+			}
+			if test.File == "" {
+				test.File = suite.File
+			}
+			test.Line, _ = strconv.Atoi(testCase.Line)
+			problem := testCase.Failure
+			if problem == nil {
+				problem = testCase.Error
+			}
+			switch {
+			case problem != nil:
+				test.Status = StatusFailed
+				test.Message = problem.Message
+				test.Details = strings.TrimSpace(problem.Text)
+				if test.Message == "" {
+					test.Message = problem.Type
+				}
+			case testCase.Skipped != nil:
+				test.Status = StatusSkipped
+				test.Message = testCase.Skipped.Message
+			}
+			testsDuration += test.Duration
+			s.Tests = append(s.Tests, test)
+		}
+		if s.Duration == 0 {
+			s.Duration = testsDuration
+		}
+		r.Suites = append(r.Suites, s)
+	}
+	for _, nested := range suite.Suites {
+		r.addJUnitSuite(nested)
+	}
+}
+
+// parseSeconds parses a duration in seconds, such as 0.123, or returns 0.
+func parseSeconds(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseJUnit(t *testing.T) {
+	file, err := os.Open("testdata/junit.xml")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	defer file.Close()
+	report, err := ParseJUnit(file)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	expected := &Report{Suites: []Suite{
+		{
+			Name:     "math",
+			Duration: 750 * time.Millisecond,
+			Tests: []Test{
+				{Name: "adds", Classname: "math", Status: StatusPassed, Duration: 250 * time.Millisecond, File: "src/math.test.js"},
+				{
+					Name:      "divides",
+					Classname: "math",
+					Status:    StatusFailed,
+					Duration:  500 * time.Millisecond,
+					Message:   "expected 2 but got 3",
+					Details:   "AssertionError: expected 2 but got 3\n    at Object.<anonymous> (src/math.test.js:12:5)",
+					File:      "src/math.test.js",
+					Line:      12,
+				},
+				{Name: "multiplies", Classname: "math", Status: StatusSkipped, Message: "not implemented", File: "src/math.test.js"},
+			},
+		},
+		{
+			Name:     "io.files",
+			Duration: 250 * time.Millisecond,
+			Tests: []Test{
+				{Name: "reads", Classname: "io.files", Status: StatusPassed, Duration: 125 * time.Millisecond, File: "src/files.test.js"},
+				{Name: "writes", Classname: "io.files", Status: StatusFailed, Duration: 125 * time.Millisecond, Message: "Error", Details: "Error: EACCES"},
+			},
+		},
+	}}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, report)
+	}
+	if counts := report.Counts(); counts != (Counts{Passed: 2, Failed: 2, Skipped: 1}) || counts.Total() != 5 {
+		t.Fatalf("expected value is %v, but result was %+v.", "2 passed, 2 failed, 1 skipped", counts)
+	}
+	if report.Duration() != time.Second || !report.Failed() {
+		t.Fatalf("expected value is %v, but result was %v.", time.Second, report.Duration())
+	}
+}
+
+func Test_ParseJUnitErrors(t *testing.T) {
+	table := []struct {
+		name     string
+		input    string
+		expected *Report
+		err      string
+	}{
+		{
+			name:     "testsuite root",
+			input:    `<testsuite name="suite"><testcase name="test" time="1,000.5"/></testsuite>`,
+			expected: &Report{Suites: []Suite{{Name: "suite", Duration: 1000500 * time.Millisecond, Tests: []Test{{Name: "test", Status: StatusPassed, Duration: 1000500 * time.Millisecond}}}}},
+		},
+		{name: "empty testsuites", input: `<testsuites></testsuites>`, expected: &Report{}},
+		{name: "unexpected root", input: `<html></html>`, err: "failed to parse the JUnit report: unexpected root element <html>"},
+		{name: "invalid", input: `<testsuites>`, err: "failed to parse the JUnit report: XML syntax error on line 1: unexpected EOF"},
+	}
+	for _, tt := range table {
+		report, err := ParseJUnit(strings.NewReader(tt.input))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+		}
+		if !reflect.DeepEqual(report, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, report, tt.name)
+		}
+	}
+}
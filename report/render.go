@@ -0,0 +1,154 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ci-tools/toolkit/core"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// The function writing the annotations, overridden by tests.
+var coreError = core.Error
+
+type SummaryOptions struct {
+	/** Optional. The heading of the results. Defaults to "Test results" */
+	Title *string
+
+	/** Optional. The maximum number of failures detailed after the table. Defaults to 50 */
+	MaxFailures *int
+
+	initOnce sync.Once
+}
+
+func initializeSummaryOptions(options *SummaryOptions) *SummaryOptions {
+	if options == nil {
+		options = &SummaryOptions{}
+	}
+	options.initOnce.Do(options.init)
+	return options
+}
+
+// DO NOT CALL THIS OUTSIDE initializeSummaryOptions
+func (o *SummaryOptions) init() {
+	if o.Title == nil {
+		o.Title = ptr.String("Test results")
+	}
+	if o.MaxFailures == nil {
+		o.MaxFailures = ptr.Int(50)
+	}
+}
+
+// AddToSummary renders the report to the summary: a heading, a table of the counts and the
+// durations of the suites, and a collapsible section per failed test with its details.
+// options may be nil.
+func (r *Report) AddToSummary(summary *core.Summary, options *SummaryOptions) *core.Summary {
+	options = initializeSummaryOptions(options)
+	counts := r.Counts()
+
+	summary.AddHeading(html.EscapeString(*options.Title), 2)
+	summary.AddRaw(fmt.Sprintf("%d passed, %d failed, %d skipped in %s", counts.Passed, counts.Failed, counts.Skipped, formatDuration(r.Duration()))).AddEOL()
+
+	header := func(data string) core.SummaryTableCell {
+		return core.SummaryTableCell{Data: data, Header: ptr.Bool(true)}
+	}
+	rows := []core.SummaryTableRow{{header("Suite"), header("Passed"), header("Failed"), header("Skipped"), header("Duration")}}
+	for _, suite := range r.Suites {
+		rows = append(rows, countsRow(html.EscapeString(suiteName(suite)), suite.Counts(), suite.Duration))
+	}
+	if len(r.Suites) > 1 {
+		rows = append(rows, countsRow("<strong>Total</strong>", counts, r.Duration()))
+	}
+	summary.AddTable(rows)
+
+	shown := 0
+	for _, suite := range r.Suites {
+		for _, test := range suite.Tests {
+			if test.Status != StatusFailed {
+				continue
+			}
+			if shown == *options.MaxFailures {
+				summary.AddRaw(fmt.Sprintf("%d more failed tests are not shown.", counts.Failed-shown)).AddEOL()
+				return summary
+			}
+			shown++
+			label := fmt.Sprintf("❌ %s › %s", html.EscapeString(suiteName(suite)), html.EscapeString(test.Name))
+			var content string
+			if location := testLocation(test); location != "" {
+				content += fmt.Sprintf("<p><code>%s</code></p>", html.EscapeString(location))
+			}
+			if test.Message != "" {
+				content += fmt.Sprintf("<p>%s</p>", html.EscapeString(test.Message))
+			}
+			if test.Details != "" {
+				content += fmt.Sprintf("<pre><code>%s</code></pre>", html.EscapeString(test.Details))
+			}
+			summary.AddDetails(label, content)
+		}
+	}
+	return summary
+}
+
+func countsRow(name string, counts Counts, duration time.Duration) core.SummaryTableRow {
+	return core.SummaryTableRow{
+		{Data: name},
+		{Data: strconv.Itoa(counts.Passed)},
+		{Data: strconv.Itoa(counts.Failed)},
+		{Data: strconv.Itoa(counts.Skipped)},
+		{Data: formatDuration(duration)},
+	}
+}
+
+func suiteName(suite Suite) string {
+	if suite.Name == "" {
+		return "Tests"
+	}
+	return suite.Name
+}
+
+// formatDuration formats a duration to the millisecond, e.g. 1.234s.
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// testLocation returns the location of a test, e.g. a_test.go:12, or an empty string.
+func testLocation(test Test) string {
+	if test.File == "" {
+		return ""
+	}
+	if test.Line == 0 {
+		return test.File
+	}
+	return fmt.Sprintf("%s:%d", test.File, test.Line)
+}
+
+// Annotate writes an error annotation per failed test, at the location of the test when the
+// report tells it.
+func (r *Report) Annotate() {
+	for _, suite := range r.Suites {
+		for _, test := range suite.Tests {
+			if test.Status != StatusFailed {
+				continue
+			}
+			test := test
+			properties := &core.AnnotationProperties{Title: ptr.String(fmt.Sprintf("%s › %s", suiteName(suite), test.Name))}
+			if test.File != "" {
+				properties.File = &test.File
+			}
+			if test.Line != 0 {
+				properties.StartLine = &test.Line
+			}
+			message := test.Message
+			if message == "" {
+				message = test.Details
+			}
+			if message == "" {
+				message = "The test failed."
+			}
+			coreError(message, properties)
+		}
+	}
+}
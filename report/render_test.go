@@ -0,0 +1,81 @@
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ci-tools/toolkit/core"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+var testReport = &Report{Suites: []Suite{
+	{
+		Name:     "math",
+		Duration: 1500 * time.Millisecond,
+		Tests: []Test{
+			{Name: "adds", Status: StatusPassed},
+			{Name: "divides <by zero>", Status: StatusFailed, Message: "expected 2 but got 3", Details: "at a < b", File: "math.test.js", Line: 12},
+			{Name: "multiplies", Status: StatusSkipped},
+		},
+	},
+	{
+		Name:     "",
+		Duration: 250 * time.Millisecond,
+		Tests: []Test{
+			{Name: "writes", Status: StatusFailed},
+		},
+	},
+}}
+
+func Test_AddToSummary(t *testing.T) {
+	actual := testReport.AddToSummary(core.NewSummary(), nil).Stringify()
+	expected := strings.Join([]string{
+		"<h2>Test results</h2>",
+		"1 passed, 2 failed, 1 skipped in 1.75s",
+		"<table>" +
+			"<tr><th>Suite</th><th>Passed</th><th>Failed</th><th>Skipped</th><th>Duration</th></tr>" +
+			"<tr><td>math</td><td>1</td><td>1</td><td>1</td><td>1.5s</td></tr>" +
+			"<tr><td>Tests</td><td>0</td><td>1</td><td>0</td><td>250ms</td></tr>" +
+			"<tr><td><strong>Total</strong></td><td>1</td><td>2</td><td>1</td><td>1.75s</td></tr>" +
+			"</table>",
+		"<details><summary>❌ math › divides &lt;by zero&gt;</summary><p><code>math.test.js:12</code></p><p>expected 2 but got 3</p><pre><code>at a &lt; b</code></pre></details>",
+		"<details><summary>❌ Tests › writes</summary></details>",
+		"",
+	}, "\n")
+	if actual != strings.ReplaceAll(expected, "\n", eol()) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+
+	// The failures are limited
+	actual = testReport.AddToSummary(core.NewSummary(), &SummaryOptions{Title: ptr.String("Unit tests"), MaxFailures: ptr.Int(1)}).Stringify()
+	if !strings.HasPrefix(actual, "<h2>Unit tests</h2>") || !strings.HasSuffix(actual, "</details>"+eol()+"1 more failed tests are not shown."+eol()) || strings.Contains(actual, "writes") {
+		t.Fatalf("unexpected summary: %q", actual)
+	}
+}
+
+// eol returns the end of line of the summaries.
+func eol() string {
+	return core.NewSummary().AddEOL().Stringify()
+}
+
+func Test_Annotate(t *testing.T) {
+	var annotations []string
+	original := coreError
+	coreError = func(message string, properties *core.AnnotationProperties) {
+		annotation := fmt.Sprintf("%s: %s", *properties.Title, message)
+		if properties.File != nil {
+			annotation += fmt.Sprintf(" (%s:%d)", *properties.File, *properties.StartLine)
+		}
+		annotations = append(annotations, annotation)
+	}
+	defer func() { coreError = original }()
+
+	testReport.Annotate()
+	expected := []string{"math › divides <by zero>: expected 2 but got 3 (math.test.js:12)", "Tests › writes: The test failed."}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, annotations)
+	}
+}
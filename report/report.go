@@ -0,0 +1,110 @@
+// Package report parses test reports, JUnit XML, TAP and the JSON output of go test, into a
+// common model, and renders them to the job summary and to annotations.
+package report
+
+import (
+	"time"
+)
+
+// Status is the outcome of a test.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Report is the result of a test run.
+type Report struct {
+	Suites []Suite
+}
+
+// Suite is a group of tests, e.g. a JUnit test suite or a Go package.
+type Suite struct {
+	Name  string
+	Tests []Test
+
+	// Duration is the duration of the suite, or 0 if the report does not tell
+	Duration time.Duration
+}
+
+// Test is the result of a test case.
+type Test struct {
+	Name string
+
+	// Classname is the class of a JUnit test case, or empty
+	Classname string
+
+	Status Status
+
+	// Duration is the duration of the test, or 0 if the report does not tell
+	Duration time.Duration
+
+	// Message is the short reason of a failure or a skip
+	Message string
+
+	// Details is the output of a failure, such as a stack trace
+	Details string
+
+	// File and Line locate the test or its failure, if the report tells
+	File string
+	Line int
+}
+
+// Counts are the numbers of tests by outcome.
+type Counts struct {
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// Total returns the number of tests.
+func (c Counts) Total() int {
+	return c.Passed + c.Failed + c.Skipped
+}
+
+func (c *Counts) add(status Status) {
+	switch status {
+	case StatusPassed:
+		c.Passed++
+	case StatusFailed:
+		c.Failed++
+	case StatusSkipped:
+		c.Skipped++
+	}
+}
+
+// Counts returns the numbers of tests of the suite by outcome.
+func (s *Suite) Counts() Counts {
+	var counts Counts
+	for _, test := range s.Tests {
+		counts.add(test.Status)
+	}
+	return counts
+}
+
+// Counts returns the numbers of tests of the report by outcome.
+func (r *Report) Counts() Counts {
+	var counts Counts
+	for _, suite := range r.Suites {
+		for _, test := range suite.Tests {
+			counts.add(test.Status)
+		}
+	}
+	return counts
+}
+
+// Duration returns the sum of the durations of the suites.
+func (r *Report) Duration() time.Duration {
+	var duration time.Duration
+	for _, suite := range r.Suites {
+		duration += suite.Duration
+	}
+	return duration
+}
+
+// Failed returns whether a test failed.
+func (r *Report) Failed() bool {
+	return r.Counts().Failed > 0
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	tapTestLine = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+	tapBailOut  = regexp.MustCompile(`^Bail out!\s*(.*)$`)
+	tapYAMLKey  = regexp.MustCompile(`^\s*(message|duration_ms|file|line):\s*(.*)$`)
+)
+
+// ParseTAP parses a TAP stream, version 12 to 14, into a report of a single suite without a
+// name. Tests with a SKIP directive are skipped, and so are the tests with a TODO directive,
+// which do not fail the stream. The message, duration_ms, file and line fields of the YAML
+// diagnostics of a test are used; the subtests are not reported separately.
+func ParseTAP(r io.Reader) (*Report, error) {
+	suite := Suite{}
+	var test *Test
+	var diagnostics []string
+	inYAML := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+				if test != nil {
+					applyTAPDiagnostics(test, diagnostics)
+				}
+				continue
+			}
+			diagnostics = append(diagnostics, line)
+			continue
+		}
+		if trimmed == "---" && test != nil && line != trimmed {
+			inYAML = true
+			diagnostics = nil
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			// Subtests are summarized by the test line of their parent
+			continue
+		}
+
+		if m := tapBailOut.FindStringSubmatch(line); m != nil {
+			suite.Tests = append(suite.Tests, Test{Name: "Bail out!", Status: StatusFailed, Message: m[1]})
+			test = nil
+			continue
+		}
+		m := tapTestLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		description, directive := splitTAPDirective(m[3])
+		status := StatusPassed
+		if m[1] == "not ok" {
+			status = StatusFailed
+		}
+		var message string
+		switch {
+		case hasPrefixFold(directive, "skip"):
+			status = StatusSkipped
+			message = strings.TrimSpace(directive[len("skip"):])
+		case hasPrefixFold(directive, "todo"):
+			status = StatusSkipped
+			message = strings.TrimSpace(directive[len("todo"):])
+		}
+		name := description
+		if name == "" {
+			name = strings.TrimSpace("test " + m[2])
+		}
+		suite.Tests = append(suite.Tests, Test{Name: name, Status: status, Message: message})
+		test = &suite.Tests[len(suite.Tests)-1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, test := range suite.Tests {
+		suite.Duration += test.Duration
+	}
+	return &Report{Suites: []Suite{suite}}, nil
+}
+
+// splitTAPDirective splits the description of a test line from its directive, after an
+// unescaped #.
+func splitTAPDirective(s string) (string, string) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '#':
+			return strings.TrimSpace(unescapeTAP(s[:i])), strings.TrimSpace(s[i+1:])
+		}
+	}
+	return strings.TrimSpace(unescapeTAP(s)), ""
+}
+
+func unescapeTAP(s string) string {
+	return strings.NewReplacer(`\#`, "#", `\\`, `\`).Replace(s)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// applyTAPDiagnostics applies the YAML diagnostics of a test to it.
+func applyTAPDiagnostics(test *Test, diagnostics []string) {
+	for _, line := range diagnostics {
+		m := tapYAMLKey.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(m[2]), `'"`)
+		switch m[1] {
+		case "message":
+			if test.Message == "" {
+				test.Message = value
+			}
+		case "duration_ms":
+			if ms, err := strconv.ParseFloat(value, 64); err == nil {
+				test.Duration = time.Duration(math.Round(ms * float64(time.Millisecond)))
+			}
+		case "file":
+			if test.File == "" {
+				test.File = value
+			}
+		case "line":
+			if test.Line == 0 {
+				test.Line, _ = strconv.Atoi(value)
+			}
+		}
+	}
+	if test.Status == StatusFailed {
+		test.Details = strings.Join(trimIndent(diagnostics), "\n")
+	}
+}
+
+// trimIndent removes the indentation common to the lines.
+func trimIndent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		res[i] = line
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseTAP(t *testing.T) {
+	file, err := os.Open("testdata/tap.txt")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	defer file.Close()
+	report, err := ParseTAP(file)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+
+	expected := &Report{Suites: []Suite{{
+		Duration: 12500 * time.Microsecond,
+		Tests: []Test{
+			{Name: "adds", Status: StatusPassed},
+			{
+				Name:     "divides",
+				Status:   StatusFailed,
+				Duration: 12500 * time.Microsecond,
+				Message:  "expected 2 but got 3",
+				Details:  "message: expected 2 but got 3\nseverity: fail\nduration_ms: 12.5\nat:\n  file: test/math.js\n  line: 12",
+				File:     "test/math.js",
+				Line:     12,
+			},
+			{Name: "multiplies", Status: StatusSkipped, Message: "not implemented"},
+			{Name: "subtracts", Status: StatusSkipped, Message: "negative numbers"},
+			{Name: "nested", Status: StatusPassed},
+			{Name: "escapes # in descriptions", Status: StatusPassed},
+		},
+	}}}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, report)
+	}
+}
+
+func Test_ParseTAPLines(t *testing.T) {
+	table := []struct {
+		input    string
+		expected []Test
+	}{
+		{input: "ok", expected: []Test{{Name: "test", Status: StatusPassed}}},
+		{input: "ok 1", expected: []Test{{Name: "test 1", Status: StatusPassed}}},
+		{input: "not ok 1 # skip", expected: []Test{{Name: "test 1", Status: StatusSkipped}}},
+		{input: "ok 1 - a\nBail out! database down", expected: []Test{{Name: "a", Status: StatusPassed}, {Name: "Bail out!", Status: StatusFailed, Message: "database down"}}},
+		{input: "okay\nnot okay", expected: nil},
+	}
+	for _, tt := range table {
+		report, err := ParseTAP(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.input)
+		}
+		if !reflect.DeepEqual(report.Suites[0].Tests, tt.expected) {
+			t.Fatalf("expected value is %+v, but result was %+v.\ntest case: %v", tt.expected, report.Suites[0].Tests, tt.input)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package sarif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Merge merges logs into a single log. The runs of the same tool, by name and version, are
+// merged into a single run, whose rules are the union of their rules and whose results are
+// deduplicated.
+func Merge(logs ...*Log) *Log {
+	merged := &Log{Version: Version}
+	index := map[string]int{}
+	for _, log := range logs {
+		if log == nil {
+			continue
+		}
+		if merged.Schema == "" {
+			merged.Schema = log.Schema
+		}
+		for _, run := range log.Runs {
+			key := run.Tool.Driver.Name + "@" + run.Tool.Driver.Version
+			i, ok := index[key]
+			if !ok {
+				i = len(merged.Runs)
+				index[key] = i
+				driver := run.Tool.Driver
+				driver.Rules = nil
+				merged.Runs = append(merged.Runs, Run{Tool: Tool{Driver: driver}})
+			}
+			merged.Runs[i].add(&run)
+		}
+	}
+	merged.Dedupe()
+	return merged
+}
+
+// add adds the rules and the results of a run of the same tool. The rule indexes of the
+// results are replaced by their rule IDs, as the indexes change.
+func (r *Run) add(other *Run) {
+	rules := map[string]bool{}
+	for _, rule := range r.Tool.Driver.Rules {
+		rules[rule.ID] = true
+	}
+	for _, rule := range other.Tool.Driver.Rules {
+		if !rules[rule.ID] {
+			rules[rule.ID] = true
+			r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+		}
+	}
+	for _, result := range other.Results {
+		result.RuleID = other.RuleID(&result)
+		if result.Level == "" {
+			result.Level = other.Level(&result)
+		}
+		result.RuleIndex = nil
+		r.Results = append(r.Results, result)
+	}
+}
+
+// Dedupe removes the duplicated results of the runs of the log. The results are compared by
+// their fingerprints if they have some, else by their rule, their location and their message.
+func (l *Log) Dedupe() {
+	for i := range l.Runs {
+		l.Runs[i].dedupe()
+	}
+}
+
+func (r *Run) dedupe() {
+	seen := map[string]bool{}
+	var results []Result
+	for i := range r.Results {
+		keys := r.resultKeys(&r.Results[i])
+		key := keys.location
+		if keys.fingerprints != "" {
+			key = "fingerprints|" + keys.fingerprints
+		} else if keys.partialFingerprints != "" {
+			key = "partialFingerprints|" + keys.partialFingerprints
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, r.Results[i])
+	}
+	r.Results = results
+}
+
+// resultKeys identify a result of a run, by its fingerprints, by its partial fingerprints, and
+// by its rule, its location and its message. The fingerprint keys are empty when the result has
+// no fingerprints.
+type resultKeys struct {
+	fingerprints        string
+	partialFingerprints string
+	location            string
+}
+
+func (r *Run) resultKeys(result *Result) resultKeys {
+	ruleID := r.RuleID(result)
+	fingerprintsKey := func(fingerprints map[string]string) string {
+		if len(fingerprints) == 0 {
+			return ""
+		}
+		keys := make([]string, 0, len(fingerprints))
+		for key, value := range fingerprints {
+			keys = append(keys, key+"="+value)
+		}
+		sort.Strings(keys)
+		return ruleID + "|" + strings.Join(keys, "|")
+	}
+
+	location := fmt.Sprintf("%s|%s", ruleID, result.File())
+	if region := result.Region(); region != nil {
+		location += fmt.Sprintf("|%d:%d-%d:%d", region.StartLine, region.StartColumn, region.EndLine, region.EndColumn)
+	}
+	return resultKeys{
+		fingerprints:        fingerprintsKey(result.Fingerprints),
+		partialFingerprints: fingerprintsKey(result.PartialFingerprints),
+		location:            location + "|" + result.Message.Text,
+	}
+}
+
+// baselineIndex holds the keys of the results of a baseline by tool.
+type baselineIndex struct {
+	fingerprints        map[string]bool
+	partialFingerprints map[string]bool
+	locations           map[string]bool
+}
+
+// contains reports whether the baseline has the result. The results are compared by their
+// fingerprints when the baseline has fingerprints, else by their partial fingerprints when
+// the baseline has partial fingerprints, else by their rule, their location and their message.
+func (b *baselineIndex) contains(keys resultKeys) bool {
+	switch {
+	case keys.fingerprints != "" && len(b.fingerprints) > 0:
+		return b.fingerprints[keys.fingerprints]
+	case keys.partialFingerprints != "" && len(b.partialFingerprints) > 0:
+		return b.partialFingerprints[keys.partialFingerprints]
+	default:
+		return b.locations[keys.location]
+	}
+}
+
+// Diff returns the results of the log which are not in the baseline, e.g. the log of the
+// base branch of a pull request, with their baseline state set to new. The results are
+// compared by their fingerprints, or by their partial fingerprints, when both logs have them,
+// else by their rule, their location and their message.
+func (l *Log) Diff(baseline *Log) *Log {
+	indexes := map[string]*baselineIndex{}
+	for _, run := range Merge(baseline).Runs {
+		index := indexes[run.Tool.Driver.Name]
+		if index == nil {
+			index = &baselineIndex{fingerprints: map[string]bool{}, partialFingerprints: map[string]bool{}, locations: map[string]bool{}}
+			indexes[run.Tool.Driver.Name] = index
+		}
+		for j := range run.Results {
+			keys := run.resultKeys(&run.Results[j])
+			if keys.fingerprints != "" {
+				index.fingerprints[keys.fingerprints] = true
+			}
+			if keys.partialFingerprints != "" {
+				index.partialFingerprints[keys.partialFingerprints] = true
+			}
+			index.locations[keys.location] = true
+		}
+	}
+
+	diff := Merge(l)
+	for i := range diff.Runs {
+		run := &diff.Runs[i]
+		index := indexes[run.Tool.Driver.Name]
+		var results []Result
+		for j := range run.Results {
+			result := run.Results[j]
+			if index != nil && index.contains(run.resultKeys(&result)) {
+				continue
+			}
+			result.BaselineState = "new"
+			results = append(results, result)
+		}
+		run.Results = results
+	}
+	return diff
+}
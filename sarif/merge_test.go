@@ -0,0 +1,126 @@
+package sarif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+func messages(run Run) []string {
+	var messages []string
+	for _, result := range run.Results {
+		messages = append(messages, result.Message.Text)
+	}
+	return messages
+}
+
+func Test_Merge(t *testing.T) {
+	log := parseTestLog(t, "eslint.sarif")
+	other := &Log{Version: Version, Runs: []Run{
+		{
+			Tool: Tool{Driver: ToolComponent{Name: "ESLint", Version: "8.57.0", Rules: []ReportingDescriptor{{ID: "semi"}, {ID: "no-console"}}}},
+			Results: []Result{
+				// A result of the first log, deduplicated
+				log.Runs[0].Results[2],
+				{RuleIndex: ptr.Int(0), Message: Message{Text: "Missing semicolon."}},
+			},
+		},
+		{
+			Tool:    Tool{Driver: ToolComponent{Name: "gosec"}},
+			Results: []Result{{RuleID: "G104", Fingerprints: map[string]string{"primary": "a"}, Message: Message{Text: "Errors unhandled."}}},
+		},
+		{
+			Tool:    Tool{Driver: ToolComponent{Name: "gosec"}},
+			Results: []Result{{RuleID: "G104", Fingerprints: map[string]string{"primary": "a"}, Message: Message{Text: "Errors unhandled, again."}}},
+		},
+	}}
+
+	merged := Merge(log, nil, other)
+	if len(merged.Runs) != 2 {
+		t.Fatalf("expected value is %v, but result was %v.", 2, len(merged.Runs))
+	}
+	eslint := merged.Runs[0]
+	var rules []string
+	for _, rule := range eslint.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	if expected := []string{"no-unused-vars", "no-console", "semi"}; !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, rules)
+	}
+	expected := []string{"'x' is assigned a value but never used.", "Unexpected console statement.", "'y' is defined but never used.", "Suppressed.", "Missing semicolon."}
+	if actual := messages(eslint); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+	// The rule indexes are replaced by the rule IDs and the levels of the rules are kept
+	semi := eslint.Results[4]
+	if semi.RuleIndex != nil || semi.RuleID != "semi" || eslint.Level(&semi) != LevelWarning {
+		t.Fatalf("unexpected result: %+v", semi)
+	}
+	console := eslint.Results[1]
+	if console.RuleID != "no-console" || eslint.Level(&console) != LevelNote {
+		t.Fatalf("unexpected result: %+v", console)
+	}
+	// The results with the same fingerprints are deduplicated
+	if expected, actual := []string{"Errors unhandled."}, messages(merged.Runs[1]); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+	// The logs are not modified
+	if log.Runs[0].Results[1].RuleIndex == nil {
+		t.Fatal("the merged log was modified")
+	}
+}
+
+func Test_Dedupe(t *testing.T) {
+	result := Result{RuleID: "a", Message: Message{Text: "message"}}
+	log := &Log{Runs: []Run{{Results: []Result{result, result, {RuleID: "b", Message: Message{Text: "message"}}}}}}
+	log.Dedupe()
+	if actual := len(log.Runs[0].Results); actual != 2 {
+		t.Fatalf("expected value is %v, but result was %v.", 2, actual)
+	}
+
+	// The results of different rules given by their index are kept
+	log = &Log{Runs: []Run{{
+		Tool:    Tool{Driver: ToolComponent{Rules: []ReportingDescriptor{{ID: "a"}, {ID: "b"}}}},
+		Results: []Result{{RuleIndex: ptr.Int(0), Message: Message{Text: "message"}}, {RuleIndex: ptr.Int(1), Message: Message{Text: "message"}}},
+	}}}
+	log.Dedupe()
+	if actual := len(log.Runs[0].Results); actual != 2 {
+		t.Fatalf("expected value is %v, but result was %v.", 2, actual)
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	log := parseTestLog(t, "eslint.sarif")
+	baseline := parseTestLog(t, "baseline.sarif")
+
+	diff := log.Diff(baseline)
+	expected := []string{"'x' is assigned a value but never used.", "Unexpected console statement.", "Suppressed."}
+	if actual := messages(diff.Runs[0]); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+	for _, result := range diff.Runs[0].Results {
+		if result.BaselineState != "new" {
+			t.Fatalf("expected value is %v, but result was %v.", "new", result.BaselineState)
+		}
+	}
+
+	// The results are compared by the fingerprints the baseline has
+	tool := Tool{Driver: ToolComponent{Name: "CodeQL"}}
+	baseline = &Log{Runs: []Run{{Tool: tool, Results: []Result{
+		{RuleID: "a", PartialFingerprints: map[string]string{"primaryLocationLineHash": "1"}, Message: Message{Text: "moved"}},
+	}}}}
+	current := &Log{Runs: []Run{{Tool: tool, Results: []Result{
+		{RuleID: "a", Fingerprints: map[string]string{"id": "x"}, PartialFingerprints: map[string]string{"primaryLocationLineHash": "1"}, Message: Message{Text: "known"}},
+		{RuleID: "a", Fingerprints: map[string]string{"id": "y"}, PartialFingerprints: map[string]string{"primaryLocationLineHash": "2"}, Message: Message{Text: "new"}},
+	}}}}
+	if actual, expected := messages(current.Diff(baseline).Runs[0]), []string{"new"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+
+	// The results of other tools are new
+	baseline.Runs[0].Tool.Driver.Name = "other"
+	if actual := len(log.Diff(baseline).Runs[0].Results); actual != 4 {
+		t.Fatalf("expected value is %v, but result was %v.", 4, actual)
+	}
+}
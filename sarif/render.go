@@ -0,0 +1,256 @@
+package sarif
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ci-tools/toolkit/core"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// The functions writing the annotations, overridden by tests.
+var (
+	coreError   = core.Error
+	coreWarning = core.Warning
+	coreNotice  = core.Notice
+)
+
+type AnnotateOptions struct {
+	/** Optional. The maximum number of annotations of a level. The runner shows at most 10 error and 10 warning annotations per step. Defaults to 10 */
+	MaxPerLevel *int
+
+	/** Optional. The maximum number of annotations. The runner shows at most 50 annotations per job. Defaults to 50 */
+	Max *int
+
+	initOnce sync.Once
+}
+
+func initializeAnnotateOptions(options *AnnotateOptions) *AnnotateOptions {
+	if options == nil {
+		options = &AnnotateOptions{}
+	}
+	options.initOnce.Do(options.init)
+	return options
+}
+
+// DO NOT CALL THIS OUTSIDE initializeAnnotateOptions
+func (o *AnnotateOptions) init() {
+	if o.MaxPerLevel == nil {
+		o.MaxPerLevel = ptr.Int(10)
+	}
+	if o.Max == nil {
+		o.Max = ptr.Int(50)
+	}
+}
+
+// annotation is a result to annotate, with the level and the rule ID of its run.
+type annotation struct {
+	level  string
+	ruleID string
+	result *Result
+}
+
+// Annotate writes an annotation per result of the log: errors for the error results, warnings
+// for the warning results and notices for the note results. The results of the level none are
+// ignored. As the runner drops the annotations past its limits, the errors are written first,
+// then the warnings, then the notes, up to the limits of the options. It returns the number of
+// results which were not annotated because of the limits. options may be nil.
+func (l *Log) Annotate(options *AnnotateOptions) int {
+	options = initializeAnnotateOptions(options)
+
+	var annotations []annotation
+	for i := range l.Runs {
+		run := &l.Runs[i]
+		for j := range run.Results {
+			result := &run.Results[j]
+			level := run.Level(result)
+			if levelPriority(level) < 0 {
+				continue
+			}
+			annotations = append(annotations, annotation{level: level, ruleID: run.RuleID(result), result: result})
+		}
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return levelPriority(annotations[i].level) < levelPriority(annotations[j].level)
+	})
+
+	written := 0
+	perLevel := map[string]int{}
+	for _, a := range annotations {
+		if written == *options.Max || perLevel[a.level] == *options.MaxPerLevel {
+			continue
+		}
+		written++
+		perLevel[a.level]++
+		a.write()
+	}
+	return len(annotations) - written
+}
+
+// levelPriority returns the order in which the results of a level are annotated, or -1 if
+// they are not annotated.
+func levelPriority(level string) int {
+	switch level {
+	case LevelError:
+		return 0
+	case LevelWarning:
+		return 1
+	case LevelNote:
+		return 2
+	default:
+		return -1
+	}
+}
+
+func (a annotation) write() {
+	properties := &core.AnnotationProperties{}
+	if a.ruleID != "" {
+		properties.Title = ptr.String(a.ruleID)
+	}
+	if file := a.result.File(); file != "" {
+		properties.File = ptr.String(file)
+	}
+	if region := a.result.Region(); region != nil && region.StartLine > 0 {
+		properties.StartLine = ptr.Int(region.StartLine)
+		if region.EndLine > 0 {
+			properties.EndLine = ptr.Int(region.EndLine)
+		}
+		// The runner ignores the columns of annotations spanning several lines
+		if region.EndLine == 0 || region.EndLine == region.StartLine {
+			if region.StartColumn > 0 {
+				properties.StartColumn = ptr.Int(region.StartColumn)
+			}
+			if region.EndColumn > 0 {
+				properties.EndColumn = ptr.Int(region.EndColumn)
+			}
+		}
+	}
+	message := a.result.Message.Text
+	if message == "" {
+		message = a.result.Message.Markdown
+	}
+
+	switch a.level {
+	case LevelError:
+		coreError(message, properties)
+	case LevelWarning:
+		coreWarning(message, properties)
+	default:
+		coreNotice(message, properties)
+	}
+}
+
+type SummaryOptions struct {
+	/** Optional. The heading of the results. Defaults to "Code scanning results" */
+	Title *string
+
+	initOnce sync.Once
+}
+
+func initializeSummaryOptions(options *SummaryOptions) *SummaryOptions {
+	if options == nil {
+		options = &SummaryOptions{}
+	}
+	options.initOnce.Do(options.init)
+	return options
+}
+
+// DO NOT CALL THIS OUTSIDE initializeSummaryOptions
+func (o *SummaryOptions) init() {
+	if o.Title == nil {
+		o.Title = ptr.String("Code scanning results")
+	}
+}
+
+// ruleCounts is the number of results of a rule, by level.
+type ruleCounts struct {
+	rule   *ReportingDescriptor
+	id     string
+	counts map[string]int
+}
+
+// AddToSummary renders the log to the summary: a heading, and a table per run of the number of
+// results of each rule by level, the rules with errors first. The runs without results are
+// only mentioned. options may be nil.
+func (l *Log) AddToSummary(summary *core.Summary, options *SummaryOptions) *core.Summary {
+	options = initializeSummaryOptions(options)
+
+	summary.AddHeading(html.EscapeString(*options.Title), 2)
+	if len(l.Runs) == 0 {
+		return summary.AddRaw("No results.").AddEOL()
+	}
+
+	header := func(data string) core.SummaryTableCell {
+		return core.SummaryTableCell{Data: data, Header: ptr.Bool(true)}
+	}
+	for i := range l.Runs {
+		run := &l.Runs[i]
+		name := run.Tool.Driver.Name
+		if run.Tool.Driver.Version != "" {
+			name += " " + run.Tool.Driver.Version
+		}
+		summary.AddHeading(html.EscapeString(name), 3)
+		rules := run.ruleCounts()
+		if len(rules) == 0 {
+			summary.AddRaw("No results.").AddEOL()
+			continue
+		}
+
+		rows := []core.SummaryTableRow{{header("Rule"), header("Description"), header("Errors"), header("Warnings"), header("Notes")}}
+		for _, rule := range rules {
+			id := html.EscapeString(rule.id)
+			var description string
+			if rule.rule != nil {
+				if rule.rule.HelpURI != "" {
+					id = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(rule.rule.HelpURI), id)
+				}
+				if rule.rule.ShortDescription != nil {
+					description = html.EscapeString(rule.rule.ShortDescription.Text)
+				}
+			}
+			rows = append(rows, core.SummaryTableRow{
+				{Data: id},
+				{Data: description},
+				{Data: strconv.Itoa(rule.counts[LevelError])},
+				{Data: strconv.Itoa(rule.counts[LevelWarning])},
+				{Data: strconv.Itoa(rule.counts[LevelNote])},
+			})
+		}
+		summary.AddTable(rows)
+	}
+	return summary
+}
+
+// ruleCounts counts the results of the run by rule, ignoring the results of the level none.
+// The rules are sorted by their number of errors, then warnings, then notes, then by ID.
+func (r *Run) ruleCounts() []*ruleCounts {
+	index := map[string]*ruleCounts{}
+	var rules []*ruleCounts
+	for i := range r.Results {
+		result := &r.Results[i]
+		level := r.Level(result)
+		if levelPriority(level) < 0 {
+			continue
+		}
+		id := r.RuleID(result)
+		rule, ok := index[id]
+		if !ok {
+			rule = &ruleCounts{rule: r.Rule(result), id: id, counts: map[string]int{}}
+			index[id] = rule
+			rules = append(rules, rule)
+		}
+		rule.counts[level]++
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		for _, level := range []string{LevelError, LevelWarning, LevelNote} {
+			if rules[i].counts[level] != rules[j].counts[level] {
+				return rules[i].counts[level] > rules[j].counts[level]
+			}
+		}
+		return rules[i].id < rules[j].id
+	})
+	return rules
+}
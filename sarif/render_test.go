@@ -0,0 +1,128 @@
+package sarif
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ci-tools/toolkit/core"
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+// recordAnnotations records the annotations written until the end of the test.
+func recordAnnotations(t *testing.T) *[]string {
+	t.Helper()
+	var annotations []string
+	record := func(level string) func(string, *core.AnnotationProperties) {
+		return func(message string, properties *core.AnnotationProperties) {
+			annotation := level
+			if properties.Title != nil {
+				annotation += " " + *properties.Title
+			}
+			if properties.File != nil {
+				annotation += " " + *properties.File
+			}
+			for _, value := range []*int{properties.StartLine, properties.EndLine, properties.StartColumn, properties.EndColumn} {
+				if value != nil {
+					annotation += fmt.Sprintf(" %d", *value)
+				} else {
+					annotation += " -"
+				}
+			}
+			annotations = append(annotations, annotation+": "+message)
+		}
+	}
+	originalError, originalWarning, originalNotice := coreError, coreWarning, coreNotice
+	coreError, coreWarning, coreNotice = record("error"), record("warning"), record("notice")
+	t.Cleanup(func() {
+		coreError, coreWarning, coreNotice = originalError, originalWarning, originalNotice
+	})
+	return &annotations
+}
+
+func Test_Annotate(t *testing.T) {
+	annotations := recordAnnotations(t)
+	log := parseTestLog(t, "eslint.sarif")
+
+	if skipped := log.Annotate(nil); skipped != 0 {
+		t.Fatalf("expected value is %v, but result was %v.", 0, skipped)
+	}
+	expected := []string{
+		"error no-unused-vars /home/runner/work/app/src/a.js 1 1 7 8: 'x' is assigned a value but never used.",
+		"warning no-unused-vars src/c.js 10 - - -: 'y' is defined but never used.",
+		// The columns of results spanning several lines are dropped
+		"notice no-console src/b.js 3 5 - -: Unexpected console statement.",
+	}
+	if !reflect.DeepEqual(*annotations, expected) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, *annotations)
+	}
+}
+
+func Test_AnnotateLimits(t *testing.T) {
+	var results []Result
+	for i := 0; i < 3; i++ {
+		for _, level := range []string{LevelNote, LevelWarning, LevelError} {
+			results = append(results, Result{Level: level, Message: Message{Text: fmt.Sprintf("%s %d", level, i)}})
+		}
+	}
+	log := &Log{Runs: []Run{{Results: results}}}
+
+	table := []struct {
+		options  *AnnotateOptions
+		skipped  int
+		expected []string
+	}{
+		{
+			options:  &AnnotateOptions{MaxPerLevel: ptr.Int(2)},
+			skipped:  3,
+			expected: []string{"error - - - -: error 0", "error - - - -: error 1", "warning - - - -: warning 0", "warning - - - -: warning 1", "notice - - - -: note 0", "notice - - - -: note 1"},
+		},
+		{
+			options:  &AnnotateOptions{Max: ptr.Int(4)},
+			skipped:  5,
+			expected: []string{"error - - - -: error 0", "error - - - -: error 1", "error - - - -: error 2", "warning - - - -: warning 0"},
+		},
+	}
+	for _, tt := range table {
+		annotations := recordAnnotations(t)
+		if skipped := log.Annotate(tt.options); skipped != tt.skipped {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %+v", tt.skipped, skipped, tt.options)
+		}
+		if !reflect.DeepEqual(*annotations, tt.expected) {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %+v", tt.expected, *annotations, tt.options)
+		}
+	}
+}
+
+func Test_AddToSummary(t *testing.T) {
+	log := parseTestLog(t, "eslint.sarif")
+	log.Runs = append(log.Runs, Run{Tool: Tool{Driver: ToolComponent{Name: "gosec"}}})
+
+	actual := log.AddToSummary(core.NewSummary(), nil).Stringify()
+	expected := strings.Join([]string{
+		"<h2>Code scanning results</h2>",
+		"<h3>ESLint 8.57.0</h3>",
+		"<table>" +
+			"<tr><th>Rule</th><th>Description</th><th>Errors</th><th>Warnings</th><th>Notes</th></tr>" +
+			`<tr><td><a href="https://eslint.org/docs/rules/no-unused-vars">no-unused-vars</a></td><td>Disallow unused variables</td><td>1</td><td>1</td><td>0</td></tr>` +
+			"<tr><td>no-console</td><td>Disallow the use of console</td><td>0</td><td>0</td><td>1</td></tr>" +
+			"</table>",
+		"<h3>gosec</h3>",
+		"No results.",
+		"",
+	}, "\n")
+	if actual != strings.ReplaceAll(expected, "\n", eol()) {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+
+	actual = (&Log{}).AddToSummary(core.NewSummary(), &SummaryOptions{Title: ptr.String("Lint")}).Stringify()
+	if expected := "<h2>Lint</h2>" + eol() + "No results." + eol(); actual != expected {
+		t.Fatalf("expected value is %q, but result was %q.", expected, actual)
+	}
+}
+
+// eol returns the end of line of the summaries.
+func eol() string {
+	return core.NewSummary().AddEOL().Stringify()
+}
@@ -0,0 +1,218 @@
+// Package sarif parses SARIF 2.1.0 logs, the output format of many security and lint tools,
+// merges and diffs them, and renders their results to annotations and to the job summary.
+//
+// Only the properties of the format used to report the results are decoded.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Version is the supported version of SARIF.
+const Version = "2.1.0"
+
+// The levels of the results.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+	LevelNone    = "none"
+)
+
+// KindFail is the kind of the results which are failures, e.g. a vulnerability, unlike the
+// passed checks or the results to review.
+const KindFail = "fail"
+
+// Log is a SARIF log.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema,omitempty"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a run of a tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool is the tool of a run.
+type Tool struct {
+	Driver ToolComponent `json:"driver"`
+}
+
+// ToolComponent describes the tool and its rules.
+type ToolComponent struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules,omitempty"`
+}
+
+// ReportingDescriptor is a rule of a tool.
+type ReportingDescriptor struct {
+	ID                   string                  `json:"id"`
+	Name                 string                  `json:"name,omitempty"`
+	ShortDescription     *Message                `json:"shortDescription,omitempty"`
+	FullDescription      *Message                `json:"fullDescription,omitempty"`
+	HelpURI              string                  `json:"helpUri,omitempty"`
+	DefaultConfiguration *ReportingConfiguration `json:"defaultConfiguration,omitempty"`
+}
+
+// ReportingConfiguration is the configuration of a rule.
+type ReportingConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+// Message is a message of a result or the description of a rule.
+type Message struct {
+	Text     string `json:"text,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// Result is a result of a run, such as a vulnerability or a lint error.
+type Result struct {
+	RuleID string `json:"ruleId,omitempty"`
+
+	// RuleIndex is the index of the rule in the rules of the driver, or nil
+	RuleIndex *int `json:"ruleIndex,omitempty"`
+
+	// Kind is fail, pass, open, informational, notApplicable or review. Defaults to fail
+	Kind string `json:"kind,omitempty"`
+
+	// Level is error, warning, note or none. Defaults to none for the results of another kind
+	// than fail, else to the level of the rule, else to warning
+	Level     string     `json:"level,omitempty"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+
+	Fingerprints        map[string]string `json:"fingerprints,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+
+	// BaselineState is new, unchanged, updated or absent when the result is compared to a
+	// baseline
+	BaselineState string `json:"baselineState,omitempty"`
+}
+
+// Location is a location of a result.
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+}
+
+// PhysicalLocation is a region of a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the location of a file.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a region of a file. The lines and the columns start at 1; 0 means unset.
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// Parse parses a SARIF log.
+func Parse(r io.Reader) (*Log, error) {
+	log := &Log{}
+	if err := json.NewDecoder(r).Decode(log); err != nil {
+		return nil, fmt.Errorf("failed to parse the SARIF log: %w", err)
+	}
+	if log.Version != Version {
+		return nil, fmt.Errorf("the SARIF version %q is not supported, only %s is", log.Version, Version)
+	}
+	return log, nil
+}
+
+// ParseFile parses the SARIF log of a file.
+func ParseFile(path string) (*Log, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Rule returns the rule of a result, from its rule index or its rule ID, or nil.
+func (r *Run) Rule(result *Result) *ReportingDescriptor {
+	rules := r.Tool.Driver.Rules
+	if result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(rules) {
+		return &rules[*result.RuleIndex]
+	}
+	for i := range rules {
+		if rules[i].ID == result.RuleID {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// RuleID returns the rule ID of a result, from its rule if it has none.
+func (r *Run) RuleID(result *Result) string {
+	if result.RuleID != "" {
+		return result.RuleID
+	}
+	if rule := r.Rule(result); rule != nil {
+		return rule.ID
+	}
+	return ""
+}
+
+// Level returns the level of a result: its level, else none if it is not a failure, else the
+// default level of its rule, else warning.
+func (r *Run) Level(result *Result) string {
+	if result.Level != "" {
+		return result.Level
+	}
+	if result.Kind != "" && result.Kind != KindFail {
+		return LevelNone
+	}
+	if rule := r.Rule(result); rule != nil && rule.DefaultConfiguration != nil && rule.DefaultConfiguration.Level != "" {
+		return rule.DefaultConfiguration.Level
+	}
+	return LevelWarning
+}
+
+// File returns the path of the file of the first physical location of the result, or an
+// empty string. File URIs are converted to paths.
+func (r *Result) File() string {
+	location := r.physicalLocation()
+	if location == nil {
+		return ""
+	}
+	uri := location.ArtifactLocation.URI
+	if u, err := url.Parse(uri); err == nil && (u.Scheme == "file" || u.Scheme == "") {
+		return strings.TrimPrefix(u.Path, "./")
+	}
+	return uri
+}
+
+// Region returns the region of the first physical location of the result, or nil.
+func (r *Result) Region() *Region {
+	if location := r.physicalLocation(); location != nil {
+		return location.Region
+	}
+	return nil
+}
+
+func (r *Result) physicalLocation() *PhysicalLocation {
+	for _, location := range r.Locations {
+		if location.PhysicalLocation != nil {
+			return location.PhysicalLocation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package sarif
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseTestLog(t *testing.T, name string) *Log {
+	t.Helper()
+	log, err := ParseFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	return log
+}
+
+func Test_Parse(t *testing.T) {
+	table := []struct {
+		name string
+		log  string
+		err  string
+	}{
+		{name: "valid", log: `{"version": "2.1.0", "runs": []}`},
+		{name: "invalid JSON", log: `{"version": "2.1.0", "runs": {}}`, err: "failed to parse the SARIF log: json: cannot unmarshal object into Go struct field Log.runs of type []sarif.Run"},
+		{name: "unsupported version", log: `{"version": "1.0.0", "runs": []}`, err: `the SARIF version "1.0.0" is not supported, only 2.1.0 is`},
+	}
+	for _, tt := range table {
+		_, err := Parse(strings.NewReader(tt.log))
+		if tt.err == "" {
+			if err != nil {
+				t.Fatalf("errors are not expected but found: %v\ntest case: %v", err, tt.name)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.err {
+			t.Fatalf("expected value is %q, but result was %v.\ntest case: %v", tt.err, err, tt.name)
+		}
+	}
+
+	if _, err := ParseFile(filepath.Join("testdata", "missing.sarif")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func Test_Results(t *testing.T) {
+	log := parseTestLog(t, "eslint.sarif")
+	run := &log.Runs[0]
+
+	type result struct {
+		ruleID string
+		level  string
+		file   string
+		region *Region
+	}
+	expected := []result{
+		{ruleID: "no-unused-vars", level: "error", file: "/home/runner/work/app/src/a.js", region: &Region{StartLine: 1, StartColumn: 7, EndLine: 1, EndColumn: 8}},
+		{ruleID: "no-console", level: "note", file: "src/b.js", region: &Region{StartLine: 3, StartColumn: 1, EndLine: 5, EndColumn: 2}},
+		{ruleID: "no-unused-vars", level: "warning", file: "src/c.js", region: &Region{StartLine: 10}},
+		{ruleID: "no-console", level: "none"},
+	}
+	var actual []result
+	for i := range run.Results {
+		r := &run.Results[i]
+		actual = append(actual, result{ruleID: run.RuleID(r), level: run.Level(r), file: r.File(), region: r.Region()})
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected value is %+v, but result was %+v.", expected, actual)
+	}
+
+	// The results which are not failures have no level by default
+	for _, kind := range []string{"pass", "open", "informational", "notApplicable", "review"} {
+		if level := run.Level(&Result{RuleID: "no-unused-vars", Kind: kind}); level != LevelNone {
+			t.Fatalf("expected value is %v, but result was %v.\ntest case: %v", LevelNone, level, kind)
+		}
+	}
+	if level := run.Level(&Result{RuleID: "no-console", Kind: KindFail}); level != LevelNote {
+		t.Fatalf("expected value is %v, but result was %v.", LevelNote, level)
+	}
+
+	// Results without a rule are warnings
+	if level := (&Run{}).Level(&Result{RuleID: "unknown"}); level != LevelWarning {
+		t.Fatalf("expected value is %v, but result was %v.", LevelWarning, level)
+	}
+	// Other URIs are kept
+	uri := "https://example.com/a.js"
+	r := &Result{Locations: []Location{{PhysicalLocation: &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: uri}}}}}
+	if file := r.File(); file != uri {
+		t.Fatalf("expected value is %v, but result was %v.", uri, file)
+	}
+}
@@ -0,0 +1,466 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	xRangeIdentifier      = numericIdentifier + `|x|X|\*`
+	xRangeIdentifierLoose = numericIdentifierLoose + `|x|X|\*`
+
+	xRangePlain = `[v=\s]*(` + xRangeIdentifier + `)(?:\.(` + xRangeIdentifier + `)(?:\.(` + xRangeIdentifier + `)` +
+		`(?:` + prerelease + `)?` + build + `?)?)?`
+	xRangePlainLoose = `[v=\s]*(` + xRangeIdentifierLoose + `)(?:\.(` + xRangeIdentifierLoose + `)(?:\.(` + xRangeIdentifierLoose + `)` +
+		`(?:` + prereleaseLoose + `)?` + build + `?)?)?`
+)
+
+var (
+	partialRegexp      = regexp.MustCompile(`^` + xRangePlain + `$`)
+	partialLooseRegexp = regexp.MustCompile(`^` + xRangePlainLoose + `$`)
+	operatorTrimRegexp = regexp.MustCompile(`(~>?|\^|[<>]=?|=)\s+`)
+	hyphenRegexp       = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+	operatorRegexp     = regexp.MustCompile(`^(~>?|\^|[<>]=?|=)?(.*)$`)
+	orRegexp           = regexp.MustCompile(`\s*\|\|\s*`)
+)
+
+// comparator tests a version against a single bound.
+type comparator struct {
+	// operator is empty for a comparator matching any version
+	operator string
+	semver   *Version
+}
+
+func (c comparator) String() string {
+	if c.semver == nil {
+		return ""
+	}
+	return c.operator + c.semver.String()
+}
+
+func (c comparator) test(v *Version) bool {
+	if c.semver == nil {
+		return true
+	}
+	switch c.operator {
+	case "<":
+		return v.Compare(c.semver) < 0
+	case "<=":
+		return v.Compare(c.semver) <= 0
+	case ">":
+		return v.Compare(c.semver) > 0
+	case ">=":
+		return v.Compare(c.semver) >= 0
+	default:
+		return v.Compare(c.semver) == 0
+	}
+}
+
+// Range is a union of comparator sets. A version satisfies the range when it satisfies all the
+// comparators of any set.
+type Range struct {
+	set     [][]comparator
+	options *Options
+}
+
+// NewRange parses the range. options may be nil.
+func NewRange(versionRange string, options *Options) (*Range, error) {
+	r := &Range{options: options}
+	for _, part := range orRegexp.Split(strings.TrimSpace(versionRange), -1) {
+		set, err := r.parseComparatorSet(part)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid SemVer Range: %s", versionRange)
+		}
+		r.set = append(r.set, set)
+	}
+
+	// Like node-semver, drop null sets when there are others, and collapse
+	// the range to a single set when any of them matches everything.
+	if len(r.set) > 1 {
+		first := r.set[0]
+		var sets [][]comparator
+		for _, set := range r.set {
+			if set[0].String() != "<0.0.0-0" {
+				sets = append(sets, set)
+			}
+		}
+		if len(sets) == 0 {
+			sets = [][]comparator{first}
+		}
+		for _, set := range sets {
+			if len(set) == 1 && set[0].semver == nil {
+				sets = [][]comparator{set}
+				break
+			}
+		}
+		r.set = sets
+	}
+	return r, nil
+}
+
+// String returns the normalized range, e.g. `^1.2` => `>=1.2.0 <2.0.0-0`.
+func (r *Range) String() string {
+	sets := make([]string, 0, len(r.set))
+	for _, set := range r.set {
+		comparators := make([]string, 0, len(set))
+		for _, c := range set {
+			comparators = append(comparators, c.String())
+		}
+		sets = append(sets, strings.TrimSpace(strings.Join(comparators, " ")))
+	}
+	return strings.Join(sets, "||")
+}
+
+// Test reports whether the version satisfies the range.
+func (r *Range) Test(v *Version) bool {
+	for _, set := range r.set {
+		if r.testSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Range) testSet(set []comparator, v *Version) bool {
+	for _, c := range set {
+		if !c.test(v) {
+			return false
+		}
+	}
+
+	if len(v.Prerelease) == 0 || r.options.includePrerelease() {
+		return true
+	}
+
+	// Find the set of versions that are allowed to have prereleases. For example, ^1.2.3-pr.1
+	// desugars to >=1.2.3-pr.1 <2.0.0-0. That should allow `1.2.3-pr.2` to pass. However,
+	// `1.2.4-alpha.notready` should NOT be allowed, even though it's within the range set by
+	// the comparators.
+	for _, c := range set {
+		if c.semver == nil || len(c.semver.Prerelease) == 0 {
+			continue
+		}
+		if c.semver.Major == v.Major && c.semver.Minor == v.Minor && c.semver.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// Satisfies reports whether the version satisfies the range. Invalid versions and ranges never do.
+func Satisfies(version, versionRange string, options *Options) bool {
+	r, err := NewRange(versionRange, options)
+	if err != nil {
+		return false
+	}
+	v, err := Parse(version, options)
+	if err != nil {
+		return false
+	}
+	return r.Test(v)
+}
+
+// MaxSatisfying returns the highest version in the list satisfying the range, or an empty string.
+// Invalid versions are ignored.
+func MaxSatisfying(versions []string, versionRange string, options *Options) string {
+	return bestSatisfying(versions, versionRange, options, 1)
+}
+
+// MinSatisfying returns the lowest version in the list satisfying the range, or an empty string.
+// Invalid versions are ignored.
+func MinSatisfying(versions []string, versionRange string, options *Options) string {
+	return bestSatisfying(versions, versionRange, options, -1)
+}
+
+func bestSatisfying(versions []string, versionRange string, options *Options, sign int) string {
+	r, err := NewRange(versionRange, options)
+	if err != nil {
+		return ""
+	}
+	var (
+		best   string
+		bestSV *Version
+	)
+	for _, version := range versions {
+		v, err := Parse(version, options)
+		if err != nil || !r.Test(v) {
+			continue
+		}
+		if bestSV == nil || v.Compare(bestSV) == sign {
+			best, bestSV = version, v
+		}
+	}
+	return best
+}
+
+// ValidRange returns the normalized range, `*` for a range matching any version,
+// or an empty string if the range is not valid.
+func ValidRange(versionRange string, options *Options) string {
+	r, err := NewRange(versionRange, options)
+	if err != nil {
+		return ""
+	}
+	if s := r.String(); s != "" {
+		return s
+	}
+	return "*"
+}
+
+// Sort sorts the versions in ascending order, using the build metadata as the last resort.
+func Sort(versions []*Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].CompareBuild(versions[j]) < 0
+	})
+}
+
+// partial is a version where missing or wildcard parts are -1.
+type partial struct {
+	major, minor, patch int64
+	prerelease          []string
+}
+
+func (r *Range) parsePartial(s string) (partial, error) {
+	if s == "" {
+		return partial{-1, -1, -1, nil}, nil
+	}
+	re := partialRegexp
+	if r.options.loose() {
+		re = partialLooseRegexp
+	}
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return partial{}, fmt.Errorf("Invalid comparator: %s", s)
+	}
+
+	xr := func(s string) (int64, error) {
+		if s == "" || s == "x" || s == "X" || s == "*" {
+			return -1, nil
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n > maxSafeInteger {
+			return 0, fmt.Errorf("Invalid comparator: %s", s)
+		}
+		return n, nil
+	}
+	var (
+		p   partial
+		err error
+	)
+	if p.major, err = xr(m[1]); err != nil {
+		return p, err
+	}
+	if p.minor, err = xr(m[2]); err != nil {
+		return p, err
+	}
+	if p.patch, err = xr(m[3]); err != nil {
+		return p, err
+	}
+	// Parts after a wildcard are wildcards
+	if p.major < 0 {
+		p.minor = -1
+	}
+	if p.minor < 0 {
+		p.patch = -1
+	}
+	if p.patch >= 0 && m[4] != "" {
+		p.prerelease = strings.Split(m[4], ".")
+	}
+	return p, nil
+}
+
+func ver(major, minor, patch int64, prerelease ...string) *Version {
+	return &Version{Major: uint64(major), Minor: uint64(minor), Patch: uint64(patch), Prerelease: prerelease}
+}
+
+// lower returns the lowest version matching the partial. With includePrerelease, the lowest
+// version of a wildcard or release partial is its `-0` prerelease.
+func (p partial) lower(z []string) *Version {
+	v := &Version{Prerelease: p.prerelease}
+	if p.major > 0 {
+		v.Major = uint64(p.major)
+	}
+	if p.minor > 0 {
+		v.Minor = uint64(p.minor)
+	}
+	if p.patch > 0 {
+		v.Patch = uint64(p.patch)
+	}
+	if len(v.Prerelease) == 0 {
+		v.Prerelease = z
+	}
+	return v
+}
+
+func (r *Range) parseComparatorSet(s string) ([]comparator, error) {
+	s = operatorTrimRegexp.ReplaceAllString(strings.TrimSpace(s), "$1")
+
+	// >=0.0.0 is equivalent to *, or >=0.0.0-0 when prereleases are included.
+	var z []string
+	gte0 := ">=0.0.0"
+	if r.options.includePrerelease() {
+		z = []string{"0"}
+		gte0 = ">=0.0.0-0"
+	}
+
+	if m := hyphenRegexp.FindStringSubmatch(s); m != nil {
+		from, err := r.parsePartial(m[1])
+		if err != nil {
+			return nil, err
+		}
+		to, err := r.parsePartial(m[2])
+		if err != nil {
+			return nil, err
+		}
+		return normalizeSet(hyphenRange(from, to, z), gte0), nil
+	}
+
+	var set []comparator
+	for _, token := range strings.Fields(s) {
+		m := operatorRegexp.FindStringSubmatch(token)
+		p, err := r.parsePartial(m[2])
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, desugar(m[1], p, z)...)
+	}
+	return normalizeSet(set, gte0), nil
+}
+
+// normalizeSet replaces a set containing a comparator matching nothing with just that comparator,
+// removes the comparators matching any version (including gte0) from sets with other comparators,
+// and removes duplicates.
+func normalizeSet(set []comparator, gte0 string) []comparator {
+	var result []comparator
+	seen := map[string]bool{}
+	for _, c := range set {
+		if c.String() == "<0.0.0-0" {
+			return []comparator{c}
+		}
+		if c.semver != nil && c.String() != gte0 && !seen[c.String()] {
+			seen[c.String()] = true
+			result = append(result, c)
+		}
+	}
+	if len(result) == 0 {
+		return []comparator{{}}
+	}
+	return result
+}
+
+func hyphenRange(from, to partial, z []string) []comparator {
+	var set []comparator
+	switch {
+	case from.major < 0:
+	case len(from.prerelease) > 0:
+		set = append(set, comparator{">=", from.lower(nil)})
+	default:
+		set = append(set, comparator{">=", from.lower(z)})
+	}
+	switch {
+	case to.major < 0:
+	case to.minor < 0:
+		set = append(set, comparator{"<", ver(to.major+1, 0, 0, "0")})
+	case to.patch < 0:
+		set = append(set, comparator{"<", ver(to.major, to.minor+1, 0, "0")})
+	case len(to.prerelease) > 0:
+		set = append(set, comparator{"<=", to.lower(nil)})
+	case z != nil:
+		set = append(set, comparator{"<", ver(to.major, to.minor, to.patch+1, "0")})
+	default:
+		set = append(set, comparator{"<=", to.lower(nil)})
+	}
+	return set
+}
+
+// desugar converts tilde, caret and x-ranges into primitive comparators.
+func desugar(operator string, p partial, z []string) []comparator {
+	switch operator {
+	case "~", "~>":
+		switch {
+		case p.major < 0:
+			return []comparator{{}}
+		case p.minor < 0:
+			return []comparator{{">=", p.lower(nil)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		default:
+			return []comparator{{">=", p.lower(nil)}, {"<", ver(p.major, p.minor+1, 0, "0")}}
+		}
+
+	case "^":
+		switch {
+		case p.major < 0:
+			return []comparator{{}}
+		case p.minor < 0:
+			return []comparator{{">=", p.lower(z)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		case p.patch < 0 && p.major == 0:
+			return []comparator{{">=", p.lower(z)}, {"<", ver(0, p.minor+1, 0, "0")}}
+		case p.patch < 0:
+			return []comparator{{">=", p.lower(z)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		case p.major == 0 && p.minor == 0:
+			return []comparator{{">=", p.lower(z)}, {"<", ver(0, 0, p.patch+1, "0")}}
+		case p.major == 0:
+			return []comparator{{">=", p.lower(z)}, {"<", ver(0, p.minor+1, 0, "0")}}
+		case len(p.prerelease) > 0:
+			return []comparator{{">=", p.lower(nil)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		default:
+			return []comparator{{">=", p.lower(nil)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		}
+	}
+
+	if operator == "=" && p.patch < 0 {
+		operator = ""
+	}
+
+	// X-ranges
+	switch {
+	case p.patch >= 0:
+		if operator == "=" {
+			operator = ""
+		}
+		return []comparator{{operator, p.lower(nil)}}
+
+	case p.major < 0:
+		if operator == "<" || operator == ">" {
+			// Nothing is allowed
+			return []comparator{{"<", ver(0, 0, 0, "0")}}
+		}
+		// Nothing is forbidden
+		return []comparator{{}}
+
+	case operator == "":
+		if p.minor < 0 {
+			return []comparator{{">=", p.lower(z)}, {"<", ver(p.major+1, 0, 0, "0")}}
+		}
+		return []comparator{{">=", p.lower(z)}, {"<", ver(p.major, p.minor+1, 0, "0")}}
+	}
+
+	major, minor := p.major, p.minor
+	if minor < 0 {
+		minor = 0
+	}
+	pr := z
+	switch operator {
+	case ">":
+		// >1 => >=2.0.0, >1.2 => >=1.3.0
+		operator = ">="
+		if p.minor < 0 {
+			major, minor = major+1, 0
+		} else {
+			minor++
+		}
+	case "<=":
+		// <=0.7.x is actually <0.8.0, since any 0.7.x should pass
+		operator = "<"
+		if p.minor < 0 {
+			major++
+		} else {
+			minor++
+		}
+	}
+	if operator == "<" {
+		pr = []string{"0"}
+	}
+	return []comparator{{operator, ver(major, minor, 0, pr...)}}
+}
@@ -0,0 +1,243 @@
+// Package semver parses and compares versions and evaluates ranges compatibly with the
+// node-semver npm package, which JavaScript actions use to interpret version inputs.
+//
+// Ranges support primitive comparators (`<`, `<=`, `>`, `>=`, `=`), x-ranges (`1.x`, `1.2.*`, `1`),
+// tilde ranges (`~1.2.3`), caret ranges (`^1.2.3`), hyphen ranges (`1.2.3 - 2.3.4`), intersections
+// separated by spaces and unions separated by `||`. Aliases such as `lts/*` or `latest` are not
+// ranges, and must be resolved by the caller.
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Options controls parsing and range evaluation.
+type Options struct {
+	/** Optional. Whether to be forgiving about not-quite-valid versions, e.g. `=v1.2.3` or `1.2.3beta`. Defaults to false */
+	Loose *bool
+
+	/** Optional. Whether prerelease versions satisfy ranges without a prerelease comparator of the same major.minor.patch. Defaults to false */
+	IncludePrerelease *bool
+}
+
+func (o *Options) loose() bool {
+	return o != nil && o.Loose != nil && *o.Loose
+}
+
+func (o *Options) includePrerelease() bool {
+	return o != nil && o.IncludePrerelease != nil && *o.IncludePrerelease
+}
+
+// maxSafeInteger is Number.MAX_SAFE_INTEGER, the largest allowed version number.
+const maxSafeInteger = 1<<53 - 1
+
+const (
+	numericIdentifier      = `0|[1-9]\d*`
+	numericIdentifierLoose = `\d+`
+	nonNumericIdentifier   = `\d*[a-zA-Z-][a-zA-Z0-9-]*`
+	buildIdentifier        = `[0-9A-Za-z-]+`
+
+	prereleaseIdentifier      = `(?:` + numericIdentifier + `|` + nonNumericIdentifier + `)`
+	prereleaseIdentifierLoose = `(?:` + numericIdentifierLoose + `|` + nonNumericIdentifier + `)`
+
+	prerelease      = `(?:-(` + prereleaseIdentifier + `(?:\.` + prereleaseIdentifier + `)*))`
+	prereleaseLoose = `(?:-?(` + prereleaseIdentifierLoose + `(?:\.` + prereleaseIdentifierLoose + `)*))`
+	build           = `(?:\+(` + buildIdentifier + `(?:\.` + buildIdentifier + `)*))`
+
+	mainVersion      = `(` + numericIdentifier + `)\.(` + numericIdentifier + `)\.(` + numericIdentifier + `)`
+	mainVersionLoose = `(` + numericIdentifierLoose + `)\.(` + numericIdentifierLoose + `)\.(` + numericIdentifierLoose + `)`
+)
+
+var (
+	fullRegexp   = regexp.MustCompile(`^v?` + mainVersion + prerelease + `?` + build + `?$`)
+	looseRegexp  = regexp.MustCompile(`^[v=\s]*` + mainVersionLoose + prereleaseLoose + `?` + build + `?$`)
+	coerceRegexp = regexp.MustCompile(`(?:^|[^\d])(\d{1,16})(?:\.(\d{1,16}))?(?:\.(\d{1,16}))?(?:$|[^\d])`)
+	numeric      = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses the version. Leading and trailing whitespace is ignored. options may be nil.
+func Parse(version string, options *Options) (*Version, error) {
+	re := fullRegexp
+	if options.loose() {
+		re = looseRegexp
+	}
+	m := re.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return nil, fmt.Errorf("Invalid Version: %s", version)
+	}
+
+	v := &Version{}
+	for i, p := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.ParseUint(m[i+1], 10, 64)
+		if err != nil || n > maxSafeInteger {
+			return nil, fmt.Errorf("Invalid Version: %s", version)
+		}
+		*p = n
+	}
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	if m[5] != "" {
+		v.Build = strings.Split(m[5], ".")
+	}
+	return v, nil
+}
+
+// MustParse is like Parse but panics if the version cannot be parsed.
+func MustParse(version string) *Version {
+	v, err := Parse(version, nil)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Valid returns the normalized version, or an empty string if it is not valid.
+func Valid(version string, options *Options) string {
+	v, err := Parse(version, options)
+	if err != nil {
+		return ""
+	}
+	return v.String()
+}
+
+// Clean returns the normalized version after removing leading `=` and `v` characters, e.g.
+// `  =v1.2.3  ` => `1.2.3`, or an empty string if it is not valid.
+func Clean(version string, options *Options) string {
+	return Valid(strings.TrimLeft(strings.TrimSpace(version), "=v"), options)
+}
+
+// Coerce converts the first version-like part of the string into a version, e.g. `v2` => `2.0.0`
+// and `42.6.7.9.3-alpha` => `42.6.7`. Returns nil if the string contains no number.
+func Coerce(version string) *Version {
+	m := coerceRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return nil
+	}
+	minor, patch := m[2], m[3]
+	if minor == "" {
+		minor = "0"
+	}
+	if patch == "" {
+		patch = "0"
+	}
+	v, err := Parse(m[1]+"."+minor+"."+patch, nil)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// String returns the normalized version, without the build metadata.
+func (v *Version) String() string {
+	s := strconv.FormatUint(v.Major, 10) + "." + strconv.FormatUint(v.Minor, 10) + "." + strconv.FormatUint(v.Patch, 10)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than o.
+// The build metadata is ignored.
+func (v *Version) Compare(o *Version) int {
+	if c := v.compareMain(o); c != 0 {
+		return c
+	}
+	return v.comparePre(o)
+}
+
+// CompareBuild is like Compare, but versions which only differ in the build metadata are ordered by it.
+func (v *Version) CompareBuild(o *Version) int {
+	if c := v.Compare(o); c != 0 {
+		return c
+	}
+	return compareIdentifierLists(v.Build, o.Build)
+}
+
+// GreaterThan reports whether v > o.
+func (v *Version) GreaterThan(o *Version) bool {
+	return v.Compare(o) > 0
+}
+
+// LessThan reports whether v < o.
+func (v *Version) LessThan(o *Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal reports whether v == o, ignoring the build metadata.
+func (v *Version) Equal(o *Version) bool {
+	return v.Compare(o) == 0
+}
+
+func (v *Version) compareMain(o *Version) int {
+	for _, pair := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v *Version) comparePre(o *Version) int {
+	// A version without prerelease has higher precedence
+	switch {
+	case len(v.Prerelease) > 0 && len(o.Prerelease) == 0:
+		return -1
+	case len(v.Prerelease) == 0 && len(o.Prerelease) > 0:
+		return 1
+	}
+	return compareIdentifierLists(v.Prerelease, o.Prerelease)
+}
+
+// compareIdentifierLists compares the identifiers pairwise. A shorter list has lower precedence
+// when all the preceding identifiers are equal.
+func compareIdentifierLists(a, b []string) int {
+	for i := 0; ; i++ {
+		switch {
+		case i == len(a) && i == len(b):
+			return 0
+		case i == len(a):
+			return -1
+		case i == len(b):
+			return 1
+		}
+		if c := compareIdentifiers(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+}
+
+// compareIdentifiers compares numeric identifiers numerically, and lower than alphanumeric identifiers.
+func compareIdentifiers(a, b string) int {
+	aNum, bNum := numeric.MatchString(a), numeric.MatchString(b)
+	switch {
+	case aNum && bNum:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
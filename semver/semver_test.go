@@ -0,0 +1,152 @@
+package semver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ci-tools/toolkit/ptr"
+)
+
+var loose = &Options{Loose: ptr.Bool(true)}
+
+func Test_Valid(t *testing.T) {
+	table := []struct {
+		version  string
+		options  *Options
+		expected string
+	}{
+		{version: "1.2.3", expected: "1.2.3"},
+		{version: " v1.2.3 ", expected: "1.2.3"},
+		{version: "1.2.3-beta.1+build.5", expected: "1.2.3-beta.1"},
+		{version: "1.2.3-0", expected: "1.2.3-0"},
+		{version: "=1.2.3", expected: ""},
+		{version: "=1.2.3", options: loose, expected: "1.2.3"},
+		{version: "1.2.3beta", expected: ""},
+		{version: "1.2.3beta", options: loose, expected: "1.2.3-beta"},
+		{version: "01.2.3", expected: ""},
+		{version: "01.2.3", options: loose, expected: "1.2.3"},
+		{version: "1.2.3-01", expected: ""},
+		{version: "1.2", expected: ""},
+		{version: "1.2.3.4", expected: ""},
+		{version: "9007199254740991.0.0", expected: "9007199254740991.0.0"},
+		{version: "9007199254740992.0.0", expected: ""},
+		{version: "", expected: ""},
+	}
+
+	for _, tt := range table {
+		if val := Valid(tt.version, tt.options); val != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %q", tt.expected, val, tt.version)
+		}
+	}
+}
+
+func Test_Clean(t *testing.T) {
+	table := []struct {
+		version  string
+		expected string
+	}{
+		{version: "1.2.3", expected: "1.2.3"},
+		{version: " 1.2.3 ", expected: "1.2.3"},
+		{version: " 1.2.3-4 ", expected: "1.2.3-4"},
+		{version: " 1.2.3-pre ", expected: "1.2.3-pre"},
+		{version: "  =v1.2.3   ", expected: "1.2.3"},
+		{version: "v1.2.3", expected: "1.2.3"},
+		{version: " v1.2.3 ", expected: "1.2.3"},
+		{version: "\t1.2.3", expected: "1.2.3"},
+		{version: ">1.2.3", expected: ""},
+		{version: "~1.2.3", expected: ""},
+		{version: "<=1.2.3", expected: ""},
+		{version: "1.2.x", expected: ""},
+		{version: "0.12.0-dev.1150+3c22cecee", expected: "0.12.0-dev.1150"},
+	}
+
+	for _, tt := range table {
+		if val := Clean(tt.version, nil); val != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %q", tt.expected, val, tt.version)
+		}
+	}
+}
+
+func Test_Coerce(t *testing.T) {
+	table := []struct {
+		version  string
+		expected string
+	}{
+		{version: "v2", expected: "2.0.0"},
+		{version: "v1.2", expected: "1.2.0"},
+		{version: "1.2.3", expected: "1.2.3"},
+		{version: "42.6.7.9.3-alpha", expected: "42.6.7"},
+		{version: "version 3.10.4 (final)", expected: "3.10.4"},
+		{version: "go1.21rc2", expected: "1.21.0"},
+		{version: "1.2.3.4", expected: "1.2.3"},
+		{version: "12345678901234567890", expected: ""},
+		{version: "v.x", expected: ""},
+		{version: "", expected: ""},
+	}
+
+	for _, tt := range table {
+		val := ""
+		if v := Coerce(tt.version); v != nil {
+			val = v.String()
+		}
+		if val != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %q", tt.expected, val, tt.version)
+		}
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	// Each version is greater than the next one
+	table := [][2]string{
+		{"0.0.0", "0.0.0-foo"},
+		{"0.0.1", "0.0.0"},
+		{"1.0.0", "0.9.9"},
+		{"0.10.0", "0.9.0"},
+		{"0.99.0", "0.10.0"},
+		{"2.0.0", "1.2.3"},
+		{"1.2.3", "1.2.3-asdf"},
+		{"1.2.3", "1.2.3-4"},
+		{"1.2.3", "1.2.3-4-foo"},
+		{"1.2.3-5-foo", "1.2.3-5"},
+		{"1.2.3-5", "1.2.3-4"},
+		{"1.2.3-5-foo", "1.2.3-5-Foo"},
+		{"3.0.0", "2.7.2+asdf"},
+		{"1.2.3-a.10", "1.2.3-a.5"},
+		{"1.2.3-a.b", "1.2.3-a.5"},
+		{"1.2.3-a.b", "1.2.3-a"},
+		{"1.2.3-a.b.c.10.d.5", "1.2.3-a.b.c.5.d.100"},
+		{"1.2.3-r2", "1.2.3-r100"},
+		{"1.2.3-r100", "1.2.3-R2"},
+		{"1.2.3-beta.11", "1.2.3-beta.2"},
+		{"1.2.3-rc.1", "1.2.3-beta.11"},
+	}
+
+	for _, tt := range table {
+		a, b := MustParse(tt[0]), MustParse(tt[1])
+		if a.Compare(b) != 1 || b.Compare(a) != -1 || !a.GreaterThan(b) || !b.LessThan(a) || a.Equal(b) {
+			t.Fatalf("expected %s > %s", tt[0], tt[1])
+		}
+		if a.Compare(a) != 0 || !a.Equal(a) {
+			t.Fatalf("expected %s == %s", tt[0], tt[0])
+		}
+	}
+
+	if a, b := MustParse("1.2.3+build.1"), MustParse("1.2.3+build.2"); !a.Equal(b) || a.CompareBuild(b) != -1 {
+		t.Fatal("expected the build metadata to be ignored by Compare but not by CompareBuild")
+	}
+}
+
+func Test_Sort(t *testing.T) {
+	versions := []*Version{MustParse("1.2.3"), MustParse("1.2.3-beta"), MustParse("0.1.0"), MustParse("1.2.3+b"), MustParse("10.0.0")}
+	Sort(versions)
+	var val []string
+	for _, v := range versions {
+		val = append(val, v.String())
+	}
+	if expected := []string{"0.1.0", "1.2.3-beta", "1.2.3", "1.2.3", "10.0.0"}; !reflect.DeepEqual(val, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, val)
+	}
+	if len(versions[3].Build) != 1 {
+		t.Fatal("expected the version with build metadata to be sorted last")
+	}
+}
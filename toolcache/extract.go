@@ -0,0 +1,242 @@
+package toolcache
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ExtractTar extracts a compressed or uncompressed tar with the tar command and returns the destination.
+// flags default to `xz`; pass e.g. `x` for an uncompressed tar or `xJ` for an xz compressed tar.
+// When dest is empty, the archive is extracted into RUNNER_TEMP with a random name.
+func ExtractTar(ctx context.Context, file string, dest string, flags ...string) (string, error) {
+	if file == "" {
+		return "", errors.New("parameter 'file' is required")
+	}
+	dest, err := createExtractFolder(dest)
+	if err != nil {
+		return "", err
+	}
+
+	// Determine whether GNU tar
+	versionOutput, _ := exec.CommandContext(ctx, "tar", "--version").CombinedOutput()
+	isGnuTar := strings.Contains(strings.ToUpper(string(versionOutput)), "GNU TAR")
+
+	// Initialize args
+	args := append([]string(nil), flags...)
+	if len(args) == 0 {
+		args = []string{"xz"}
+	}
+	destArg, fileArg := dest, file
+	if runtime.GOOS == "windows" && isGnuTar {
+		args = append(args, "--force-local")
+		destArg = strings.ReplaceAll(dest, `\`, "/")
+		fileArg = strings.ReplaceAll(file, `\`, "/")
+	}
+	if isGnuTar {
+		// Suppress warnings when using GNU tar to extract archives created by BSD tar
+		args = append(args, "--warning=no-unknown-keyword", "--overwrite")
+	}
+	args = append(args, "-C", destArg, "-f", fileArg)
+
+	if err := run(ctx, "", "tar", args...); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// ExtractXar extracts a xar archive with the xar command, which is only available on macOS.
+// When dest is empty, the archive is extracted into RUNNER_TEMP with a random name.
+func ExtractXar(ctx context.Context, file string, dest string, flags ...string) (string, error) {
+	if runtime.GOOS != "darwin" {
+		return "", errors.New("extractXar() not supported on current OS")
+	}
+	if file == "" {
+		return "", errors.New("parameter 'file' is required")
+	}
+	dest, err := createExtractFolder(dest)
+	if err != nil {
+		return "", err
+	}
+
+	args := append([]string{"-x", "-C", dest, "-f", file}, flags...)
+	if err := run(ctx, "", "xar", args...); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// Extract7z extracts a 7z archive with the 7z command found at sevenZipPath, or in PATH when empty.
+// When dest is empty, the archive is extracted into RUNNER_TEMP with a random name.
+func Extract7z(ctx context.Context, file string, dest string, sevenZipPath string) (string, error) {
+	if file == "" {
+		return "", errors.New("parameter 'file' is required")
+	}
+	dest, err := createExtractFolder(dest)
+	if err != nil {
+		return "", err
+	}
+	if sevenZipPath == "" {
+		sevenZipPath = "7z"
+	}
+
+	file, err = filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	if err := run(ctx, dest, sevenZipPath, "x", "-bb1", "-bd", "-sccUTF-8", "-y", file); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// ExtractZip extracts a zip archive and returns the destination. Existing files are overwritten.
+// When dest is empty, the archive is extracted into RUNNER_TEMP with a random name.
+func ExtractZip(ctx context.Context, file string, dest string) (string, error) {
+	if file == "" {
+		return "", errors.New("parameter 'file' is required")
+	}
+	dest, err := createExtractFolder(dest)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := extractZipEntry(f, dest); err != nil {
+			return "", err
+		}
+	}
+	return dest, nil
+}
+
+func extractZipEntry(f *zip.File, dest string) error {
+	dest = filepath.Clean(dest)
+	target := filepath.Join(dest, filepath.FromSlash(f.Name))
+	if !isWithin(dest, target) {
+		return fmt.Errorf("zip entry %s is outside of the destination", f.Name)
+	}
+	// The symlinks of the archive must not be followed, as they could lead outside of the destination
+	if err := checkNoSymlinks(dest, filepath.Dir(target)); err != nil {
+		return fmt.Errorf("zip entry %s: %w", f.Name, err)
+	}
+
+	mode := f.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(target, 0o755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		linkTarget, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		link := filepath.FromSlash(string(linkTarget))
+		if filepath.IsAbs(link) || !isWithin(dest, filepath.Join(filepath.Dir(target), link)) {
+			return fmt.Errorf("zip entry %s links to %s outside of the destination", f.Name, linkTarget)
+		}
+		os.Remove(target)
+		return os.Symlink(link, target)
+	}
+	// Replace an existing symlink rather than writing through it
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// isWithin reports whether the path is dest or under dest. Both paths must be clean.
+func isWithin(dest, path string) bool {
+	return path == dest || strings.HasPrefix(path, dest+string(filepath.Separator))
+}
+
+// checkNoSymlinks returns an error when a directory between dest and dir is a symlink.
+func checkNoSymlinks(dest, dir string) error {
+	rel, err := filepath.Rel(dest, dir)
+	if err != nil || rel == "." {
+		return err
+	}
+	path := dest
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		path = filepath.Join(path, name)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", path)
+		}
+	}
+	return nil
+}
+
+func createExtractFolder(dest string) (string, error) {
+	if dest == "" {
+		// Create a temp folder
+		tempDir, err := getTempDirectory()
+		if err != nil {
+			return "", err
+		}
+		dest = filepath.Join(tempDir, newUUID())
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// run runs the command and includes its output in the error when it fails.
+func run(ctx context.Context, dir string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w\n%s", name, err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package toolcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ci-tools/toolkit/httpclient"
+	"github.com/ci-tools/toolkit/platform"
+	"github.com/ci-tools/toolkit/semver"
+)
+
+// ToolReleaseFile is a downloadable file of a release in versions-manifest.json.
+type ToolReleaseFile struct {
+	Filename string `json:"filename"`
+
+	// Platform is one of `aix`, `darwin`, `freebsd`, `linux`, `openbsd`, `sunos` and `win32`
+	Platform string `json:"platform"`
+
+	// PlatformVersion is an optional version or semver range of the OS, e.g. `20.04` for Ubuntu
+	PlatformVersion string `json:"platform_version,omitempty"`
+
+	// Arch is one of `arm`, `arm64`, `ia32`, `mips`, `mipsel`, `ppc`, `ppc64`, `s390`, `s390x`, `x32` and `x64`
+	Arch string `json:"arch"`
+
+	DownloadURL string `json:"download_url"`
+}
+
+// ToolRelease is a release in versions-manifest.json.
+type ToolRelease struct {
+	Version    string            `json:"version"`
+	Stable     bool              `json:"stable"`
+	ReleaseURL string            `json:"release_url"`
+	Files      []ToolReleaseFile `json:"files"`
+}
+
+// The root of the filesystem to read the Linux version files from, overridden by tests.
+var osReleaseRoot = "/"
+
+// GetManifestFromRepo fetches versions-manifest.json from the root of the repository branch.
+// branch defaults to `master`. auth, if not empty, is sent as the Authorization header.
+// client defaults to a client without retries. An invalid manifest results in no releases.
+func GetManifestFromRepo(ctx context.Context, owner, repo, auth, branch string, client *httpclient.Client) ([]ToolRelease, error) {
+	if branch == "" {
+		branch = "master"
+	}
+	if client == nil {
+		client = httpclient.NewClient("tool-cache", nil, nil)
+	}
+	treeURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s", owner, repo, branch)
+
+	headers := http.Header{}
+	if auth != "" {
+		headers.Set("Authorization", auth)
+	}
+
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			URL  string `json:"url"`
+		} `json:"tree"`
+	}
+	if _, err := client.GetJSON(ctx, treeURL, &tree, headers); err != nil {
+		return nil, err
+	}
+
+	manifestURL := ""
+	for _, item := range tree.Tree {
+		if item.Path == "versions-manifest.json" {
+			manifestURL = item.URL
+			break
+		}
+	}
+	if manifestURL == "" {
+		return []ToolRelease{}, nil
+	}
+
+	headers.Set("Accept", "application/vnd.github.VERSION.raw")
+	resp, err := client.Get(ctx, manifestURL, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{HTTPStatusCode: resp.StatusCode}
+	}
+	versionsRaw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shouldn't be needed but protects against invalid json saved with BOM
+	versionsRaw = []byte(strings.TrimPrefix(string(versionsRaw), "\uFEFF"))
+	releases := []ToolRelease{}
+	if err := json.Unmarshal(versionsRaw, &releases); err != nil {
+		return []ToolRelease{}, nil
+	}
+	return releases, nil
+}
+
+// FindFromManifest returns the first release satisfying versionSpec, with only the file matching
+// the current platform, the arch and the OS version. stable restricts the match to stable releases.
+// arch defaults to the architecture of the current process. Returns nil if there is no match.
+func FindFromManifest(versionSpec string, stable bool, manifest []ToolRelease, arch string) *ToolRelease {
+	if arch == "" {
+		arch = Arch()
+	}
+	platform := Platform()
+
+	for _, candidate := range manifest {
+		if !semver.Satisfies(candidate.Version, versionSpec, nil) || (stable && !candidate.Stable) {
+			continue
+		}
+		for _, item := range candidate.Files {
+			if item.Arch != arch || item.Platform != platform {
+				continue
+			}
+			if item.PlatformVersion != "" {
+				osVersion := getOSVersion()
+				if osVersion != item.PlatformVersion && !semver.Satisfies(osVersion, item.PlatformVersion, nil) {
+					continue
+				}
+			}
+			// Copy since the file list is reduced to the matching file
+			result := candidate
+			result.Files = []ToolReleaseFile{item}
+			return &result
+		}
+	}
+	return nil
+}
+
+// Platform returns the operating system in the naming of Node.js, e.g. `linux` or `win32`,
+// which is used for the platform of the manifest files.
+func Platform() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "win32"
+	case "solaris", "illumos":
+		return "sunos"
+	default:
+		return runtime.GOOS
+	}
+}
+
+// getOSVersion returns the OS version on macOS and Linux, e.g. `12.3` or `22.04`, or an empty string.
+func getOSVersion() string {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("sw_vers", "-productVersion").Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	case "linux":
+		return parseLinuxVersion(readLinuxVersionFile())
+	}
+	return ""
+}
+
+// readLinuxVersionFile reads /etc/lsb-release, falling back to /etc/os-release
+// since lsb_release is missing in some containers.
+func readLinuxVersionFile() string {
+	for _, name := range []string{"etc/lsb-release", "etc/os-release"} {
+		if contents, err := os.ReadFile(filepath.Join(osReleaseRoot, name)); err == nil {
+			return string(contents)
+		}
+	}
+	return ""
+}
+
+// parseLinuxVersion returns the DISTRIB_RELEASE of lsb-release or VERSION_ID of os-release, e.g.
+//
+//	DISTRIB_ID=Ubuntu
+//	DISTRIB_RELEASE=18.04
+//	DISTRIB_CODENAME=bionic
+//	DISTRIB_DESCRIPTION="Ubuntu 18.04.4 LTS"
+func parseLinuxVersion(contents string) string {
+	fields := platform.ParseOSRelease([]byte(contents))
+	if version := fields["VERSION_ID"]; version != "" {
+		return version
+	}
+	return fields["DISTRIB_RELEASE"]
+}
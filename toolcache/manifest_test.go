@@ -0,0 +1,179 @@
+package toolcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ci-tools/toolkit/httpclient"
+)
+
+func readManifestFixture(t *testing.T) []ToolRelease {
+	b, err := os.ReadFile(filepath.Join("testdata", "versions-manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest []ToolRelease
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+// setOSRelease writes the Linux version file under a temporary filesystem root.
+func setOSRelease(t *testing.T, name, contents string) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "etc"), 0o755)
+	if contents != "" {
+		os.WriteFile(filepath.Join(root, "etc", name), []byte(contents), 0o644)
+	}
+	prev := osReleaseRoot
+	osReleaseRoot = root
+	t.Cleanup(func() { osReleaseRoot = prev })
+}
+
+func Test_FindFromManifest(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the manifest fixture is filtered by the linux platform")
+	}
+	manifest := readManifestFixture(t)
+
+	ubuntu2204 := "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\n"
+	ubuntu2004 := "DISTRIB_ID=Ubuntu\nDISTRIB_RELEASE=20.04\nDISTRIB_CODENAME=focal\n"
+	debian := "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nVERSION_ID=\"12\"\nID=debian\n"
+
+	table := []struct {
+		name         string
+		osFile       string
+		osRelease    string
+		versionSpec  string
+		stable       bool
+		arch         string
+		expectedFile string
+	}{
+		{name: "exact platform version", osFile: "os-release", osRelease: ubuntu2204, versionSpec: "3.10.x", stable: true, arch: "x64", expectedFile: "sometool-3.10.8-linux-22.04-x64.tar.gz"},
+		{name: "lsb-release", osFile: "lsb-release", osRelease: ubuntu2004, versionSpec: "3.10", stable: true, arch: "x64", expectedFile: "sometool-3.10.8-linux-20.04-x64.tar.gz"},
+		{name: "no file for the distro", osFile: "os-release", osRelease: debian, versionSpec: "3.10", stable: true, arch: "x64", expectedFile: ""},
+		{name: "highest satisfying release with a matching file", osFile: "os-release", osRelease: debian, versionSpec: ">=3.9", stable: true, arch: "x64", expectedFile: "sometool-3.9.15-linux-x64.tar.gz"},
+		{name: "arch", osFile: "os-release", osRelease: ubuntu2204, versionSpec: "3.x", stable: true, arch: "arm64", expectedFile: "sometool-3.9.15-linux-arm64.tar.gz"},
+		{name: "unstable excluded", osFile: "os-release", osRelease: ubuntu2204, versionSpec: ">=3.11.0-beta.0", stable: true, arch: "x64", expectedFile: ""},
+		{name: "unstable included", osFile: "os-release", osRelease: ubuntu2204, versionSpec: ">=3.11.0-beta.0", stable: false, arch: "x64", expectedFile: "sometool-3.11.0-beta.1-linux-22.04-x64.tar.gz"},
+		{name: "platform version range with non-semver os version", osFile: "os-release", osRelease: ubuntu2004, versionSpec: "3.8", stable: true, arch: "x64", expectedFile: ""},
+		{name: "platform version range", osFile: "os-release", osRelease: "VERSION_ID=18.4.0\n", versionSpec: "3.8", stable: true, arch: "x64", expectedFile: "sometool-3.8.15-linux-ubuntu-x64.tar.gz"},
+		{name: "no version file", osFile: "os-release", osRelease: "", versionSpec: "3.10", stable: true, arch: "x64", expectedFile: ""},
+	}
+
+	for _, tt := range table {
+		setOSRelease(t, tt.osFile, tt.osRelease)
+		match := FindFromManifest(tt.versionSpec, tt.stable, manifest, tt.arch)
+		val := ""
+		if match != nil {
+			if len(match.Files) != 1 {
+				t.Fatalf("expected a single file, but result was %v.\ntest case: %s", match.Files, tt.name)
+			}
+			val = match.Files[0].Filename
+		}
+		if val != tt.expectedFile {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %s", tt.expectedFile, val, tt.name)
+		}
+	}
+
+	// The manifest itself is left untouched
+	if len(manifest[1].Files) != 4 {
+		t.Fatalf("expected the manifest to be left untouched, but was %v", manifest[1].Files)
+	}
+}
+
+func Test_ParseLinuxVersion(t *testing.T) {
+	table := []struct {
+		contents string
+		expected string
+	}{
+		{contents: "DISTRIB_ID=Ubuntu\nDISTRIB_RELEASE=18.04\nDISTRIB_CODENAME=bionic\nDISTRIB_DESCRIPTION=\"Ubuntu 18.04.4 LTS\"", expected: "18.04"},
+		{contents: "NAME=\"Ubuntu\"\nVERSION=\"22.04.1 LTS (Jammy Jellyfish)\"\nVERSION_ID=\"22.04\"", expected: "22.04"},
+		{contents: "NAME=\"Arch Linux\"\nID=arch", expected: ""},
+		{contents: "", expected: ""},
+	}
+
+	for _, tt := range table {
+		if val := parseLinuxVersion(tt.contents); val != tt.expected {
+			t.Fatalf("expected value is %q, but result was %q.", tt.expected, val)
+		}
+	}
+}
+
+// rewriteTransport sends every request to the test server.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_GetManifestFromRepo(t *testing.T) {
+	manifest, err := os.ReadFile(filepath.Join("testdata", "versions-manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/actions/sometool-versions/git/trees/main":
+			fmt.Fprint(w, `{"tree":[{"path":"README.md","url":"https://api.github.com/blobs/1"},{"path":"versions-manifest.json","url":"https://api.github.com/blobs/2"}]}`)
+		case "/repos/actions/invalid-versions/git/trees/master":
+			fmt.Fprint(w, `{"tree":[{"path":"versions-manifest.json","url":"https://api.github.com/blobs/3"}]}`)
+		case "/blobs/2":
+			if r.Header.Get("Accept") != "application/vnd.github.VERSION.raw" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write(append([]byte("\uFEFF"), manifest...))
+		case "/blobs/3":
+			fmt.Fprint(w, "not json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	client := httpclient.NewClient("tool-cache", nil, &httpclient.RequestOptions{Transport: &rewriteTransport{target: target}})
+	ctx := context.Background()
+
+	releases, err := GetManifestFromRepo(ctx, "actions", "sometool-versions", "token abc", "main", client)
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if len(releases) != 4 || releases[0].Version != "3.11.0-beta.1" || releases[1].Files[1].PlatformVersion != "20.04" {
+		t.Fatalf("unexpected releases: %v", releases)
+	}
+
+	for _, repo := range []string{"invalid-versions", "missing"} {
+		releases, err := GetManifestFromRepo(ctx, "actions", repo, "token abc", "", client)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if len(releases) != 0 {
+			t.Fatalf("expected no releases, but result was %v.\ntest case: %s", releases, repo)
+		}
+	}
+
+	if _, err := GetManifestFromRepo(ctx, "actions", "sometool-versions", "", "main", client); err == nil {
+		t.Fatal("errors are expected but not found.")
+	}
+}
@@ -0,0 +1,50 @@
+package toolcache
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// retryHelper retries an action with a random delay between minSeconds and maxSeconds.
+type retryHelper struct {
+	maxAttempts int
+	minSeconds  int
+	maxSeconds  int
+}
+
+func newRetryHelper(maxAttempts, minSeconds, maxSeconds int) *retryHelper {
+	if minSeconds > maxSeconds {
+		panic("min seconds should be less than or equal to max seconds")
+	}
+	if maxAttempts < 1 {
+		panic("max attempts should be greater than or equal to 1")
+	}
+	return &retryHelper{maxAttempts: maxAttempts, minSeconds: minSeconds, maxSeconds: maxSeconds}
+}
+
+// execute calls the action until it succeeds, the error is not retryable or the attempts are exhausted.
+func (r *retryHelper) execute(ctx context.Context, action func() error, isRetryable func(error) bool) error {
+	for attempt := 1; attempt < r.maxAttempts; attempt++ {
+		err := action()
+		if err == nil {
+			return nil
+		}
+		if isRetryable != nil && !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(r.getSleepAmount())
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return action()
+}
+
+func (r *retryHelper) getSleepAmount() time.Duration {
+	return time.Duration(rand.Intn(r.maxSeconds-r.minSeconds+1)+r.minSeconds) * time.Second
+}
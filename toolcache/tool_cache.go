@@ -0,0 +1,443 @@
+// Package toolcache downloads, extracts and caches tools, like @actions/tool-cache.
+//
+// Tools are cached in RUNNER_TOOL_CACHE/<tool>/<version>/<arch>, and a `<arch>.complete`
+// marker file is written next to the directory once the tool is completely cached.
+package toolcache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/ci-tools/toolkit/httpclient"
+	"github.com/ci-tools/toolkit/semver"
+)
+
+const userAgent = "actions/tool-cache"
+
+// The delay between download attempts, overridden by tests.
+var (
+	downloadRetryMinSeconds = 10
+	downloadRetryMaxSeconds = 20
+)
+
+// HTTPError is returned when the download responds with a status code other than 200.
+type HTTPError struct {
+	HTTPStatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("Unexpected HTTP response: %d", e.HTTPStatusCode)
+}
+
+// ChecksumError is returned when the downloaded file does not match the expected checksum.
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum mismatch: expected sha256 %s but was %s", e.Expected, e.Actual)
+}
+
+// DownloadOptions controls DownloadTool.
+type DownloadOptions struct {
+	/** Optional. Additional headers sent with the request */
+	Headers http.Header
+
+	/** Optional. The expected hex-encoded SHA-256 of the file. The file is deleted when it does not match */
+	SHA256 string
+}
+
+// DownloadTool downloads the url and returns the path to the downloaded file.
+// When dest is empty, the file is downloaded into RUNNER_TEMP with a random name.
+// auth, if not empty, is sent as the Authorization header. options may be nil.
+//
+// The download is attempted up to 3 times, except for 4xx responses other than 408 and 429.
+func DownloadTool(ctx context.Context, url string, dest string, auth string, options *DownloadOptions) (string, error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	if dest == "" {
+		tempDir, err := getTempDirectory()
+		if err != nil {
+			return "", err
+		}
+		dest = filepath.Join(tempDir, newUUID())
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+
+	headers := options.Headers.Clone()
+	if auth != "" {
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers.Set("Authorization", auth)
+	}
+
+	retryHelper := newRetryHelper(3, downloadRetryMinSeconds, downloadRetryMaxSeconds)
+	err := retryHelper.execute(ctx, func() error {
+		return downloadToolAttempt(ctx, url, dest, headers, options.SHA256)
+	}, func(err error) bool {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			// Don't retry anything less than 500, except 408 Request Timeout and 429 Too Many Requests
+			code := httpErr.HTTPStatusCode
+			if code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests {
+				return false
+			}
+		}
+		var checksumErr *ChecksumError
+		return !errors.As(err, &checksumErr) && ctx.Err() == nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func downloadToolAttempt(ctx context.Context, url, dest string, headers http.Header, checksum string) (err error) {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("Destination file path %s already exists", dest)
+	}
+
+	client := httpclient.NewClient(userAgent, nil, nil)
+	resp, err := client.Get(ctx, url, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{HTTPStatusCode: resp.StatusCode}
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		// Error, delete dest before retry
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); checksum != "" && !equalFoldHex(actual, checksum) {
+		return &ChecksumError{Expected: checksum, Actual: actual}
+	}
+	return nil
+}
+
+func equalFoldHex(a, b string) bool {
+	x, err1 := hex.DecodeString(a)
+	y, err2 := hex.DecodeString(b)
+	return err1 == nil && err2 == nil && string(x) == string(y)
+}
+
+// CacheDir caches a directory and installs it into the tool cacheDir.
+// arch defaults to the architecture of the current process. Returns the path to the cached tool.
+func CacheDir(sourceDir, tool, version, arch string) (string, error) {
+	if arch == "" {
+		arch = Arch()
+	}
+	info, err := os.Stat(sourceDir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", errors.New("sourceDir is not a directory")
+	}
+
+	// Create the tool dir
+	destPath, err := createToolPath(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+
+	// Copy each child item. Do not move. Move can fail on Windows due to anti-virus software
+	// having an open handle on a file.
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if err := copyItem(filepath.Join(sourceDir, entry.Name()), filepath.Join(destPath, entry.Name())); err != nil {
+			return "", err
+		}
+	}
+
+	// Write .complete
+	if err := completeToolPath(tool, version, arch); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// CacheFile caches a downloaded file (GUID) and installs it into the tool cache with the given targetFile name.
+// arch defaults to the architecture of the current process. Returns the path to the cached tool directory.
+func CacheFile(sourceFile, targetFile, tool, version, arch string) (string, error) {
+	if arch == "" {
+		arch = Arch()
+	}
+	info, err := os.Stat(sourceFile)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", errors.New("sourceFile is not a file")
+	}
+
+	// Create the tool dir
+	destFolder, err := createToolPath(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+
+	// Copy instead of move. Move can fail on Windows due to anti-virus software having an open handle on a file.
+	if err := copyItem(sourceFile, filepath.Join(destFolder, targetFile)); err != nil {
+		return "", err
+	}
+
+	// Write .complete
+	if err := completeToolPath(tool, version, arch); err != nil {
+		return "", err
+	}
+	return destFolder, nil
+}
+
+// Find finds the path to a tool version in the local installed tool cache.
+// versionSpec is an explicit version or a semver range, e.g. `1.x` or `>=3.8 <3.11`.
+// arch defaults to the architecture of the current process. Returns an empty string if not found.
+func Find(toolName, versionSpec, arch string) (string, error) {
+	if toolName == "" {
+		return "", errors.New("toolName parameter is required")
+	}
+	if versionSpec == "" {
+		return "", errors.New("versionSpec parameter is required")
+	}
+	if arch == "" {
+		arch = Arch()
+	}
+
+	// Attempt to resolve an explicit version
+	if !isExplicitVersion(versionSpec) {
+		localVersions, err := FindAllVersions(toolName, arch)
+		if err != nil {
+			return "", err
+		}
+		versionSpec = evaluateVersions(localVersions, versionSpec)
+	}
+
+	// Check for the explicit version in the cache
+	if versionSpec == "" {
+		return "", nil
+	}
+	cacheDir, err := getCacheDirectory()
+	if err != nil {
+		return "", err
+	}
+	cachePath := filepath.Join(cacheDir, toolName, semver.Clean(versionSpec, nil), arch)
+	if isDirectory(cachePath) && exists(cachePath+".complete") {
+		return cachePath, nil
+	}
+	return "", nil
+}
+
+// FindAllVersions returns the explicit versions of the tool completely cached for the arch.
+// arch defaults to the architecture of the current process.
+func FindAllVersions(toolName, arch string) ([]string, error) {
+	if arch == "" {
+		arch = Arch()
+	}
+	cacheDir, err := getCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+	toolPath := filepath.Join(cacheDir, toolName)
+
+	entries, err := os.ReadDir(toolPath)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, entry := range entries {
+		if !isExplicitVersion(entry.Name()) {
+			continue
+		}
+		fullPath := filepath.Join(toolPath, entry.Name(), arch)
+		if exists(fullPath) && exists(fullPath+".complete") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions, nil
+}
+
+func isExplicitVersion(versionSpec string) bool {
+	return semver.Clean(versionSpec, nil) != ""
+}
+
+// evaluateVersions returns the highest version satisfying the spec, or an empty string.
+func evaluateVersions(versions []string, versionSpec string) string {
+	return semver.MaxSatisfying(versions, versionSpec, nil)
+}
+
+func createToolPath(tool, version, arch string) (string, error) {
+	folderPath, err := toolPath(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(folderPath); err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(folderPath + ".complete"); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(folderPath, 0o755); err != nil {
+		return "", err
+	}
+	return folderPath, nil
+}
+
+func completeToolPath(tool, version, arch string) error {
+	folderPath, err := toolPath(tool, version, arch)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(folderPath+".complete", nil, 0o644)
+}
+
+func toolPath(tool, version, arch string) (string, error) {
+	cacheDir, err := getCacheDirectory()
+	if err != nil {
+		return "", err
+	}
+	if cleaned := semver.Clean(version, nil); cleaned != "" {
+		version = cleaned
+	}
+	return filepath.Join(cacheDir, tool, version, arch), nil
+}
+
+func getCacheDirectory() (string, error) {
+	cacheDirectory := os.Getenv("RUNNER_TOOL_CACHE")
+	if cacheDirectory == "" {
+		return "", errors.New("Expected RUNNER_TOOL_CACHE to be defined")
+	}
+	return cacheDirectory, nil
+}
+
+func getTempDirectory() (string, error) {
+	tempDirectory := os.Getenv("RUNNER_TEMP")
+	if tempDirectory == "" {
+		return "", errors.New("Expected RUNNER_TEMP to be defined")
+	}
+	return tempDirectory, nil
+}
+
+// Arch returns the architecture of the current process in the naming of Node.js, e.g. `x64` or `arm64`,
+// which is used for the arch directories of the tool cache.
+func Arch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	case "ppc64le":
+		return "ppc64"
+	case "mips64le":
+		return "mips64el"
+	case "mipsle":
+		return "mipsel"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func isDirectory(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// copyItem copies a file, a symbolic link or a directory recursively, preserving the modes.
+func copyItem(src, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dest)
+
+	case info.IsDir():
+		if err := os.MkdirAll(dest, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyItem(filepath.Join(src, entry.Name()), filepath.Join(dest, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
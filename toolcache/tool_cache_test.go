@@ -0,0 +1,353 @@
+package toolcache
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ci-tools/toolkit/semver"
+)
+
+func setupDirectories(t *testing.T) (cacheDir, tempDir string) {
+	cacheDir = filepath.Join(t.TempDir(), "cache")
+	tempDir = filepath.Join(t.TempDir(), "temp")
+	t.Setenv("RUNNER_TOOL_CACHE", cacheDir)
+	t.Setenv("RUNNER_TEMP", tempDir)
+
+	minSeconds, maxSeconds := downloadRetryMinSeconds, downloadRetryMaxSeconds
+	downloadRetryMinSeconds, downloadRetryMaxSeconds = 0, 0
+	t.Cleanup(func() {
+		downloadRetryMinSeconds, downloadRetryMaxSeconds = minSeconds, maxSeconds
+	})
+	return cacheDir, tempDir
+}
+
+func Test_DownloadTool(t *testing.T) {
+	_, tempDir := setupDirectories(t)
+	content := []byte("tool content")
+	sum := sha256.Sum256(content)
+
+	var flaky int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tool":
+			w.Write(content)
+		case "/auth":
+			if r.Header.Get("Authorization") != "token abc" || r.Header.Get("X-Custom") != "custom" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(content)
+		case "/flaky":
+			if atomic.AddInt32(&flaky, 1) < 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	p, err := DownloadTool(ctx, server.URL+"/tool", "", "", &DownloadOptions{SHA256: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if filepath.Dir(p) != tempDir {
+		t.Fatalf("expected the file to be downloaded into %s, but was %s", tempDir, p)
+	}
+	if b, _ := os.ReadFile(p); string(b) != string(content) {
+		t.Fatalf("unexpected content: %s", b)
+	}
+
+	dest := filepath.Join(t.TempDir(), "sub", "tool")
+	if _, err := DownloadTool(ctx, server.URL+"/auth", dest, "token abc", &DownloadOptions{Headers: http.Header{"X-Custom": {"custom"}}}); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if _, err := DownloadTool(ctx, server.URL+"/tool", dest, "", nil); err == nil {
+		t.Fatal("errors are expected when the destination exists")
+	}
+
+	if _, err := DownloadTool(ctx, server.URL+"/flaky", "", "", nil); err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if flaky != 3 {
+		t.Fatalf("expected 3 attempts, but was %d", flaky)
+	}
+
+	var httpErr *HTTPError
+	if _, err := DownloadTool(ctx, server.URL+"/missing", "", "", nil); !errors.As(err, &httpErr) || httpErr.HTTPStatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 HTTPError, but was %v", err)
+	}
+
+	dest = filepath.Join(t.TempDir(), "mismatch")
+	var checksumErr *ChecksumError
+	if _, err := DownloadTool(ctx, server.URL+"/tool", dest, "", &DownloadOptions{SHA256: hex.EncodeToString(make([]byte, 32))}); !errors.As(err, &checksumErr) {
+		t.Fatalf("expected a ChecksumError, but was %v", err)
+	}
+	if exists(dest) {
+		t.Fatal("expected the mismatched file to be deleted")
+	}
+}
+
+func writeArchiveFixtures(t *testing.T) (tarGz, zipFile string) {
+	dir := t.TempDir()
+	files := []struct {
+		name    string
+		content string
+		mode    int64
+	}{
+		{name: "bin/tool", content: "#!/bin/sh\necho tool\n", mode: 0o755},
+		{name: "README", content: "readme", mode: 0o644},
+	}
+
+	tarGz = filepath.Join(dir, "tool.tar.gz")
+	f, err := os.Create(tarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: file.mode, Size: int64(len(file.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(file.content))
+	}
+	tw.Close()
+	gw.Close()
+	f.Close()
+
+	zipFile = filepath.Join(dir, "tool.zip")
+	f, err = os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		h := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+		h.SetMode(os.FileMode(file.mode))
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(file.content))
+	}
+	zw.Close()
+	f.Close()
+	return tarGz, zipFile
+}
+
+func Test_Extract(t *testing.T) {
+	_, tempDir := setupDirectories(t)
+	tarGz, zipFile := writeArchiveFixtures(t)
+	ctx := context.Background()
+
+	extractors := map[string]func() (string, error){
+		"tar": func() (string, error) { return ExtractTar(ctx, tarGz, "") },
+		"zip": func() (string, error) { return ExtractZip(ctx, zipFile, "") },
+	}
+	for name, extract := range extractors {
+		dest, err := extract()
+		if err != nil {
+			t.Fatalf("errors are not expected but found: %v\ntest case: %s", err, name)
+		}
+		if filepath.Dir(dest) != tempDir {
+			t.Fatalf("expected the archive to be extracted into %s, but was %s", tempDir, dest)
+		}
+		info, err := os.Stat(filepath.Join(dest, "bin", "tool"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o755 {
+			t.Fatalf("expected mode is 0755, but result was %v.\ntest case: %s", info.Mode().Perm(), name)
+		}
+		if b, _ := os.ReadFile(filepath.Join(dest, "README")); string(b) != "readme" {
+			t.Fatalf("unexpected content: %s\ntest case: %s", b, name)
+		}
+	}
+
+	if _, err := ExtractTar(ctx, zipFile, ""); err == nil {
+		t.Fatal("errors are expected when tar fails")
+	}
+}
+
+type zipEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+// writeZip writes a zip archive of entries, which are symlinks when their link is set.
+func writeZip(t *testing.T, entries []zipEntry) string {
+	zipFile := filepath.Join(t.TempDir(), "evil.zip")
+	f, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		h := &zip.FileHeader{Name: entry.name}
+		content := entry.content
+		if entry.link != "" {
+			h.SetMode(os.ModeSymlink | 0o777)
+			content = entry.link
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	f.Close()
+	return zipFile
+}
+
+func Test_ExtractZipRejectsTraversal(t *testing.T) {
+	setupDirectories(t)
+	outside := t.TempDir()
+	table := []struct {
+		name    string
+		entries []zipEntry
+	}{
+		{name: "parent path", entries: []zipEntry{{name: "../evil", content: "evil"}}},
+		{name: "absolute link", entries: []zipEntry{{name: "lib", link: outside}, {name: "lib/evil", content: "evil"}}},
+		{name: "relative link", entries: []zipEntry{{name: "lib", link: "../../.."}, {name: "lib/evil", content: "evil"}}},
+		{name: "nested link", entries: []zipEntry{{name: "a/lib", link: "../.."}, {name: "a/lib/evil", content: "evil"}}},
+	}
+	for _, tt := range table {
+		if _, err := ExtractZip(context.Background(), writeZip(t, tt.entries), ""); err == nil {
+			t.Fatalf("errors are expected but not found.\ntest case: %s", tt.name)
+		}
+		if exists(filepath.Join(outside, "evil")) {
+			t.Fatalf("expected nothing to be written outside of the destination.\ntest case: %s", tt.name)
+		}
+	}
+
+	// An existing link in the destination is not written through
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "lib")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractZip(context.Background(), writeZip(t, []zipEntry{{name: "lib/evil", content: "evil"}}), dest); err == nil {
+		t.Fatal("errors are expected but not found.")
+	}
+	if exists(filepath.Join(outside, "evil")) {
+		t.Fatal("expected nothing to be written outside of the destination.")
+	}
+
+	// The links within the destination are extracted
+	dest, err := ExtractZip(context.Background(), writeZip(t, []zipEntry{
+		{name: "lib/libtool.so.1", content: "tool"},
+		{name: "lib/libtool.so", link: "libtool.so.1"},
+	}), "")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dest, "lib", "libtool.so")); string(b) != "tool" {
+		t.Fatalf("unexpected content: %s", b)
+	}
+}
+
+func Test_CacheAndFind(t *testing.T) {
+	cacheDir, _ := setupDirectories(t)
+
+	source := t.TempDir()
+	os.MkdirAll(filepath.Join(source, "bin"), 0o755)
+	os.WriteFile(filepath.Join(source, "bin", "tool"), []byte("tool"), 0o755)
+
+	for _, version := range []string{"1.1.0", "1.2.3", "v2.0.0", "3.0.0-beta.1"} {
+		toolPath, err := CacheDir(source, "my-tool", version, "x64")
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		if expected := filepath.Join(cacheDir, "my-tool", semver.Clean(version, nil), "x64"); toolPath != expected {
+			t.Fatalf("expected value is %s, but result was %s.", expected, toolPath)
+		}
+		if !exists(filepath.Join(toolPath, "bin", "tool")) || !exists(toolPath+".complete") {
+			t.Fatalf("expected the tool and marker to be cached in %s", toolPath)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "download")
+	os.WriteFile(file, []byte("single file"), 0o644)
+	toolPath, err := CacheFile(file, "tool.exe", "my-tool", "1.5.0", "arm64")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(toolPath, "tool.exe")); string(b) != "single file" {
+		t.Fatalf("unexpected content: %s", b)
+	}
+
+	// Incomplete versions are ignored
+	os.MkdirAll(filepath.Join(cacheDir, "my-tool", "1.9.0", "x64"), 0o755)
+	// Non-version directories are ignored
+	os.MkdirAll(filepath.Join(cacheDir, "my-tool", "latest", "x64"), 0o755)
+	os.WriteFile(filepath.Join(cacheDir, "my-tool", "latest", "x64.complete"), nil, 0o644)
+
+	versions, err := FindAllVersions("my-tool", "x64")
+	if err != nil {
+		t.Fatal("errors are not expected but found:", err)
+	}
+	sort.Strings(versions)
+	if expected := []string{"1.1.0", "1.2.3", "2.0.0", "3.0.0-beta.1"}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected value is %v, but result was %v.", expected, versions)
+	}
+
+	table := []struct {
+		versionSpec string
+		arch        string
+		expected    string
+	}{
+		{versionSpec: "1.2.3", arch: "x64", expected: "1.2.3"},
+		{versionSpec: "v1.2.3", arch: "x64", expected: "1.2.3"},
+		{versionSpec: "1.x", arch: "x64", expected: "1.2.3"},
+		{versionSpec: "^1.1", arch: "x64", expected: "1.2.3"},
+		{versionSpec: "~1.1", arch: "x64", expected: "1.1.0"},
+		{versionSpec: ">=1.0.0 <1.2.0", arch: "x64", expected: "1.1.0"},
+		{versionSpec: "1.1.0 - 1.2", arch: "x64", expected: "1.2.3"},
+		{versionSpec: "*", arch: "x64", expected: "2.0.0"},
+		{versionSpec: "3.0.0-beta.1", arch: "x64", expected: "3.0.0-beta.1"},
+		{versionSpec: ">=3.0.0-beta.0", arch: "x64", expected: "3.0.0-beta.1"},
+		{versionSpec: "1.9.0", arch: "x64", expected: ""},
+		{versionSpec: "4.x", arch: "x64", expected: ""},
+		{versionSpec: "1.x", arch: "arm64", expected: "1.5.0"},
+		{versionSpec: "1.x", arch: "ia32", expected: ""},
+	}
+
+	for _, tt := range table {
+		val, err := Find("my-tool", tt.versionSpec, tt.arch)
+		if err != nil {
+			t.Fatal("errors are not expected but found:", err)
+		}
+		expected := ""
+		if tt.expected != "" {
+			expected = filepath.Join(cacheDir, "my-tool", tt.expected, tt.arch)
+		}
+		if val != expected {
+			t.Fatalf("expected value is %q, but result was %q.\ntest case: %v", expected, val, tt)
+		}
+	}
+
+	if _, err := Find("", "1.x", ""); err == nil {
+		t.Fatal("errors are expected when toolName is empty")
+	}
+	if _, err := Find("my-tool", "", ""); err == nil {
+		t.Fatal("errors are expected when versionSpec is empty")
+	}
+}